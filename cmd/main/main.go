@@ -5,12 +5,15 @@ import (
 	"flag"
 	"io"
 	"log"
+	"metalink/cmd/osm-zone-parser/mappers"
 	"metalink/internal/api"
 	"metalink/internal/config"
+	"metalink/internal/logx"
 	"metalink/internal/postgres"
 	"metalink/internal/redis"
 	"metalink/internal/service/target"
 	"metalink/internal/service/zone"
+	"metalink/internal/util"
 	"metalink/internal/worker"
 	"os"
 	"os/signal"
@@ -25,6 +28,11 @@ import (
 func main() {
 	// Parse command line flags
 	playgroundFlag := flag.Bool("playground", false, "Run in playground mode")
+	seedFlag := flag.String("seed", "", "Seed for reproducible target IDs in playground mode (empty = random)")
+	seedRoutesFlag := flag.String("seed-routes", "", "File of encoded route polylines, one per line, playground targets pick from")
+	seedBBoxFlag := flag.String("seed-bbox", "", "Generate random playground routes inside minLat,minLng,maxLat,maxLng (ignored with -seed-routes)")
+	routeSeedFlag := flag.Int64("route-seed", 0, "Seed for reproducible playground routes")
+	importTargetsFlag := flag.String("import-targets", "", "Import targets from a GeoJSON file of Point features and exit")
 	flag.Parse()
 
 	setupLogging()
@@ -33,19 +41,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	configureLogLevel(cfg)
+
+	if err := mappers.InitBuildingEffectsConfig(); err != nil {
+		log.Fatalf("Failed to load building effects config: %v", err)
+	}
 
 	initializeDatabaseAndCache(cfg)
 	defer closeConnections()
 
-	setupSignalHandler()
+	if *importTargetsFlag != "" {
+		setupSignalHandler(nil)
+		importTargets(*importTargetsFlag)
+		return
+	}
 
 	// Use the flag value instead of hardcoded variable
 	if *playgroundFlag {
-		playground()
+		setupSignalHandler(nil)
+		playground(seedOptions(*seedFlag, *seedRoutesFlag, *seedBBoxFlag, *routeSeedFlag))
 		return
 	}
 
-	targetService := initializeServices()
+	targetService := initializeServices(cfg)
+	setupSignalHandler(targetService)
 	startWorkers(targetService)
 
 	reportMemoryStats()
@@ -68,6 +87,16 @@ func setupLogging() {
 	log.SetOutput(multiWriter)
 }
 
+// configureLogLevel applies the logx level and format from the config
+func configureLogLevel(cfg config.Config) {
+	level, err := logx.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Printf("Invalid LOG_LEVEL, using info: %v", err)
+	}
+	logx.SetLevel(level)
+	logx.SetJSON(cfg.LogJSON)
+}
+
 func loadConfiguration() (config.Config, error) {
 	// Try loading from config package first
 	cfg, err := config.LoadConfig()
@@ -95,17 +124,41 @@ func getEnvWithDefault(key, defaultValue string) string {
 
 func initializeDatabaseAndCache(cfg config.Config) {
 	// Initialize PostgreSQL
-	postgres.Init(cfg.DBUrl)
+	if _, err := postgres.Init(cfg.DBUrl); err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL: %v", err)
+	}
 
 	// Initialize Redis
-	redis.Init(cfg.RedisUrl)
+	if _, err := redis.Init(cfg.RedisUrl); err != nil {
+		log.Fatalf("Failed to initialize Redis: %v", err)
+	}
 }
 
-func initializeServices() *target.TargetService {
+func initializeServices(cfg config.Config) *target.TargetService {
 	// Initialize target service
 	targetService := target.GetTargetService()
 	ctx := context.Background()
 
+	// Limit processing to the configured active hours
+	if cfg.ActiveHours != "" {
+		window, err := util.ParseTimeWindow(cfg.ActiveHours)
+		if err != nil {
+			log.Fatalf("Invalid ACTIVE_HOURS: %v", err)
+		}
+		targetService.ActiveWindow = window
+		log.Printf("Targets are processed only within %s UTC", window)
+	}
+
+	targetService.ZoneTransitionsChannel = cfg.ZoneTransitionsChannel
+	if cfg.ZoneTransitionsChannel != "" {
+		log.Printf("Publishing zone transitions to Redis channel %q", cfg.ZoneTransitionsChannel)
+	}
+	targetService.TrackZoneOccupancy = cfg.TrackZoneOccupancy
+	targetService.CompactMovement = cfg.CompactTargetMovement
+	if cfg.CompactTargetMovement {
+		log.Printf("Moving targets from the compact struct-of-arrays store")
+	}
+
 	// Load data from PostgreSQL and Redis
 	if err := targetService.InitService(ctx); err != nil {
 		log.Fatalf("Failed to initialize target service: %v", err)
@@ -113,6 +166,13 @@ func initializeServices() *target.TargetService {
 
 	// Initialize zone service
 	zoneService := zone.GetZoneService()
+	zoneService.ForceRecalcEffects = cfg.ForceRecalcEffects
+	zoneService.MaxViewportZones = cfg.MaxViewportZones
+	zoneService.GradientEffects = cfg.GradientEffects
+	zoneService.GradientEdgeWeight = cfg.GradientEdgeWeight
+	if cfg.GradientEffects {
+		log.Printf("Zone effects fall off to %.2f at zone edges", cfg.GradientEdgeWeight)
+	}
 	if err := zoneService.InitService(ctx); err != nil {
 		log.Fatalf("Failed to initialize zone service: %v", err)
 	}
@@ -138,20 +198,62 @@ func runAPIServer(cfg config.Config) {
 		"dbUrl":    cfg.DBUrl,
 		"redisUrl": cfg.RedisUrl,
 	}
-	api.SetupRouter(r, config)
+	opts := api.RouterOptions{
+		RateLimitRPS:   cfg.RateLimitRPS,
+		RateLimitBurst: cfg.RateLimitBurst,
+		AccessLog:      cfg.AccessLog,
+	}
+	if opts.RateLimitRPS > 0 {
+		log.Printf("Rate limiting /api to %.1f requests/s per client (burst %d)", opts.RateLimitRPS, opts.RateLimitBurst)
+	}
+	api.SetupRouter(r, config, opts)
 
 	// Start the server
 	r.Run(cfg.Port)
 }
 
-func playground() {
+// seedOptions builds the playground seeding options from the command line flags
+func seedOptions(idSeed, routesPath, bbox string, routeSeed int64) target.SeedOptions {
+	opts := target.SeedOptions{IDSeed: idSeed, RouteSeed: routeSeed}
+
+	if routesPath != "" {
+		routes, err := target.LoadSeedRoutes(routesPath)
+		if err != nil {
+			log.Fatalf("Failed to load seed routes: %v", err)
+		}
+		opts.Routes = routes
+		log.Printf("Seeded targets pick from %d routes", len(routes))
+	} else if bbox != "" {
+		bounds, err := util.ParseBounds(bbox)
+		if err != nil {
+			log.Fatalf("Invalid -seed-bbox: %v", err)
+		}
+		opts.RouteBounds = &bounds
+		log.Printf("Seeded targets walk random routes inside %s", bbox)
+	}
+
+	return opts
+}
+
+func playground(opts target.SeedOptions) {
 	log.Println(">>> PLAYGROUND <<<")
 	log.Println(">>> PLAYGROUND <<<")
 	log.Println(">>> PLAYGROUND <<<")
 
 	targetService := target.GetTargetService()
-	targetService.DeleteAllRedisTargets()
-	targetService.SeedTestTargetsPGParallel(1000000)
+	if err := targetService.DeleteAllRedisTargets(); err != nil {
+		log.Fatalf("Failed to delete Redis targets: %v", err)
+	}
+	if err := targetService.SeedTestTargetsViaCopy(1000000, opts); err != nil {
+		log.Fatalf("Failed to seed test targets: %v", err)
+	}
+}
+
+func importTargets(path string) {
+	targetService := target.GetTargetService()
+	if _, err := targetService.ImportTargetsFromGeoJSON(path); err != nil {
+		log.Fatalf("Failed to import targets: %v", err)
+	}
 }
 
 func reportMemoryStats() {
@@ -178,13 +280,28 @@ func closeConnections() {
 	log.Println("PostgreSQL and Redis connections closed successfully")
 }
 
-func setupSignalHandler() {
+func setupSignalHandler(targetService *target.TargetService) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
-		log.Println("Shutdown signal received, closing connections...")
-		closeConnections()
+		log.Println("Shutdown signal received")
+		shutdown(targetService)
 		os.Exit(0)
 	}()
 }
+
+// shutdown flushes in-memory targets (if the service is running) and closes connections
+func shutdown(targetService *target.TargetService) {
+	if targetService != nil {
+		log.Println("Flushing targets before shutdown...")
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownFlushTimeout)
+		if err := targetService.Shutdown(ctx); err != nil {
+			log.Printf("Error flushing targets on shutdown: %v", err)
+		}
+		cancel()
+	}
+
+	log.Println("Closing connections...")
+	closeConnections()
+}
@@ -3,15 +3,17 @@ package parser_db
 import (
 	"fmt"
 	"log"
-	"math"
 	"time"
 
 	parser_model "metalink/cmd/osm-zone-parser/models"
+	"metalink/internal/config"
 	"metalink/internal/model"
-	pg "metalink/internal/postgres"
+	"metalink/internal/util"
+	"metalink/internal/util/geoproj"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // QueryZonesFromDB queries zones from the database that overlap with the given bounding box.
@@ -29,14 +31,11 @@ func QueryZonesFromDB(minLat, minLng, maxLat, maxLng float64, bufferMeters float
 	log.Printf("Finding zones in bounding box [%.6f, %.6f] to [%.6f, %.6f] with %.1f meter buffer",
 		minLat, minLng, maxLat, maxLng, bufferMeters)
 
-	// Convert buffer distance from meters to approximate degrees
-	// This is a simplification - 1 degree of latitude is ~111km at the equator
-	bufferLatDegrees := bufferMeters / 111000.0 // roughly 111km per degree of latitude
-
-	// For longitude, the distance varies with latitude
-	// At the equator, 1 degree of longitude is ~111km, but it decreases with latitude
+	// Convert buffer distance from meters to degrees at the middle of the box
+	// Longitude degrees shrink towards the poles
 	meanLat := (minLat + maxLat) / 2.0
-	bufferLngDegrees := bufferMeters / (111000.0 * math.Cos(meanLat*math.Pi/180.0))
+	bufferLatDegrees := geoproj.MetersToDegreesLat(bufferMeters, meanLat)
+	bufferLngDegrees := geoproj.MetersToDegreesLon(bufferMeters, meanLat)
 
 	// Extend the bounding box by the buffer distance
 	extendedMinLat := minLat - bufferLatDegrees
@@ -48,9 +47,14 @@ func QueryZonesFromDB(minLat, minLng, maxLat, maxLng float64, bufferMeters float
 		extendedMinLat, extendedMinLng, extendedMaxLat, extendedMaxLng)
 
 	// Check if there are any zones in the database
-	db := pg.GetDB()
+	db, err := getDB()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrZoneQuery, err)
+	}
 	var count int64
-	db.Model(&model.ZonePG{}).Count(&count)
+	if err := db.Model(&model.ZonePG{}).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("%w: failed to count zones: %w", ErrZoneQuery, err)
+	}
 	log.Printf("Total zones in database: %d", count)
 
 	// For debugging, let's get a few zones and check their structure
@@ -85,7 +89,7 @@ func QueryZonesFromDB(minLat, minLng, maxLat, maxLng float64, bufferMeters float
 	).Find(&pgZones)
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("database query failed: %w", result.Error)
+		return nil, fmt.Errorf("%w: database query failed: %w", ErrZoneQuery, result.Error)
 	}
 
 	log.Printf("Found %d zones intersecting with the extended bounding box", len(pgZones))
@@ -99,9 +103,28 @@ func QueryZonesFromDB(minLat, minLng, maxLat, maxLng float64, bufferMeters float
 	return zones, nil
 }
 
+// zoneUpsertColumns are the columns overwritten when an upserted zone already exists
+// created_at is deliberately missing so a re-saved zone keeps its original creation time
+var zoneUpsertColumns = []string{
+	"name", "top_left_lat_lon", "top_right_lat_lon", "bottom_left_lat_lon", "bottom_right_lat_lon", "geometry",
+	"buildings", "water_bodies", "poi_stats", "zone_effects", "updated_at", "deleted_at",
+}
+
 // saveUpdatedZonesToDB saves updated zones back to the database using UPSERT
+// New zones get created_at set once; existing zones keep theirs
 func SaveUpdatedZonesToDB(zones []*model.Zone) error {
-	db := pg.GetDB()
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZoneSave, err)
+	}
+
+	// Precompute effects once, before any batch is sent, so services can skip the calculation on startup
+	// Use the incremental accumulator if effects were tracked during distribution
+	for _, zone := range zones {
+		if !zone.FinalizeEffects() {
+			zone.CalculateEffects()
+		}
+	}
 
 	// Process in batches
 	batchSize := 50
@@ -112,35 +135,35 @@ func SaveUpdatedZonesToDB(zones []*model.Zone) error {
 		}
 
 		batch := zones[i:end]
-
-		// Convert to PG models and upsert
-		err := db.Transaction(func(tx *gorm.DB) error {
-			for _, zone := range batch {
-				now := time.Now()
-				pgZone := model.ZonePG{
-					ID:                zone.ID,
-					Name:              zone.Name,
-					TopLeftLatLon:     model.Float64Slice(zone.TopLeftLatLon),
-					TopRightLatLon:    model.Float64Slice(zone.TopRightLatLon),
-					BottomLeftLatLon:  model.Float64Slice(zone.BottomLeftLatLon),
-					BottomRightLatLon: model.Float64Slice(zone.BottomRightLatLon),
-					Buildings:         zone.Buildings,
-					WaterBodies:       zone.WaterBodies,
-					UpdatedAt:         now,
-					CreatedAt:         now, // Set CreatedAt for new records
-				}
-
-				// Use Save method which performs UPSERT (INSERT or UPDATE)
-				result := tx.Save(&pgZone)
-				if result.Error != nil {
-					return result.Error
-				}
+		now := time.Now()
+
+		// Convert to PG models
+		pgZones := make([]model.ZonePG, len(batch))
+		for j, zone := range batch {
+			pgZones[j] = model.ZonePG{
+				ID:                zone.ID,
+				Name:              zone.Name,
+				TopLeftLatLon:     model.Float64Slice(zone.TopLeftLatLon),
+				TopRightLatLon:    model.Float64Slice(zone.TopRightLatLon),
+				BottomLeftLatLon:  model.Float64Slice(zone.BottomLeftLatLon),
+				BottomRightLatLon: model.Float64Slice(zone.BottomRightLatLon),
+				Geometry:          zone.Geometry,
+				Buildings:         zone.Buildings,
+				WaterBodies:       zone.WaterBodies,
+				POIStats:          zone.POIStats,
+				ZoneEffects:       model.ZoneEffects(zone.Effects),
+				UpdatedAt:         now,
+				CreatedAt:         now, // Only used for new records, see zoneUpsertColumns
 			}
-			return nil
+		}
+
+		// Upsert the batch in one statement (INSERT ... ON CONFLICT (id) DO UPDATE), retrying transient failures
+		err := util.WithRetry(config.PGSaveRetryAttempts, config.PGSaveRetryBackoff, func() error {
+			return upsertZones(db, pgZones).Error
 		})
 
 		if err != nil {
-			return fmt.Errorf("failed to upsert zones batch %d-%d: %w", i, end, err)
+			return fmt.Errorf("%w: failed to upsert zones batch %d-%d: %w", ErrZoneSave, i, end, err)
 		}
 
 		log.Printf("Upserted zone batch %d-%d", i, end)
@@ -149,25 +172,40 @@ func SaveUpdatedZonesToDB(zones []*model.Zone) error {
 	return nil
 }
 
+// upsertZones inserts new zones and updates existing ones in one statement, keeping their created_at
+func upsertZones(db *gorm.DB, pgZones []model.ZonePG) *gorm.DB {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(zoneUpsertColumns),
+	}).Create(&pgZones)
+}
+
 // clearAllZonesFromDB removes all zones from the database
 func ClearAllZonesFromDB() error {
-	db := pg.GetDB()
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZoneDelete, err)
+	}
 
 	log.Println("Clearing all zones from database...")
 
 	// Delete all zones
 	result := db.Exec("DELETE FROM zones")
 	if result.Error != nil {
-		return fmt.Errorf("failed to clear zones from database: %w", result.Error)
+		return fmt.Errorf("%w: failed to clear zones from database: %w", ErrZoneDelete, result.Error)
 	}
 
 	log.Printf("Successfully cleared %d zones from database", result.RowsAffected)
 	return nil
 }
 
-// saveZonesToDB converts GameZones to ZonePG models and saves them to the database
-func SaveZonesToDB(zones []parser_model.GameZone) {
-	db := pg.GetDB()
+// SaveZonesToDB converts GameZones to ZonePG models and saves them to the database
+// Stops at the first batch that fails to insert
+func SaveZonesToDB(zones []parser_model.GameZone) error {
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZoneSave, err)
+	}
 
 	// Create a batch of zones to insert
 	var zonePGs []model.ZonePG
@@ -221,11 +259,11 @@ func SaveZonesToDB(zones []parser_model.GameZone) {
 		batch := zonePGs[i:end]
 		result := db.Create(&batch)
 		if result.Error != nil {
-			log.Printf("Error saving batch %d-%d: %v", i, end, result.Error)
-		} else {
-			log.Printf("Saved batch %d-%d successfully", i, end)
+			return fmt.Errorf("%w: failed to insert zones batch %d-%d: %w", ErrZoneSave, i, end, result.Error)
 		}
+		log.Printf("Saved batch %d-%d successfully", i, end)
 	}
+	return nil
 }
 
 // DeleteZonesFromDB deletes zones with specified IDs from the database
@@ -234,7 +272,10 @@ func DeleteZonesFromDB(zoneIDs []string) error {
 		return nil
 	}
 
-	db := pg.GetDB()
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZoneDelete, err)
+	}
 
 	log.Printf("Deleting %d zones from database", len(zoneIDs))
 
@@ -251,7 +292,7 @@ func DeleteZonesFromDB(zoneIDs []string) error {
 		// Use IN clause to delete multiple zones at once
 		result := db.Exec("DELETE FROM zones WHERE id IN (?)", batch)
 		if result.Error != nil {
-			return fmt.Errorf("failed to delete zones batch %d-%d: %w", i, end, result.Error)
+			return fmt.Errorf("%w: failed to delete zones batch %d-%d: %w", ErrZoneDelete, i, end, result.Error)
 		}
 
 		log.Printf("Deleted batch %d-%d: %d zones affected", i, end, result.RowsAffected)
@@ -260,3 +301,65 @@ func DeleteZonesFromDB(zoneIDs []string) error {
 	log.Printf("Successfully deleted zones from database")
 	return nil
 }
+
+// RecomputeZoneEffectsInDB recalculates effects of all zones with the current effects config
+// and updates only the zone_effects column, building stats are left untouched
+func RecomputeZoneEffectsInDB(batchSize int) error {
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZoneSave, err)
+	}
+
+	var total int64
+	if err := db.Model(&model.ZonePG{}).Count(&total).Error; err != nil {
+		return fmt.Errorf("%w: failed to count zones: %w", ErrZoneQuery, err)
+	}
+
+	log.Printf("Recomputing effects for %d zones in batches of %d", total, batchSize)
+
+	// Page through zones by ID so updates don't affect the iteration
+	lastID := ""
+	processed := 0
+	for {
+		var pgZones []*model.ZonePG
+		result := db.Where("id > ?", lastID).Order("id").Limit(batchSize).Find(&pgZones)
+		if result.Error != nil {
+			return fmt.Errorf("%w: failed to load zones batch after %q: %w", ErrZoneQuery, lastID, result.Error)
+		}
+
+		if len(pgZones) == 0 {
+			break
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, pgZone := range pgZones {
+				result := tx.Model(&model.ZonePG{}).
+					Where("id = ?", pgZone.ID).
+					UpdateColumn("zone_effects", recomputedZoneEffects(pgZone))
+				if result.Error != nil {
+					return result.Error
+				}
+			}
+			return nil
+		})
+
+		if err != nil {
+			return fmt.Errorf("%w: failed to update zone effects batch after %q: %w", ErrZoneSave, lastID, err)
+		}
+
+		lastID = pgZones[len(pgZones)-1].ID
+		processed += len(pgZones)
+		log.Printf("Recomputed effects for %d/%d zones", processed, total)
+	}
+
+	log.Printf("Successfully recomputed effects for %d zones", processed)
+	return nil
+}
+
+// recomputedZoneEffects returns the effects of a stored zone under the current effects config
+// The stored zone_effects cache is ignored
+func recomputedZoneEffects(pgZone *model.ZonePG) model.ZoneEffects {
+	zone := model.ZoneFromPG(pgZone)
+	zone.CalculateEffects()
+	return model.ZoneEffects(zone.Effects)
+}
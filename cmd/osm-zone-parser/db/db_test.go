@@ -0,0 +1,106 @@
+package parser_db
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+	parser_model "metalink/cmd/osm-zone-parser/models"
+	"metalink/internal/model"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestFunctionsReturnSentinelErrorsWithoutConnection(t *testing.T) {
+	_, queryErr := QueryZonesFromDB(0, 0, 1, 1, 100)
+	cases := []struct {
+		name     string
+		err      error
+		sentinel error
+	}{
+		{"QueryZonesFromDB", queryErr, ErrZoneQuery},
+		{"SaveUpdatedZonesToDB", SaveUpdatedZonesToDB([]*model.Zone{{ID: "a"}}), ErrZoneSave},
+		{"SaveZonesToDB", SaveZonesToDB([]parser_model.GameZone{{}}), ErrZoneSave},
+		{"ClearAllZonesFromDB", ClearAllZonesFromDB(), ErrZoneDelete},
+		{"DeleteZonesFromDB", DeleteZonesFromDB([]string{"a"}), ErrZoneDelete},
+		{"RecomputeZoneEffectsInDB", RecomputeZoneEffectsInDB(100), ErrZoneSave},
+	}
+
+	for _, c := range cases {
+		if !errors.Is(c.err, c.sentinel) || !errors.Is(c.err, errNotConnected) {
+			t.Errorf("%s: err = %v, want %v wrapping %v", c.name, c.err, c.sentinel, errNotConnected)
+		}
+	}
+}
+
+func TestRecomputedZoneEffectsReplaceStaleCache(t *testing.T) {
+	config, err := mappers.LoadBuildingEffectsConfig("../../../usa_buildings_data/building_cat_kf_config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappers.SetBuildingEffectsConfig(config)
+	if err := mappers.InitBuildingEffectsConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	buildings := model.BuildingStats{
+		TotalCount:    3,
+		TotalArea:     6000,
+		BuildingTypes: map[string]int{"residential": 2, "food_services": 1},
+		BuildingAreas: map[string]float64{"residential": 4000, "food_services": 2000},
+	}
+	// Effects cached under an earlier config
+	stale := model.ZoneEffects{{ResourceType: model.TargetParamTypeHealth, Value: 99}}
+	pgZone := &model.ZonePG{ID: "a", Buildings: buildings, ZoneEffects: stale}
+
+	effects := recomputedZoneEffects(pgZone)
+
+	fresh := &model.Zone{Buildings: buildings}
+	fresh.CalculateEffects()
+	if len(effects) == 0 || !reflect.DeepEqual([]model.ZoneEffect(effects), fresh.Effects) {
+		t.Fatalf("recomputed effects %v, want %v from the current config", effects, fresh.Effects)
+	}
+	if reflect.DeepEqual(effects, stale) {
+		t.Fatal("stale cached effects were kept")
+	}
+	if !reflect.DeepEqual(pgZone.Buildings, buildings) {
+		t.Fatalf("building stats changed to %+v", pgZone.Buildings)
+	}
+}
+
+func TestUpsertZonesKeepsCreatedAt(t *testing.T) {
+	// Dry run: statements are built for PostgreSQL but never sent
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	result := upsertZones(db, []model.ZonePG{{ID: "a", CreatedAt: now, UpdatedAt: now}})
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	sql := result.Statement.SQL.String()
+	insert, updates, found := strings.Cut(sql, `ON CONFLICT ("id") DO UPDATE SET `)
+	if !found {
+		t.Fatalf("statement is not an upsert on id: %s", sql)
+	}
+	if !strings.Contains(insert, `"created_at"`) {
+		t.Fatalf("new zones are inserted without created_at: %s", sql)
+	}
+	if strings.Contains(updates, "created_at") {
+		t.Fatalf("re-saving a zone overwrites created_at: %s", updates)
+	}
+	if !strings.Contains(updates, `"updated_at"="excluded"."updated_at"`) {
+		t.Fatalf("re-saving a zone keeps the old updated_at: %s", updates)
+	}
+}
@@ -0,0 +1,28 @@
+package parser_db
+
+import (
+	"errors"
+
+	pg "metalink/internal/postgres"
+
+	"gorm.io/gorm"
+)
+
+// Sentinel errors wrapped by every database failure of this package, to check with errors.Is
+var (
+	ErrZoneQuery  = errors.New("zone query failed")
+	ErrZoneSave   = errors.New("zone save failed")
+	ErrZoneDelete = errors.New("zone delete failed")
+)
+
+// errNotConnected is returned (wrapped in a sentinel) when the database was never initialized
+var errNotConnected = errors.New("postgres is not initialized")
+
+// getDB returns the global database connection, or errNotConnected instead of a nil connection
+func getDB() (*gorm.DB, error) {
+	db := pg.GetDB()
+	if db == nil {
+		return nil, errNotConnected
+	}
+	return db, nil
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -16,40 +18,119 @@ type BuildingMappingConfig struct {
 var (
 	cachedConfig *BuildingMappingConfig
 	configOnce   sync.Once
+
+	// Reverse index: game category -> sorted OSM building types
+	typesByCategory     map[string][]string
+	typesByCategoryOnce sync.Once
 )
 
+// UnknownBuildingCategory is the default game category for unmapped OSM building types
+const UnknownBuildingCategory = "unknown"
+
 // MapBuildingCategory maps an OSM building category to game category
-// Returns "other" if no mapping is found
+// Returns UnknownBuildingCategory if no mapping is found
 func MapBuildingCategory(osmCategory string) string {
-	config := getBuildingMappingConfig()
-	if config == nil {
-		return "other"
+	return MapBuildingCategoryWithDefault(osmCategory, UnknownBuildingCategory)
+}
+
+// MapBuildingCategoryWithDefault maps an OSM building category to game category
+// The loaded category overrides are consulted first, then the default mapping
+// Returns fallback if no mapping is found
+func MapBuildingCategoryWithDefault(osmCategory string, fallback string) string {
+	if category, ok := lookupBuildingCategory(osmCategory, getBuildingMappingConfig(), categoryOverrides); ok {
+		return category
 	}
+	return fallback
+}
 
-	return MapBuildingCategoryWithConfig(osmCategory, config)
+// GetSimplifiedBuildingType returns the game category of an OSM building type
+// Returns UnknownBuildingCategory if no mapping is found
+func GetSimplifiedBuildingType(buildingType string) string {
+	return GetSimplifiedBuildingTypeWithDefault(buildingType, UnknownBuildingCategory)
+}
+
+// GetSimplifiedBuildingTypeWithDefault returns the game category of an OSM building type
+// Returns fallback if no mapping is found
+func GetSimplifiedBuildingTypeWithDefault(buildingType string, fallback string) string {
+	return MapBuildingCategoryWithDefault(buildingType, fallback)
+}
+
+// IsMappedBuildingType reports whether an OSM building type has an explicit game category mapping
+func IsMappedBuildingType(osmCategory string) bool {
+	return MapBuildingCategoryWithDefault(osmCategory, "") != ""
 }
 
 // MapBuildingCategoryWithConfig maps an OSM building category using provided config
 // This version allows for dependency injection and easier testing
 func MapBuildingCategoryWithConfig(osmCategory string, config *BuildingMappingConfig) string {
-	if config == nil {
-		return "other"
+	return mapBuildingCategory(osmCategory, config, UnknownBuildingCategory)
+}
+
+// mapBuildingCategory maps an OSM building category using provided config and fallback
+func mapBuildingCategory(osmCategory string, config *BuildingMappingConfig, fallback string) string {
+	if category, ok := lookupBuildingCategory(osmCategory, config, nil); ok {
+		return category
 	}
+	return fallback
+}
+
+// lookupBuildingCategory resolves an OSM building type, overrides first, then the config
+// Values joining several types with semicolons (e.g. "industrial;big state electric") that have no
+// mapping of their own resolve to the first recognized type, generic "yes" only if nothing else matches
+func lookupBuildingCategory(osmCategory string, config *BuildingMappingConfig, overrides map[string]string) (string, bool) {
+	normalizedCategory := normalizeBuildingType(osmCategory)
+	if category, ok := lookupSingleBuildingCategory(normalizedCategory, config, overrides); ok {
+		return category, true
+	}
+	if !strings.Contains(normalizedCategory, ";") {
+		return "", false
+	}
+
+	genericCategory, genericFound := "", false
+	for _, token := range strings.Split(normalizedCategory, ";") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		category, ok := lookupSingleBuildingCategory(token, config, overrides)
+		if !ok {
+			continue
+		}
+		if token != "yes" {
+			return category, true
+		}
+		if !genericFound {
+			genericCategory, genericFound = category, true
+		}
+	}
+	return genericCategory, genericFound
+}
 
-	// Normalize input category (trim whitespace and convert to lowercase)
-	normalizedCategory := strings.TrimSpace(strings.ToLower(osmCategory))
+// lookupSingleBuildingCategory resolves an already normalized OSM building type without splitting it
+func lookupSingleBuildingCategory(normalizedCategory string, config *BuildingMappingConfig, overrides map[string]string) (string, bool) {
+	if category, ok := overrides[normalizedCategory]; ok {
+		return category, true
+	}
+	if config == nil {
+		return "", false
+	}
 
 	// Search through all categories to find a match
+	// A type listed under several categories resolves to the first category by name,
+	// since map iteration order would otherwise pick one at random
+	found := ""
 	for gameCategory, osmCategories := range config.BuildingMappingConfig {
+		if found != "" && gameCategory >= found {
+			continue
+		}
 		for _, category := range osmCategories {
 			if strings.ToLower(category) == normalizedCategory {
-				return gameCategory
+				found = gameCategory
+				break
 			}
 		}
 	}
-
-	// If no match found, return "other"
-	return "other"
+	return found, found != ""
 }
 
 // getBuildingMappingConfig returns cached config, loading it once if needed
@@ -87,7 +168,7 @@ func loadBuildingMappingConfig() (*BuildingMappingConfig, error) {
 func GetAllGameCategories() []string {
 	config := getBuildingMappingConfig()
 	if config == nil {
-		return []string{"other"}
+		return []string{"other", UnknownBuildingCategory}
 	}
 
 	categories := make([]string, 0, len(config.BuildingMappingConfig))
@@ -95,5 +176,64 @@ func GetAllGameCategories() []string {
 		categories = append(categories, category)
 	}
 
+	// Overrides may map to categories the default mapping doesn't use
+	for _, category := range categoryOverrides {
+		if _, ok := config.BuildingMappingConfig[category]; !ok && !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+
+	// Unmapped building types fall back to the unknown category
+	if !slices.Contains(categories, UnknownBuildingCategory) {
+		categories = append(categories, UnknownBuildingCategory)
+	}
+
 	return categories
 }
+
+// BuildingTypesForCategory returns the sorted list of OSM building types that map to the given game category
+func BuildingTypesForCategory(category string) []string {
+	typesByCategoryOnce.Do(func() {
+		typesByCategory = buildTypesByCategoryIndex(getBuildingMappingConfig(), categoryOverrides)
+	})
+
+	types := typesByCategory[category]
+	result := make([]string, len(types))
+	copy(result, types)
+	return result
+}
+
+// buildTypesByCategoryIndex inverts the mapping config and overrides into a category -> OSM types index
+// Each OSM type is resolved like MapBuildingCategory (overrides first) so the index matches actual lookups
+func buildTypesByCategoryIndex(config *BuildingMappingConfig, overrides map[string]string) map[string][]string {
+	index := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	for osmType, gameCategory := range overrides {
+		seen[osmType] = true
+		index[gameCategory] = append(index[gameCategory], osmType)
+	}
+
+	if config == nil {
+		return index
+	}
+
+	for _, osmCategories := range config.BuildingMappingConfig {
+		for _, category := range osmCategories {
+			normalizedCategory := normalizeBuildingType(category)
+			if seen[normalizedCategory] {
+				continue
+			}
+			seen[normalizedCategory] = true
+
+			gameCategory := MapBuildingCategoryWithConfig(normalizedCategory, config)
+			index[gameCategory] = append(index[gameCategory], normalizedCategory)
+		}
+	}
+
+	for _, types := range index {
+		sort.Strings(types)
+	}
+
+	return index
+}
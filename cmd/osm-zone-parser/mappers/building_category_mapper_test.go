@@ -0,0 +1,113 @@
+package mappers
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+)
+
+// loadTestMappingConfig reads the repository's building mapping config
+func loadTestMappingConfig(t *testing.T) *BuildingMappingConfig {
+	t.Helper()
+	data, err := os.ReadFile("../../../usa_buildings_data/bmap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config BuildingMappingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	return &config
+}
+
+func TestMapBuildingCategoryWithConfig(t *testing.T) {
+	config := loadTestMappingConfig(t)
+
+	tests := []struct {
+		osmType string
+		want    string
+	}{
+		{"industrial;commercial", "industrial_manufacturing"},
+		{"church;yes", "religious_worship"},
+		{"definitely-not-a-building-type", UnknownBuildingCategory},
+	}
+	for _, tt := range tests {
+		if got := MapBuildingCategoryWithConfig(tt.osmType, config); got != tt.want {
+			t.Errorf("MapBuildingCategoryWithConfig(%q) = %q, want %q", tt.osmType, got, tt.want)
+		}
+	}
+}
+
+func TestGetSimplifiedBuildingTypeWithDefaultUsesFallback(t *testing.T) {
+	if got := GetSimplifiedBuildingTypeWithDefault("definitely-not-a-building-type", "other"); got != "other" {
+		t.Fatalf("got %q, want the fallback", got)
+	}
+	if got := GetSimplifiedBuildingType("definitely-not-a-building-type"); got != UnknownBuildingCategory {
+		t.Fatalf("got %q, want %q", got, UnknownBuildingCategory)
+	}
+}
+
+func TestGetAllGameCategoriesIncludesUnknown(t *testing.T) {
+	if categories := GetAllGameCategories(); !slices.Contains(categories, UnknownBuildingCategory) {
+		t.Fatalf("categories %v do not include %q", categories, UnknownBuildingCategory)
+	}
+	if _, ok := loadTestMappingConfig(t).BuildingMappingConfig[UnknownBuildingCategory]; !ok {
+		t.Fatalf("bmap.json has no %q category", UnknownBuildingCategory)
+	}
+}
+
+func TestTypesByCategoryIndexCoversEveryTypeOnce(t *testing.T) {
+	config := loadTestMappingConfig(t)
+	overrides := map[string]string{"hangar": "transportation_aviation", "house": "other"}
+
+	all := make(map[string]bool)
+	for _, osmTypes := range config.BuildingMappingConfig {
+		for _, osmType := range osmTypes {
+			all[normalizeBuildingType(osmType)] = true
+		}
+	}
+	for osmType := range overrides {
+		all[osmType] = true
+	}
+
+	union := make(map[string]bool)
+	for category, osmTypes := range buildTypesByCategoryIndex(config, overrides) {
+		if !slices.IsSorted(osmTypes) {
+			t.Fatalf("types of %s are not sorted", category)
+		}
+		for _, osmType := range osmTypes {
+			if union[osmType] {
+				t.Fatalf("%s is listed under more than one category", osmType)
+			}
+			union[osmType] = true
+
+			// The index agrees with the lookup, overrides first
+			if mapped, _ := lookupBuildingCategory(osmType, config, overrides); mapped != category {
+				t.Fatalf("%s is listed under %s but maps to %s", osmType, category, mapped)
+			}
+		}
+	}
+
+	if len(union) != len(all) {
+		t.Fatalf("index lists %d types, the mapping and overrides have %d", len(union), len(all))
+	}
+	for osmType := range all {
+		if !union[osmType] {
+			t.Fatalf("%s is missing from the index", osmType)
+		}
+	}
+}
+
+func TestTypeInSeveralCategoriesMapsToFirstByName(t *testing.T) {
+	config := &BuildingMappingConfig{BuildingMappingConfig: map[string][]string{
+		"specialized_buildings": {"courthouse"},
+		"government_civic":      {"townhall", "courthouse"},
+		"other":                 {"shed"},
+	}}
+	for i := 0; i < 20; i++ {
+		if got := MapBuildingCategoryWithConfig("courthouse", config); got != "government_civic" {
+			t.Fatalf("courthouse maps to %s, want government_civic", got)
+		}
+	}
+}
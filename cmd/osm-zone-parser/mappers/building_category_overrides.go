@@ -0,0 +1,55 @@
+package mappers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// categoryOverrides maps normalized OSM building types to game categories, consulted before the default mapping
+var categoryOverrides map[string]string
+
+// LoadBuildingCategoryOverrides loads a JSON object of OSM building type -> game category,
+// e.g. {"hangar": "transportation_aviation"}
+// Every game category must have an entry in the building effects config
+func LoadBuildingCategoryOverrides(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read building category map %q: %w", path, err)
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse building category map %q: %w", path, err)
+	}
+
+	overrides := make(map[string]string, len(raw))
+	var unknown []string
+	for osmType, category := range raw {
+		if GetBuildingEffectsConfig(category) == nil {
+			unknown = append(unknown, fmt.Sprintf("%s -> %s", osmType, category))
+			continue
+		}
+		overrides[normalizeBuildingType(osmType)] = category
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("building category map %q uses game categories without effects config: %s",
+			path, strings.Join(unknown, ", "))
+	}
+
+	return overrides, nil
+}
+
+// SetBuildingCategoryOverrides sets the OSM type -> game category overrides merged over the default mapping
+// Must be called once at startup, before any category lookups
+func SetBuildingCategoryOverrides(overrides map[string]string) {
+	categoryOverrides = overrides
+}
+
+// normalizeBuildingType trims and lowercases an OSM building type for lookups
+func normalizeBuildingType(osmType string) string {
+	return strings.TrimSpace(strings.ToLower(osmType))
+}
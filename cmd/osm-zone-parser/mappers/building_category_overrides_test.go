@@ -0,0 +1,64 @@
+package mappers
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain loads the effects config the override loader validates against, once for the whole test binary
+func TestMain(m *testing.M) {
+	config, err := LoadBuildingEffectsConfig("../../../usa_buildings_data/building_cat_kf_config.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	SetBuildingEffectsConfig(config)
+	if err := InitBuildingEffectsConfig(); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(m.Run())
+}
+
+// writeCategoryMap writes a building category map file and returns its path
+func writeCategoryMap(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "building_category_map.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCategoryOverrideChangesMapping(t *testing.T) {
+	config := loadTestMappingConfig(t)
+	if category := mapBuildingCategory("house", config, UnknownBuildingCategory); category != "residential" {
+		t.Fatalf("default category of house = %q, want residential", category)
+	}
+
+	overrides, err := LoadBuildingCategoryOverrides(writeCategoryMap(t, `{" House ": "commercial_retail"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetBuildingCategoryOverrides(overrides)
+	t.Cleanup(func() { SetBuildingCategoryOverrides(nil) })
+
+	for _, osmType := range []string{"house", "HOUSE"} {
+		if category := MapBuildingCategory(osmType); category != "commercial_retail" {
+			t.Errorf("category of %q with the override = %q, want commercial_retail", osmType, category)
+		}
+	}
+}
+
+func TestLoadCategoryOverridesRejectsUnknownCategory(t *testing.T) {
+	path := writeCategoryMap(t, `{"hangar": "spaceport", "house": "residential"}`)
+	_, err := LoadBuildingCategoryOverrides(path)
+	if err == nil || !strings.Contains(err.Error(), "hangar -> spaceport") {
+		t.Fatalf("err = %v, want the unknown category named", err)
+	}
+
+	if _, err := LoadBuildingCategoryOverrides(writeCategoryMap(t, `["house"]`)); err == nil {
+		t.Fatal("no error for a map that isn't a JSON object")
+	}
+}
@@ -1,13 +1,18 @@
 package mappers
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
 // BuildingEffect represents individual effect configuration
+// Positive values are buffs and negative values are debuffs, e.g. "air_quality": -30 for heavy industry
 type BuildingEffect struct {
 	SleepQuality       int `json:"sleep_quality,omitempty"`
 	FoodSearch         int `json:"food_search,omitempty"`
@@ -17,11 +22,28 @@ type BuildingEffect struct {
 	StaminaConsumption int `json:"stamina_consumption,omitempty"`
 }
 
+// UnmarshalJSON rejects unknown effect names, so a misspelled effect (often a debuff) fails the load
+// instead of silently turning into no effect
+func (e *BuildingEffect) UnmarshalJSON(data []byte) error {
+	type plainBuildingEffect BuildingEffect
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var effect plainBuildingEffect
+	if err := decoder.Decode(&effect); err != nil {
+		return fmt.Errorf("invalid effects: %w", err)
+	}
+	*e = BuildingEffect(effect)
+	return nil
+}
+
 // BuildingTypeConfig represents configuration for a specific building type
 type BuildingTypeConfig struct {
-	ExtraRadiusKf float64        `json:"extra_radius_kf"`
-	Weight        float64        `json:"weight"`
-	Effects       BuildingEffect `json:"effects"`
+	ExtraRadiusKf      float64        `json:"extra_radius_kf"`
+	Weight             float64        `json:"weight"`
+	MaxInfluenceRadius float64        `json:"max_influence_radius,omitempty"` // Overrides the global cap (0 = global cap)
+	Effects            BuildingEffect `json:"effects"`
 }
 
 // BuildingEffectsConfig represents the structure of building effects configuration
@@ -29,12 +51,116 @@ type BuildingEffectsConfig struct {
 	BuildingBaseRadius    float64                       `json:"building_base_radius"`
 	BaseAreaKf            float64                       `json:"base_area_kf"`
 	WeightThreshold       float64                       `json:"weight_threshold"`
+	MaxInfluenceRadius    float64                       `json:"max_influence_radius,omitempty"` // Cap of a building's influence radius in meters (0 = default)
+	HeightThresholds      *HeightThresholds             `json:"height_thresholds,omitempty"`    // nil = default height classes
 	BuildingEffectsConfig map[string]BuildingTypeConfig `json:"building_effects_config"`
+
+	// Mixed-use buildings: secondary tags like shop/amenity add categories besides the building tag
+	SecondaryTags    []SecondaryTagRule `json:"secondary_tags,omitempty"`
+	MixedUseMode     string             `json:"mixed_use_mode,omitempty"`    // split (default) or priority
+	CategoryPriority map[string]int     `json:"category_priority,omitempty"` // Priority of primary categories in priority mode
+
+	AreaCurve *AreaCurve `json:"area_curve,omitempty"` // Area to effect coefficient curve (nil = linear)
+
+	// Combination of overlapping zone effects at a point
+	EffectCaps        map[string]EffectCap `json:"effect_caps,omitempty"`        // Bounds by resource name, e.g. food_search
+	EffectCombination string               `json:"effect_combination,omitempty"` // additive (default) or diminishing
+
+	POIEffectArea float64 `json:"poi_effect_area,omitempty"` // Building area equivalent of one amenity/shop/leisure node (0 = default)
+}
+
+// AreaCurve maps a building area to the effect coefficient of its zone
+type AreaCurve struct {
+	Type     string  `json:"type"`                // linear (default), logarithmic or saturating
+	Scale    float64 `json:"scale,omitempty"`     // Square meters per 1.0 coefficient for linear and logarithmic curves
+	Max      float64 `json:"max,omitempty"`       // Saturating curve limit (a in a*area/(area+k))
+	HalfArea float64 `json:"half_area,omitempty"` // Saturating curve area at half of max (k in a*area/(area+k))
+}
+
+// Area curve types
+const (
+	AreaCurveLinear      = "linear"      // area/scale
+	AreaCurveLogarithmic = "logarithmic" // ln(1+area/scale)
+	AreaCurveSaturating  = "saturating"  // max*area/(area+half_area)
+)
+
+// DefaultAreaCurve: 1000 m² = 1.0 coefficient, growing linearly
+var DefaultAreaCurve = AreaCurve{
+	Type:  AreaCurveLinear,
+	Scale: 1000,
+}
+
+// IsLinear reports whether coefficients of area parts add up to the coefficient of the whole area
+func (c AreaCurve) IsLinear() bool {
+	return c.Type == "" || c.Type == AreaCurveLinear
+}
+
+// Validate checks the curve type and the constants it uses
+func (c AreaCurve) Validate() error {
+	switch c.Type {
+	case "", AreaCurveLinear, AreaCurveLogarithmic:
+		if c.Scale < 0 {
+			return fmt.Errorf("scale must not be negative, got %v", c.Scale)
+		}
+	case AreaCurveSaturating:
+		if c.Max <= 0 || c.HalfArea <= 0 {
+			return fmt.Errorf("saturating curve needs positive max and half_area, got %v and %v", c.Max, c.HalfArea)
+		}
+	default:
+		return fmt.Errorf("invalid type %q (expected %s, %s or %s)", c.Type, AreaCurveLinear, AreaCurveLogarithmic, AreaCurveSaturating)
+	}
+	return nil
+}
+
+// EffectCap bounds the combined value of one resource type; a nil side is unbounded
+type EffectCap struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// Effect combination modes
+const (
+	EffectCombinationAdditive    = "additive"    // Effects are summed
+	EffectCombinationDiminishing = "diminishing" // 1-prod(1-e) relative to the cap, additive for uncapped resources
+)
+
+// DefaultMaxInfluenceRadius: no building influences zones farther than 1km
+const DefaultMaxInfluenceRadius = 1000.0
+
+// DefaultPOIEffectArea: a point of interest counts as 250 m² of its category
+const DefaultPOIEffectArea = 250.0
+
+// HeightThresholds are the minimum levels of each building height class above single floor
+type HeightThresholds struct {
+	LowRiseMinLevels    int `json:"low_rise_min_levels"`
+	HighRiseMinLevels   int `json:"high_rise_min_levels"`
+	SkyscraperMinLevels int `json:"skyscraper_min_levels"`
+}
+
+// DefaultHeightThresholds: 1 floor, 2-9, 10-29, 30+
+var DefaultHeightThresholds = HeightThresholds{
+	LowRiseMinLevels:    2,
+	HighRiseMinLevels:   10,
+	SkyscraperMinLevels: 30,
+}
+
+// Validate checks that the thresholds are strictly increasing and above single floor
+func (t HeightThresholds) Validate() error {
+	if t.LowRiseMinLevels < 2 || t.HighRiseMinLevels <= t.LowRiseMinLevels || t.SkyscraperMinLevels <= t.HighRiseMinLevels {
+		return fmt.Errorf("height thresholds must satisfy 2 <= low_rise (%d) < high_rise (%d) < skyscraper (%d)",
+			t.LowRiseMinLevels, t.HighRiseMinLevels, t.SkyscraperMinLevels)
+	}
+	return nil
 }
 
+// DefaultBuildingEffectsConfigPath is used when no override path is set
+const DefaultBuildingEffectsConfigPath = "usa_buildings_data/building_cat_kf_config.json"
+
 var (
-	cachedEffectsConfig *BuildingEffectsConfig
-	effectsConfigOnce   sync.Once
+	cachedEffectsConfig   *BuildingEffectsConfig
+	effectsConfigErr      error
+	effectsConfigOnce     sync.Once
+	effectsConfigOverride *BuildingEffectsConfig
 
 	// Cache for OSM type to effects config mapping
 	osmTypeToEffectsCache sync.Map // map[string]*BuildingTypeConfig
@@ -108,37 +234,203 @@ func GetBuildingEffectsByOSMType(osmBuildingType string) *BuildingEffect {
 	return &config.Effects
 }
 
-// getBuildingEffectsConfig returns cached config, loading it once if needed
-func getBuildingEffectsConfig() *BuildingEffectsConfig {
+// SetBuildingEffectsConfig overrides the cached effects config
+// Must be called once at startup, before any effects lookups
+func SetBuildingEffectsConfig(config *BuildingEffectsConfig) {
+	effectsConfigOverride = config
+}
+
+// InitBuildingEffectsConfig loads the effects config once and returns the load error
+// Call it at startup, after any SetBuildingEffectsConfig, so a missing or invalid config
+// stops the program instead of silently giving buildings no effects
+func InitBuildingEffectsConfig() error {
 	effectsConfigOnce.Do(func() {
-		config, err := loadBuildingEffectsConfig()
-		if err != nil {
-			log.Printf("Failed to load building effects config: %v", err)
+		if effectsConfigOverride != nil {
+			cachedEffectsConfig = effectsConfigOverride
 			return
 		}
-		cachedEffectsConfig = config
+		cachedEffectsConfig, effectsConfigErr = LoadBuildingEffectsConfig(DefaultBuildingEffectsConfigPath)
 	})
+	return effectsConfigErr
+}
+
+// getBuildingEffectsConfig returns cached config, loading it once if needed
+// Falls back to the default config path when no override is set; returns nil if loading failed
+func getBuildingEffectsConfig() *BuildingEffectsConfig {
+	InitBuildingEffectsConfig()
 	return cachedEffectsConfig
 }
 
-// loadBuildingEffectsConfig loads the building effects configuration from JSON file
-func loadBuildingEffectsConfig() (*BuildingEffectsConfig, error) {
+// LoadBuildingEffectsConfig loads the building effects configuration from JSON file
+func LoadBuildingEffectsConfig(path string) (*BuildingEffectsConfig, error) {
 	// Read the JSON file
-	data, err := os.ReadFile("usa_buildings_data/building_cat_kf_config.json")
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("building effects config not found at %q (run from the repo root or pass -effects-config): %w", path, err)
+		}
+		return nil, fmt.Errorf("failed to read building effects config %q: %w", path, err)
 	}
 
 	// Parse JSON
 	var config BuildingEffectsConfig
 	err = json.Unmarshal(data, &config)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse building effects config %q: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid building effects config %q: %w", path, err)
 	}
 
 	return &config, nil
 }
 
+// GetHeightThresholds returns the building height thresholds from the effects config
+// Returns the defaults if the config doesn't define them
+func GetHeightThresholds() HeightThresholds {
+	config := getBuildingEffectsConfig()
+	if config == nil || config.HeightThresholds == nil {
+		return DefaultHeightThresholds
+	}
+	return *config.HeightThresholds
+}
+
+// Validate checks the config values, failing on the first invalid one
+// Game categories without an entry are only logged since they just get no effects
+func (c *BuildingEffectsConfig) Validate() error {
+	if c.BuildingBaseRadius <= 0 {
+		return fmt.Errorf("building_base_radius must be positive, got %v", c.BuildingBaseRadius)
+	}
+	if c.BaseAreaKf <= 0 {
+		return fmt.Errorf("base_area_kf must be positive, got %v", c.BaseAreaKf)
+	}
+
+	if c.MaxInfluenceRadius < 0 {
+		return fmt.Errorf("max_influence_radius must not be negative, got %v", c.MaxInfluenceRadius)
+	}
+
+	categories := make([]string, 0, len(c.BuildingEffectsConfig))
+	for category := range c.BuildingEffectsConfig {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		typeConfig := c.BuildingEffectsConfig[category]
+		if typeConfig.Weight < 0 {
+			return fmt.Errorf("weight of %q must not be negative, got %v", category, typeConfig.Weight)
+		}
+		if typeConfig.MaxInfluenceRadius < 0 {
+			return fmt.Errorf("max_influence_radius of %q must not be negative, got %v", category, typeConfig.MaxInfluenceRadius)
+		}
+	}
+
+	switch c.MixedUseMode {
+	case "", MixedUseModeSplit, MixedUseModePriority:
+	default:
+		return fmt.Errorf("invalid mixed_use_mode %q (expected %s or %s)", c.MixedUseMode, MixedUseModeSplit, MixedUseModePriority)
+	}
+
+	switch c.EffectCombination {
+	case "", EffectCombinationAdditive, EffectCombinationDiminishing:
+	default:
+		return fmt.Errorf("invalid effect_combination %q (expected %s or %s)",
+			c.EffectCombination, EffectCombinationAdditive, EffectCombinationDiminishing)
+	}
+
+	for resource, effectCap := range c.EffectCaps {
+		if effectCap.Min != nil && effectCap.Max != nil && *effectCap.Min > *effectCap.Max {
+			return fmt.Errorf("invalid effect_caps for %q: min %v is above max %v", resource, *effectCap.Min, *effectCap.Max)
+		}
+	}
+
+	if c.HeightThresholds != nil {
+		if err := c.HeightThresholds.Validate(); err != nil {
+			return fmt.Errorf("invalid height_thresholds: %w", err)
+		}
+	}
+
+	if c.AreaCurve != nil {
+		if err := c.AreaCurve.Validate(); err != nil {
+			return fmt.Errorf("invalid area_curve: %w", err)
+		}
+	}
+
+	if missing := c.missingCategories(GetAllGameCategories()); len(missing) > 0 {
+		log.Printf("Warning: building effects config has no entry for game categories %s, they get no effects",
+			strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// missingCategories returns the sorted game categories without an effects entry
+func (c *BuildingEffectsConfig) missingCategories(categories []string) []string {
+	var missing []string
+	for _, category := range categories {
+		if _, ok := c.BuildingEffectsConfig[category]; !ok {
+			missing = append(missing, category)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// GetAreaCurve returns the area coefficient curve from the effects config
+// Returns the linear default if the config doesn't define one; a zero scale uses the default scale
+func GetAreaCurve() AreaCurve {
+	config := getBuildingEffectsConfig()
+	if config == nil || config.AreaCurve == nil {
+		return DefaultAreaCurve
+	}
+
+	curve := *config.AreaCurve
+	if curve.Scale == 0 {
+		curve.Scale = DefaultAreaCurve.Scale
+	}
+	return curve
+}
+
+// GetMaxInfluenceRadius returns the influence radius cap in meters for a building type config
+// The category override wins over the global cap; typeConfig may be nil
+func GetMaxInfluenceRadius(typeConfig *BuildingTypeConfig) float64 {
+	if typeConfig != nil && typeConfig.MaxInfluenceRadius > 0 {
+		return typeConfig.MaxInfluenceRadius
+	}
+	config := getBuildingEffectsConfig()
+	if config == nil || config.MaxInfluenceRadius <= 0 {
+		return DefaultMaxInfluenceRadius
+	}
+	return config.MaxInfluenceRadius
+}
+
+// GetPOIEffectArea returns the building area equivalent of one point of interest
+func GetPOIEffectArea() float64 {
+	config := getBuildingEffectsConfig()
+	if config == nil || config.POIEffectArea <= 0 {
+		return DefaultPOIEffectArea
+	}
+	return config.POIEffectArea
+}
+
+// GetEffectCaps returns the combined effect bounds by resource name (nil if none are configured)
+func GetEffectCaps() map[string]EffectCap {
+	config := getBuildingEffectsConfig()
+	if config == nil {
+		return nil
+	}
+	return config.EffectCaps
+}
+
+// GetEffectCombination returns the configured effect combination mode, additive by default
+func GetEffectCombination() string {
+	config := getBuildingEffectsConfig()
+	if config == nil || config.EffectCombination == "" {
+		return EffectCombinationAdditive
+	}
+	return config.EffectCombination
+}
+
 // GetBuildingRadiusKf returns the radius coefficient for a specific building type
 // Returns 0 if no configuration is found
 func GetBuildingRadiusKf(buildingType string) float64 {
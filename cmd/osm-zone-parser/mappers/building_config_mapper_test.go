@@ -0,0 +1,77 @@
+package mappers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testEffectsConfigPath = "../../../usa_buildings_data/building_cat_kf_config.json"
+
+func TestLoadBuildingEffectsConfig(t *testing.T) {
+	if _, err := LoadBuildingEffectsConfig(testEffectsConfigPath); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadBuildingEffectsConfig("missing.json")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing config: err = %v, want a not found error", err)
+	}
+}
+
+// writeEffectsConfig writes a config with a single industrial category having the given effects object
+func writeEffectsConfig(t *testing.T, effects string) string {
+	t.Helper()
+	config := `{"building_base_radius": 15, "base_area_kf": 3, "building_effects_config": {
+		"industrial_manufacturing": {"weight": 1, "effects": ` + effects + `}}}`
+	path := filepath.Join(t.TempDir(), "effects.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBuildingEffectsConfigKeepsDebuffs(t *testing.T) {
+	loaded, err := LoadBuildingEffectsConfig(writeEffectsConfig(t, `{"air_quality": -30, "stamina_consumption": 25}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effects := loaded.BuildingEffectsConfig["industrial_manufacturing"].Effects; effects.AirQuality != -30 || effects.StaminaConsumption != 25 {
+		t.Fatalf("effects %+v, want air_quality -30 and stamina_consumption 25", effects)
+	}
+
+	// A misspelled debuff fails the load instead of becoming no effect
+	_, err = LoadBuildingEffectsConfig(writeEffectsConfig(t, `{"air_qualty": -30}`))
+	if err == nil || !strings.Contains(err.Error(), "air_qualty") {
+		t.Fatalf("misspelled effect: err = %v, want an unknown field error", err)
+	}
+}
+
+func TestBuildingEffectsConfigValidate(t *testing.T) {
+	for name, modify := range map[string]func(c *BuildingEffectsConfig){
+		"base radius":        func(c *BuildingEffectsConfig) { c.BuildingBaseRadius = 0 },
+		"base area kf":       func(c *BuildingEffectsConfig) { c.BaseAreaKf = -1 },
+		"max influence":      func(c *BuildingEffectsConfig) { c.MaxInfluenceRadius = -1 },
+		"category weight":    func(c *BuildingEffectsConfig) { c.BuildingEffectsConfig["house"] = BuildingTypeConfig{Weight: -1} },
+		"mixed use mode":     func(c *BuildingEffectsConfig) { c.MixedUseMode = "merge" },
+		"effect combination": func(c *BuildingEffectsConfig) { c.EffectCombination = "max" },
+		"category max radius": func(c *BuildingEffectsConfig) {
+			c.BuildingEffectsConfig["house"] = BuildingTypeConfig{MaxInfluenceRadius: -1}
+		},
+	} {
+		config := &BuildingEffectsConfig{
+			BuildingBaseRadius:    10,
+			BaseAreaKf:            1,
+			BuildingEffectsConfig: map[string]BuildingTypeConfig{"house": {Weight: 1}},
+		}
+		if err := config.Validate(); err != nil {
+			t.Fatalf("valid config: %v", err)
+		}
+
+		modify(config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package mappers
+
+import "strings"
+
+// Mixed-use modes of the building effects config
+const (
+	MixedUseModeSplit    = "split"    // Building area is split between the primary and secondary categories
+	MixedUseModePriority = "priority" // The whole building goes to the highest priority category
+)
+
+// SecondaryTagRule maps a secondary OSM tag (e.g. shop=supermarket) to a game category
+type SecondaryTagRule struct {
+	Tag      string  `json:"tag"`             // OSM tag key, e.g. "shop" or "amenity"
+	Value    string  `json:"value,omitempty"` // Tag value to match, empty matches any value
+	Category string  `json:"category"`        // Game category the building contributes to
+	Weight   float64 `json:"weight"`          // Share of the building area in split mode (0..1)
+	Priority int     `json:"priority"`        // Compared with category_priority of the primary category in priority mode
+}
+
+// CategoryShare is the part of a building attributed to a game category
+type CategoryShare struct {
+	Category string
+	Share    float64 // Fraction of the building area (0..1)
+}
+
+// ResolveBuildingCategories returns the categories a building contributes to, considering secondary tags
+// The first share is the dominant category, which should be used for per-building settings like weight and radius
+// Without matching secondary tag rules the primary category gets the whole building
+func ResolveBuildingCategories(primaryCategory string, tags map[string]string) []CategoryShare {
+	config := getBuildingEffectsConfig()
+	if config == nil || len(config.SecondaryTags) == 0 {
+		return []CategoryShare{{Category: primaryCategory, Share: 1}}
+	}
+	return resolveBuildingCategories(primaryCategory, tags, config)
+}
+
+// resolveBuildingCategories resolves building categories using provided config
+func resolveBuildingCategories(primaryCategory string, tags map[string]string, config *BuildingEffectsConfig) []CategoryShare {
+	matched := matchSecondaryTags(primaryCategory, tags, config.SecondaryTags)
+	if len(matched) == 0 {
+		return []CategoryShare{{Category: primaryCategory, Share: 1}}
+	}
+
+	if config.MixedUseMode == MixedUseModePriority {
+		best := primaryCategory
+		bestPriority := config.CategoryPriority[primaryCategory]
+		for _, rule := range matched {
+			// Ties keep the primary category
+			if rule.Priority > bestPriority {
+				best, bestPriority = rule.Category, rule.Priority
+			}
+		}
+		return []CategoryShare{{Category: best, Share: 1}}
+	}
+
+	// Split mode: secondary categories take their weight, the primary category keeps the rest
+	var secondaryTotal float64
+	for _, rule := range matched {
+		secondaryTotal += clampShare(rule.Weight)
+	}
+	scale := 1.0
+	if secondaryTotal > 1 {
+		scale = 1 / secondaryTotal
+	}
+
+	shares := make([]CategoryShare, 0, len(matched)+1)
+	shares = append(shares, CategoryShare{Category: primaryCategory, Share: 1 - secondaryTotal*scale})
+	for _, rule := range matched {
+		share := clampShare(rule.Weight) * scale
+		if share == 0 {
+			continue
+		}
+		shares = append(shares, CategoryShare{Category: rule.Category, Share: share})
+	}
+
+	// Keep the dominant category first, the primary one wins ties
+	for i := 1; i < len(shares); i++ {
+		if shares[i].Share > shares[0].Share {
+			shares[0], shares[i] = shares[i], shares[0]
+		}
+	}
+	return shares
+}
+
+// matchSecondaryTags returns the first matching rule per secondary category, skipping the primary category
+func matchSecondaryTags(primaryCategory string, tags map[string]string, rules []SecondaryTagRule) []SecondaryTagRule {
+	var matched []SecondaryTagRule
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		value, ok := tags[rule.Tag]
+		if !ok || rule.Category == primaryCategory || seen[rule.Category] {
+			continue
+		}
+		if rule.Value != "" && !strings.EqualFold(strings.TrimSpace(value), rule.Value) {
+			continue
+		}
+
+		seen[rule.Category] = true
+		matched = append(matched, rule)
+	}
+	return matched
+}
+
+// clampShare limits a share to 0..1
+func clampShare(share float64) float64 {
+	if share < 0 {
+		return 0
+	}
+	if share > 1 {
+		return 1
+	}
+	return share
+}
@@ -0,0 +1,61 @@
+package mappers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// supermarketRules map shop=supermarket to retail and any amenity to food services
+var supermarketRules = []SecondaryTagRule{
+	{Tag: "shop", Value: "supermarket", Category: "commercial_retail", Weight: 0.4, Priority: 5},
+	{Tag: "amenity", Category: "food_services", Weight: 0.2, Priority: 1},
+}
+
+func TestResolveMixedUseSupermarketSplit(t *testing.T) {
+	config := &BuildingEffectsConfig{SecondaryTags: supermarketRules}
+	tags := map[string]string{"building": "apartments", "shop": "Supermarket "}
+
+	shares := resolveBuildingCategories("residential", tags, config)
+	want := []CategoryShare{{Category: "residential", Share: 0.6}, {Category: "commercial_retail", Share: 0.4}}
+	if len(shares) != len(want) {
+		t.Fatalf("shares = %v, want %v", shares, want)
+	}
+	total := 0.0
+	for i, share := range shares {
+		if share.Category != want[i].Category || math.Abs(share.Share-want[i].Share) > 1e-9 {
+			t.Fatalf("shares = %v, want %v", shares, want)
+		}
+		total += share.Share
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("shares add up to %v, want 1", total)
+	}
+}
+
+func TestResolveMixedUseSupermarketPriority(t *testing.T) {
+	config := &BuildingEffectsConfig{
+		SecondaryTags:    supermarketRules,
+		MixedUseMode:     MixedUseModePriority,
+		CategoryPriority: map[string]int{"residential": 3},
+	}
+
+	shares := resolveBuildingCategories("residential", map[string]string{"shop": "supermarket"}, config)
+	if want := []CategoryShare{{Category: "commercial_retail", Share: 1}}; !reflect.DeepEqual(shares, want) {
+		t.Fatalf("supermarket shares = %v, want %v", shares, want)
+	}
+
+	// A lower priority secondary tag leaves the building to its primary category
+	shares = resolveBuildingCategories("residential", map[string]string{"amenity": "cafe"}, config)
+	if want := []CategoryShare{{Category: "residential", Share: 1}}; !reflect.DeepEqual(shares, want) {
+		t.Fatalf("cafe shares = %v, want %v", shares, want)
+	}
+}
+
+func TestResolveWithoutSecondaryTagsKeepsPrimary(t *testing.T) {
+	config := &BuildingEffectsConfig{SecondaryTags: supermarketRules}
+	shares := resolveBuildingCategories("residential", map[string]string{"shop": "bakery"}, config)
+	if want := []CategoryShare{{Category: "residential", Share: 1}}; !reflect.DeepEqual(shares, want) {
+		t.Fatalf("shares = %v, want %v", shares, want)
+	}
+}
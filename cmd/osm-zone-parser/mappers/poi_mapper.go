@@ -0,0 +1,35 @@
+package mappers
+
+// POITagKeys are the node tags that mark a point of interest, in lookup order
+var POITagKeys = []string{"amenity", "shop", "leisure"}
+
+// IsNodeOfInterest reports whether node tags describe a point of interest
+func IsNodeOfInterest(tags map[string]string) bool {
+	for _, key := range POITagKeys {
+		if value, ok := tags[key]; ok && value != "" && value != "no" {
+			return true
+		}
+	}
+	return false
+}
+
+// MapPOICategory maps the tags of a point of interest to a game category
+// Secondary tag rules of the effects config are checked first, then the tag values are looked up
+// in the building mapping (e.g. amenity=hospital like building=hospital)
+// Returns an empty string if no mapping is found
+func MapPOICategory(tags map[string]string) string {
+	if config := getBuildingEffectsConfig(); config != nil {
+		if matched := matchSecondaryTags("", tags, config.SecondaryTags); len(matched) > 0 {
+			return matched[0].Category
+		}
+	}
+
+	for _, key := range POITagKeys {
+		if value, ok := tags[key]; ok {
+			if category := MapBuildingCategoryWithDefault(value, ""); category != "" {
+				return category
+			}
+		}
+	}
+	return ""
+}
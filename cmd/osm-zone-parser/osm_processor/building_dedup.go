@@ -0,0 +1,213 @@
+package osm_processor
+
+import (
+	"math"
+
+	"metalink/internal/logx"
+	"metalink/internal/model"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// DeduplicateOverlappingBuildings finds buildings whose footprint overlaps an earlier building with
+// intersection-over-union at or above iouThreshold and records them as its duplicates
+// Duplicates stay in p.Buildings; each zone counts only one building of a duplicate pair (see
+// withoutOriginalZones). Returns the number of duplicates
+func (p *OSMProcessor) DeduplicateOverlappingBuildings(iouThreshold float64) int {
+	logx.Info("De-duplicating overlapping buildings (IoU >= %.2f)", iouThreshold)
+
+	position := make(map[*model.Building]int, len(p.Buildings))
+	for i, building := range p.Buildings {
+		position[building] = i
+	}
+
+	p.duplicateOf = make(map[*model.Building]*model.Building)
+	for i, building := range p.Buildings {
+		if p.duplicateOf[building] != nil {
+			continue
+		}
+
+		for _, item := range p.SpatialIndex.SearchIntersect((&model.BuildingSpatial{Building: building}).Bounds()) {
+			candidate := item.(*model.BuildingSpatial).Building
+
+			// Keep the first of each duplicate group in processing order
+			if position[candidate] <= i || p.duplicateOf[candidate] != nil {
+				continue
+			}
+
+			if footprintIoU(building.Outline, candidate.Outline) >= iouThreshold {
+				p.duplicateOf[candidate] = building
+			}
+		}
+	}
+
+	if len(p.duplicateOf) == 0 {
+		logx.Info("No overlapping duplicate buildings found")
+	} else {
+		logx.Info("Found %d overlapping duplicate buildings, they are counted once per zone", len(p.duplicateOf))
+	}
+	return len(p.duplicateOf)
+}
+
+// withoutOriginalZones drops the zones that the original of a duplicate building also contributes to,
+// so a duplicate is only counted in zones its original doesn't reach
+func (p *OSMProcessor) withoutOriginalZones(building *model.Building, zoneIndex *rtreego.Rtree, zones []*ZoneSpatial) []*ZoneSpatial {
+	original := p.duplicateOf[building]
+	if original == nil || len(zones) == 0 {
+		return zones
+	}
+
+	_, _, originalZones := p.buildingInfluence(original, zoneIndex)
+	counted := make(map[string]bool, len(originalZones))
+	for _, zoneSpatial := range originalZones {
+		counted[zoneSpatial.Zone.ID] = true
+	}
+
+	kept := zones[:0:0]
+	for _, zoneSpatial := range zones {
+		if !counted[zoneSpatial.Zone.ID] {
+			kept = append(kept, zoneSpatial)
+		}
+	}
+	return kept
+}
+
+// footprintIoU calculates intersection-over-union of two building footprints
+// IoU is a ratio, so it's computed directly in degrees
+func footprintIoU(a, b orb.Polygon) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if !a.Bound().Intersects(b.Bound()) {
+		return 0
+	}
+
+	outerA, outerB := a[0], b[0]
+	areaA := math.Abs(planar.Area(outerA))
+	areaB := math.Abs(planar.Area(outerB))
+	if areaA == 0 || areaB == 0 {
+		return 0
+	}
+
+	// Exact clipping needs a convex clip polygon. Two concave footprints aren't compared: their bounding
+	// boxes can overlap almost entirely while the shapes don't (e.g. two interlocking L shapes)
+	var intersection float64
+	switch {
+	case isConvexRing(outerB):
+		intersection = math.Abs(planar.Area(clipRing(outerA, outerB)))
+	case isConvexRing(outerA):
+		intersection = math.Abs(planar.Area(clipRing(outerB, outerA)))
+	default:
+		return 0
+	}
+
+	union := areaA + areaB - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// clipRing clips subject by a convex clip ring (Sutherland-Hodgman)
+func clipRing(subject, clip orb.Ring) orb.Ring {
+	// Clip edges must be counter-clockwise for the inside test
+	counterClockwise := clip.Orientation() == orb.CCW
+
+	clip = openRing(clip)
+	output := openRing(subject)
+
+	if !counterClockwise {
+		reversed := make(orb.Ring, len(clip))
+		for i, pt := range clip {
+			reversed[len(clip)-1-i] = pt
+		}
+		clip = reversed
+	}
+
+	for i := range clip {
+		if len(output) == 0 {
+			break
+		}
+
+		edgeStart, edgeEnd := clip[i], clip[(i+1)%len(clip)]
+		input := output
+		output = nil
+
+		for j := range input {
+			current, previous := input[j], input[(j+len(input)-1)%len(input)]
+			currentInside := isLeftOf(edgeStart, edgeEnd, current)
+			previousInside := isLeftOf(edgeStart, edgeEnd, previous)
+
+			if currentInside {
+				if !previousInside {
+					output = append(output, lineIntersection(previous, current, edgeStart, edgeEnd))
+				}
+				output = append(output, current)
+			} else if previousInside {
+				output = append(output, lineIntersection(previous, current, edgeStart, edgeEnd))
+			}
+		}
+	}
+
+	if len(output) < 3 {
+		return nil
+	}
+	return append(output, output[0])
+}
+
+// isConvexRing checks if all turns of a ring go in the same direction
+func isConvexRing(ring orb.Ring) bool {
+	points := openRing(ring)
+	if len(points) < 3 {
+		return false
+	}
+
+	sign := 0
+	for i := range points {
+		a, b, c := points[i], points[(i+1)%len(points)], points[(i+2)%len(points)]
+		cross := (b[0]-a[0])*(c[1]-b[1]) - (b[1]-a[1])*(c[0]-b[0])
+		if cross == 0 {
+			continue
+		}
+
+		current := 1
+		if cross < 0 {
+			current = -1
+		}
+		if sign == 0 {
+			sign = current
+		} else if sign != current {
+			return false
+		}
+	}
+	return sign != 0
+}
+
+// openRing returns ring points without the closing point
+func openRing(ring orb.Ring) orb.Ring {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// isLeftOf checks if point p is on the left of (or on) the directed edge a->b
+func isLeftOf(a, b, p orb.Point) bool {
+	return (b[0]-a[0])*(p[1]-a[1])-(b[1]-a[1])*(p[0]-a[0]) >= 0
+}
+
+// lineIntersection returns the intersection of segment p1-p2 with the infinite line through a-b
+func lineIntersection(p1, p2, a, b orb.Point) orb.Point {
+	dx, dy := p2[0]-p1[0], p2[1]-p1[1]
+	ex, ey := b[0]-a[0], b[1]-a[1]
+
+	denominator := dx*ey - dy*ex
+	if denominator == 0 {
+		return p2
+	}
+
+	t := ((a[0]-p1[0])*ey - (a[1]-p1[1])*ex) / denominator
+	return orb.Point{p1[0] + t*dx, p1[1] + t*dy}
+}
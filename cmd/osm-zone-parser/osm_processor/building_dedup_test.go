@@ -0,0 +1,107 @@
+package osm_processor
+
+import (
+	"testing"
+
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+)
+
+// testBuilding returns a house with the given [lon, lat] outline
+func testBuilding(id int64, outline orb.Ring) *model.Building {
+	polygon := orb.Polygon{outline}
+	centroid := polygon.Bound().Center()
+	return &model.Building{
+		ID:          id,
+		Levels:      1,
+		Type:        "house",
+		Outline:     polygon,
+		BoundingBox: polygon.Bound(),
+		CentroidLat: centroid[1],
+		CentroidLon: centroid[0],
+	}
+}
+
+// squareRing returns a square ring of size degrees with its lower left corner at (lon, lat)
+func squareRing(lon, lat, size float64) orb.Ring {
+	return orb.Bound{Min: orb.Point{lon, lat}, Max: orb.Point{lon + size, lat + size}}.ToRing()
+}
+
+// testZones returns 0.01 degree square zones needing recalculation, one per [lon, lat] corner
+func testZones(corners ...[2]float64) []*model.Zone {
+	zones := make([]*model.Zone, len(corners))
+	for i, c := range corners {
+		lon, lat := c[0], c[1]
+		zones[i] = &model.Zone{
+			ID:                string(rune('a' + i)),
+			TopLeftLatLon:     []float64{lat + 0.01, lon},
+			TopRightLatLon:    []float64{lat + 0.01, lon + 0.01},
+			BottomRightLatLon: []float64{lat, lon + 0.01},
+			BottomLeftLatLon:  []float64{lat, lon},
+			RecalculateNeeded: true,
+		}
+	}
+	return zones
+}
+
+// distribute adds the buildings to the processor and distributes them over the zones
+func distribute(t *testing.T, p *OSMProcessor, zones []*model.Zone) {
+	t.Helper()
+	zoneIndex, err := p.updateSpatialIndexWithNewZones(zones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.processRecalculationNeededZones(zones, zoneIndex); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeduplicateCountsNearlyIdenticalBuildingsOnce(t *testing.T) {
+	p := NewOSMProcessor(1000)
+	p.addBuilding(testBuilding(1, squareRing(-99.9955, 40.0045, 0.0002)))
+	p.addBuilding(testBuilding(2, squareRing(-99.99549, 40.00451, 0.0002)))
+
+	if duplicates := p.DeduplicateOverlappingBuildings(0.8); duplicates != 1 {
+		t.Fatalf("found %d duplicates, want 1", duplicates)
+	}
+
+	zones := testZones([2]float64{-100, 40})
+	distribute(t, p, zones)
+	if count := zones[0].Buildings.TotalCount; count != 1 {
+		t.Fatalf("zone counts %d buildings, want 1", count)
+	}
+}
+
+func TestDeduplicateIsPerZone(t *testing.T) {
+	// The duplicate's original only reaches the first zone, so the second zone still counts the duplicate
+	p := NewOSMProcessor(1000)
+	original := testBuilding(1, squareRing(-99.9955, 40.0045, 0.0002))
+	duplicate := testBuilding(2, squareRing(-99.4955, 40.0045, 0.0002))
+	p.addBuilding(original)
+	p.addBuilding(duplicate)
+	p.duplicateOf = map[*model.Building]*model.Building{duplicate: original}
+
+	zones := testZones([2]float64{-100, 40}, [2]float64{-99.5, 40})
+	distribute(t, p, zones)
+	for _, zone := range zones {
+		if zone.Buildings.TotalCount != 1 {
+			t.Fatalf("zone %s counts %d buildings, want 1", zone.ID, zone.Buildings.TotalCount)
+		}
+	}
+}
+
+func TestFootprintIoUSkipsConcavePairs(t *testing.T) {
+	// Two interlocking L shapes with the same bounding box and little overlap
+	lower := orb.Ring{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 0}}
+	upper := orb.Ring{{2, 2}, {0, 2}, {0, 1.9}, {1.9, 1.9}, {1.9, 0}, {2, 0}, {2, 2}}
+	if iou := footprintIoU(orb.Polygon{lower}, orb.Polygon{upper}); iou != 0 {
+		t.Fatalf("IoU of two concave footprints = %v, want 0 (not compared)", iou)
+	}
+
+	// A concave footprint is still compared with a convex one
+	square := orb.Ring{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}
+	if iou := footprintIoU(orb.Polygon{lower}, orb.Polygon{square}); iou < 0.74 || iou > 0.76 {
+		t.Fatalf("IoU of L shape and square = %v, want 0.75", iou)
+	}
+}
@@ -0,0 +1,79 @@
+package osm_processor
+
+import (
+	"fmt"
+	"sort"
+
+	"metalink/internal/logx"
+	"metalink/internal/model"
+)
+
+// collectDryRunStats distributes all buildings over the final zones once more without changing them
+// and compares the final zones with the initially loaded ones
+func (p *OSMProcessor) collectDryRunStats(zones []*model.Zone, initialZoneIDs map[string]bool) (*ProcessingStats, error) {
+	stats := &ProcessingStats{
+		TotalBuildings:   len(p.Buildings),
+		ZoneDependencies: NewZoneDependencies(),
+		CategoryCounts:   make(map[string]int),
+		CategoryAreas:    make(map[string]float64),
+	}
+
+	zoneIndex, err := p.updateSpatialIndexWithNewZones(zones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build spatial index: %w", err)
+	}
+
+	for _, building := range p.Buildings {
+		buildingArea, categories, zonesInRadius := p.buildingInfluence(building, zoneIndex)
+		zonesInRadius = p.withoutOriginalZones(building, zoneIndex, zonesInRadius)
+		if len(zonesInRadius) == 0 {
+			continue
+		}
+
+		stats.ProcessedBuildings++
+		if len(zonesInRadius) > 1 {
+			stats.BuildingsDistributedToMultipleZones++
+		}
+		for _, category := range categories {
+			stats.CategoryCounts[category.Category]++
+			stats.CategoryAreas[category.Category] += buildingArea * category.Share
+		}
+	}
+
+	finalZoneIDs := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		finalZoneIDs[zone.ID] = true
+		if !initialZoneIDs[zone.ID] {
+			stats.ZonesCreated++
+		}
+	}
+	for id := range initialZoneIDs {
+		if !finalZoneIDs[id] {
+			stats.ZonesDeleted++
+		}
+	}
+
+	return stats, nil
+}
+
+// logDryRunSummary logs what a real run would have written
+func logDryRunSummary(stats *ProcessingStats, finalZones int) {
+	logx.Info("=== Dry run summary (nothing was written) ===")
+	logx.Info("Total buildings: %d", stats.TotalBuildings)
+	logx.Info("Buildings distributed to zones: %d", stats.ProcessedBuildings)
+	logx.Info("Buildings distributed to multiple zones: %d", stats.BuildingsDistributedToMultipleZones)
+	logx.Info("Zones: %d would be created, %d deleted, %d in total", stats.ZonesCreated, stats.ZonesDeleted, finalZones)
+
+	categories := make([]string, 0, len(stats.CategoryCounts))
+	for category := range stats.CategoryCounts {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return stats.CategoryAreas[categories[i]] > stats.CategoryAreas[categories[j]]
+	})
+
+	logx.Info("Buildings by category:")
+	for _, category := range categories {
+		logx.Info("  %-30s %8d buildings %14.0f m2", category, stats.CategoryCounts[category], stats.CategoryAreas[category])
+	}
+}
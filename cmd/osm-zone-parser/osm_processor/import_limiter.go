@@ -0,0 +1,122 @@
+package osm_processor
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTooManyImports is returned when the concurrent import limit is reached and queuing is disabled
+// API callers should map it to HTTP 429
+var ErrTooManyImports = errors.New("too many concurrent OSM imports")
+
+// RunningImport describes a running OSM import
+type RunningImport struct {
+	File      string    `json:"file"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// ImportStatus describes currently running and queued OSM imports
+type ImportStatus struct {
+	MaxConcurrent int             `json:"max_concurrent"`
+	Queued        int             `json:"queued"`
+	Running       []RunningImport `json:"running"`
+}
+
+// importLimiter limits the number of concurrent OSM imports
+type importLimiter struct {
+	mutex         sync.Mutex
+	cond          *sync.Cond
+	maxConcurrent int
+	queueExtra    bool
+	queued        int
+	nextID        int
+	running       map[int]RunningImport
+}
+
+var imports = newImportLimiter(1, false)
+
+// newImportLimiter creates a limiter allowing maxConcurrent imports
+func newImportLimiter(maxConcurrent int, queueExtra bool) *importLimiter {
+	l := &importLimiter{
+		maxConcurrent: maxConcurrent,
+		queueExtra:    queueExtra,
+		running:       make(map[int]RunningImport),
+	}
+	l.cond = sync.NewCond(&l.mutex)
+	return l
+}
+
+// SetImportLimit configures the maximum number of concurrent imports (default 1)
+// Extra imports wait for a free slot when queue is true, otherwise they fail with ErrTooManyImports
+func SetImportLimit(maxConcurrent int, queue bool) {
+	imports.setLimit(maxConcurrent, queue)
+}
+
+// GetImportStatus returns the current import status
+func GetImportStatus() ImportStatus {
+	return imports.status()
+}
+
+// setLimit changes the limit, waking queued imports in case it was raised
+func (l *importLimiter) setLimit(maxConcurrent int, queue bool) {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	l.mutex.Lock()
+	l.maxConcurrent = maxConcurrent
+	l.queueExtra = queue
+	l.mutex.Unlock()
+
+	l.cond.Broadcast()
+}
+
+// status returns the running and queued imports
+func (l *importLimiter) status() ImportStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	running := make([]RunningImport, 0, len(l.running))
+	for _, runningImport := range l.running {
+		running = append(running, runningImport)
+	}
+
+	return ImportStatus{
+		MaxConcurrent: l.maxConcurrent,
+		Queued:        l.queued,
+		Running:       running,
+	}
+}
+
+// acquire takes an import slot for the file, waiting or failing when none is free
+// Returns the slot ID to release
+func (l *importLimiter) acquire(file string) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.running) >= l.maxConcurrent {
+		if !l.queueExtra {
+			return 0, ErrTooManyImports
+		}
+
+		l.queued++
+		for len(l.running) >= l.maxConcurrent {
+			l.cond.Wait()
+		}
+		l.queued--
+	}
+
+	l.nextID++
+	l.running[l.nextID] = RunningImport{File: file, StartedAt: time.Now()}
+	return l.nextID, nil
+}
+
+// release frees an import slot
+func (l *importLimiter) release(id int) {
+	l.mutex.Lock()
+	delete(l.running, id)
+	l.mutex.Unlock()
+
+	l.cond.Signal()
+}
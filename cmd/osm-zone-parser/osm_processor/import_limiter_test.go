@@ -0,0 +1,96 @@
+package osm_processor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestImportLimiterRejectsSecondImport(t *testing.T) {
+	l := newImportLimiter(1, false)
+	first, err := l.acquire("first.osm.pbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.acquire("second.osm.pbf"); !errors.Is(err, ErrTooManyImports) {
+		t.Fatalf("second import: err = %v, want ErrTooManyImports", err)
+	}
+	if status := l.status(); len(status.Running) != 1 || status.Running[0].File != "first.osm.pbf" {
+		t.Fatalf("status = %+v, want only the first import running", status)
+	}
+
+	l.release(first)
+	second, err := l.acquire("second.osm.pbf")
+	if err != nil {
+		t.Fatalf("import after release: %v", err)
+	}
+	l.release(second)
+}
+
+func TestImportLimiterQueuesSecondImport(t *testing.T) {
+	l := newImportLimiter(1, true)
+	first, err := l.acquire("first.osm.pbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan int)
+	go func() {
+		id, err := l.acquire("second.osm.pbf")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- id
+	}()
+
+	// Wait until the second import is queued
+	deadline := time.Now().Add(5 * time.Second)
+	for l.status().Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second import was not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-acquired:
+		t.Fatal("second import started while the first was running")
+	default:
+	}
+
+	l.release(first)
+	select {
+	case second := <-acquired:
+		if status := l.status(); status.Queued != 0 || len(status.Running) != 1 || status.Running[0].File != "second.osm.pbf" {
+			t.Fatalf("status = %+v, want only the second import running", status)
+		}
+		l.release(second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second import did not start after the first was released")
+	}
+}
+
+func TestImportLimiterRaisedLimitWakesQueuedImport(t *testing.T) {
+	l := newImportLimiter(1, true)
+	if _, err := l.acquire("first.osm.pbf"); err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if _, err := l.acquire("second.osm.pbf"); err != nil {
+			t.Error(err)
+		}
+		close(acquired)
+	}()
+	for l.status().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	l.setLimit(2, true)
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued import did not start after the limit was raised")
+	}
+}
@@ -0,0 +1,107 @@
+package osm_processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qedus/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileBlock frames blob data as a fileblock of the given type (OSMHeader or OSMData)
+func fileBlock(t testing.TB, blockType string, data []byte) []byte {
+	t.Helper()
+	blob, err := proto.Marshal(&OSMPBF.Blob{RawSize: proto.Int32(int32(len(data))), Data: &OSMPBF.Blob_Raw{Raw: data}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := proto.Marshal(&OSMPBF.BlobHeader{Type: proto.String(blockType), Datasize: proto.Int32(int32(len(blob)))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+	block = append(block, header...)
+	return append(block, blob...)
+}
+
+// headerFileBlock returns the OSMHeader fileblock of a fixture
+func headerFileBlock(t testing.TB) []byte {
+	t.Helper()
+	data, err := proto.Marshal(&OSMPBF.HeaderBlock{RequiredFeatures: []string{"OsmSchema-V0.6"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileBlock(t, "OSMHeader", data)
+}
+
+// buildingFileBlock returns an OSMData fileblock with the four nodes and the way of a square house
+// about 20 m wide; building i has way ID i+1 and node IDs from 4i+1, and is 0.001° east of building i-1
+func buildingFileBlock(t testing.TB, i int) []byte {
+	t.Helper()
+	const size = 2000 // 0.0002° in the default granularity of 100 nanodegrees
+	lat, lon := int64(40*1e7), int64(-100*1e7)+int64(i)*10000
+
+	group := &OSMPBF.PrimitiveGroup{}
+	firstNode := int64(4*i + 1)
+	for v, corner := range [][2]int64{{0, 0}, {0, size}, {size, size}, {size, 0}} {
+		group.Nodes = append(group.Nodes, &OSMPBF.Node{
+			Id:  proto.Int64(firstNode + int64(v)),
+			Lat: proto.Int64(lat + corner[0]),
+			Lon: proto.Int64(lon + corner[1]),
+		})
+	}
+	// Delta coded refs of the closed ring first, first+1, first+2, first+3, first
+	group.Ways = []*OSMPBF.Way{{
+		Id:   proto.Int64(int64(i + 1)),
+		Keys: []uint32{1},
+		Vals: []uint32{2},
+		Refs: []int64{firstNode, 1, 1, 1, -3},
+	}}
+
+	data, err := proto.Marshal(&OSMPBF.PrimitiveBlock{
+		Stringtable:    &OSMPBF.StringTable{S: []string{"", "building", "house"}},
+		Primitivegroup: []*OSMPBF.PrimitiveGroup{group},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileBlock(t, "OSMData", data)
+}
+
+// corruptFileBlock returns an OSMData fileblock with valid framing but a blob that isn't a PrimitiveBlock
+func corruptFileBlock(t testing.TB) []byte {
+	t.Helper()
+	return fileBlock(t, "OSMData", []byte{0x0a, 0xff, 0x01})
+}
+
+// testPBF returns a PBF stream with one house per data fileblock; corrupt lists the
+// buildings whose fileblocks are replaced by corrupt ones
+func testPBF(t testing.TB, buildings int, corrupt ...int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.Write(headerFileBlock(t))
+	for i := 0; i < buildings; i++ {
+		block := buildingFileBlock(t, i)
+		for _, c := range corrupt {
+			if c == i {
+				block = corruptFileBlock(t)
+			}
+		}
+		buf.Write(block)
+	}
+	return buf.Bytes()
+}
+
+// writeTestFile writes fixture data to a file in a temp dir and returns its path
+func writeTestFile(t testing.TB, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
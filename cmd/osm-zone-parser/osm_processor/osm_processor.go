@@ -1,23 +1,31 @@
 package osm_processor
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	parser_db "metalink/cmd/osm-zone-parser/db"
+	mappers "metalink/cmd/osm-zone-parser/mappers"
 	utils "metalink/cmd/osm-zone-parser/utils"
+	"metalink/internal/logx"
 	"metalink/internal/model"
+	"metalink/internal/util"
 
 	"github.com/dhconnelly/rtreego"
 	"github.com/paulmach/orb"
 	"github.com/qedus/osmpbf"
 )
 
+// DefaultExcludedBuildingTypes lists OSM building types that aren't real enclosed space
+var DefaultExcludedBuildingTypes = []string{"bridge", "roof"}
+
 // OSMProcessor handles processing of OSM PBF files
 type OSMProcessor struct {
 	Buildings      []*model.Building
@@ -25,15 +33,72 @@ type OSMProcessor struct {
 	ProcessedNodes map[int64]orb.Point
 	mutex          sync.Mutex
 	MinZoneSize    float64 // Minimum zone size in meters
+	KeepRawTypes   bool    // Additionally record raw OSM building types in zone stats
+	DryRun         bool    // Skip all database writes and file exports, log a summary instead
+
+	LevelBuckets []model.LevelBucket // Custom level ranges recorded in zone stats (nil = fixed height buckets only)
+
+	IncrementalEffects bool // Accumulate zone effects while distributing buildings instead of a separate pass
+	ParallelBuildings  bool // Build buildings from ways on a worker pool
+
+	DecompressToTempFile bool // Decompress gzip/bzip2 OSM files once to a temp file instead of on every pass
+
+	MaxDecodeErrors int // Corrupt fileblocks skipped per pass before failing (0 = fail on the first decode error)
+	SkippedBlocks   int // Count of corrupt fileblocks skipped (both passes skip the same blocks)
+
+	DedupIoUThreshold float64                             // Count overlapping buildings with IoU at or above this value once per zone (0 = disabled)
+	duplicateOf       map[*model.Building]*model.Building // Duplicate building -> the earlier building it overlaps
+
+	MinBuildingArea float64 // Skip buildings with a smaller footprint in square meters (0 = keep all)
+	SmallBuildings  int64   // Count of buildings skipped below MinBuildingArea, updated atomically
+
+	WeightThreshold float64 // Zone split threshold, overrides the effects config value when > 0
+	SplitByArea     bool    // Compare total building area instead of weighted area against the threshold
+
+	ExcludedTypes     map[string]bool // OSM building types skipped during processing
+	ExcludedBuildings map[string]int  // Count of skipped buildings by OSM type
+
+	FallbackCategory  string         // Game category for OSM types without a mapping
+	UnmappedBuildings map[string]int // Count of buildings that hit the fallback by raw OSM type
+
+	ProcessPOIs  bool   // Collect amenity/shop/leisure nodes as zone effect sources
+	POIs         []*POI // Points of interest collected during the node pass
+	UnmappedPOIs int    // Count of POI nodes without a game category mapping
+
+	roi          *orb.Bound // Region of interest in [lon, lat] (nil = whole file), see SetBoundingBox
+	roiNodeBound orb.Bound  // Region of interest padded by the node margin
+	ClippedNodes int        // Count of nodes dropped outside the region of interest
+
+	queryZones func(minLat, minLng, maxLat, maxLng, bufferMeters float64) ([]*model.Zone, error) // Zone source, parser_db.QueryZonesFromDB
 }
 
+// ErrNoZonesForBuildings is returned when no zones exist around the processed buildings
+var ErrNoZonesForBuildings = errors.New("no zones found for processed buildings")
+
 // NewOSMProcessor creates a new OSM processor
 func NewOSMProcessor(minZoneSize float64) *OSMProcessor {
-	return &OSMProcessor{
-		Buildings:      make([]*model.Building, 0),
-		SpatialIndex:   rtreego.NewTree(2, 25, 50), // 2D index with min 25, max 50 entries per node
-		ProcessedNodes: make(map[int64]orb.Point),
-		MinZoneSize:    minZoneSize,
+	p := &OSMProcessor{
+		Buildings:         make([]*model.Building, 0),
+		SpatialIndex:      rtreego.NewTree(2, 25, 50), // 2D index with min 25, max 50 entries per node
+		ProcessedNodes:    make(map[int64]orb.Point),
+		MinZoneSize:       minZoneSize,
+		ExcludedBuildings: make(map[string]int),
+		FallbackCategory:  mappers.UnknownBuildingCategory,
+		UnmappedBuildings: make(map[string]int),
+		queryZones:        parser_db.QueryZonesFromDB,
+	}
+	p.SetExcludedTypes(DefaultExcludedBuildingTypes)
+	return p
+}
+
+// SetExcludedTypes replaces the set of OSM building types skipped during processing
+func (p *OSMProcessor) SetExcludedTypes(buildingTypes []string) {
+	p.ExcludedTypes = make(map[string]bool, len(buildingTypes))
+	for _, buildingType := range buildingTypes {
+		buildingType = strings.TrimSpace(strings.ToLower(buildingType))
+		if buildingType != "" {
+			p.ExcludedTypes[buildingType] = true
+		}
 	}
 }
 
@@ -43,6 +108,14 @@ type ProcessingStats struct {
 	BuildingsDistributedToMultipleZones int
 	TotalBuildings                      int
 	ZoneDependencies                    *ZoneDependencies
+
+	// Aggregates by game category, filled for dry-run summaries
+	CategoryCounts map[string]int
+	CategoryAreas  map[string]float64
+
+	// Zone changes compared to the zones loaded from the database, filled for dry-run summaries
+	ZonesCreated int
+	ZonesDeleted int
 }
 
 // BoundingBox represents a geographic bounding box
@@ -51,52 +124,106 @@ type BoundingBox struct {
 }
 
 // ProcessOSMFile processes an OSM PBF file and extracts buildings
+// Fails with ErrTooManyImports if the concurrent import limit is reached (see SetImportLimit)
+// On a decode error (e.g. truncated file) the error is returned and buildings processed
+// so far are kept in p.Buildings, so the caller can decide whether to use partial results
 func (p *OSMProcessor) ProcessOSMFile(osmFilePath string) error {
-	log.Printf("Processing OSM file: %s", osmFilePath)
+	// Limit concurrent imports to protect memory
+	importID, err := imports.acquire(osmFilePath)
+	if err != nil {
+		return err
+	}
+	defer imports.release(importID)
+
+	logx.Info("Processing OSM file: %s", osmFilePath)
 
-	// Open the OSM PBF file
-	file, err := os.Open(osmFilePath)
+	// Open the OSM PBF file, unwrapping gzip or bzip2 compression
+	source, err := openOSMSource(osmFilePath, p.DecompressToTempFile)
 	if err != nil {
-		return fmt.Errorf("failed to open OSM file: %w", err)
+		return err
 	}
-	defer file.Close()
+	defer source.Close()
 
-	// Create a new decoder
-	decoder := osmpbf.NewDecoder(file)
-	decoder.SetBufferSize(osmpbf.MaxBlobSize)
+	reader, err := source.reader()
+	if err != nil {
+		return err
+	}
 
-	// Use all available CPU cores
-	decoder.Start(runtime.GOMAXPROCS(-1))
+	// Create a new decoder
+	decoder, err := p.newDecoder(reader)
+	if err != nil {
+		return err
+	}
 
 	// First pass: collect all nodes
-	log.Println("First pass: collecting nodes...")
-	if err := p.collectNodes(decoder); err != nil {
+	logx.Info("First pass: collecting nodes...")
+	err = p.collectNodes(decoder)
+	p.countSkippedBlocks(decoder)
+	if err != nil {
 		return err
 	}
 
 	// Rewind the file for the second pass
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to rewind OSM file: %w", err)
+	reader, err = source.reader()
+	if err != nil {
+		return err
 	}
 
 	// Reset and restart the decoder
-	decoder = osmpbf.NewDecoder(file)
-	decoder.SetBufferSize(osmpbf.MaxBlobSize)
-	decoder.Start(runtime.GOMAXPROCS(-1))
+	decoder, err = p.newDecoder(reader)
+	if err != nil {
+		return err
+	}
 
 	// Second pass: process ways (buildings)
-	log.Println("Second pass: processing buildings...")
-	if err := p.processBuildings(decoder); err != nil {
+	logx.Info("Second pass: processing buildings...")
+	err = p.processBuildings(decoder)
+	p.countSkippedBlocks(decoder)
+	if err != nil {
 		return err
 	}
+	if p.SkippedBlocks > 0 {
+		logx.Warn("Skipped %d corrupt OSM fileblocks, results may be missing nodes or buildings", p.SkippedBlocks)
+	}
+
+	// Find duplicate footprints so their area isn't counted twice in a zone
+	if p.DedupIoUThreshold > 0 {
+		p.DeduplicateOverlappingBuildings(p.DedupIoUThreshold)
+	}
 
-	log.Printf("Processing complete. Found %d buildings.", len(p.Buildings))
+	logx.Info("Processing complete. Found %d buildings.", len(p.Buildings))
 	return nil
 }
 
+// newDecoder starts decoding a pass over the OSM stream on all CPU cores
+// With MaxDecodeErrors set, corrupt fileblocks are skipped instead of failing the pass
+func (p *OSMProcessor) newDecoder(reader io.Reader) (osmDecoder, error) {
+	workers := runtime.GOMAXPROCS(-1)
+	if p.MaxDecodeErrors > 0 {
+		return newSkippingDecoder(reader, workers, p.MaxDecodeErrors)
+	}
+
+	// The decoder reports a truncated stream as a clean EOF, so the framing is checked alongside
+	reader, framing := withFramingCheck(reader)
+	decoder := osmpbf.NewDecoder(reader)
+	decoder.SetBufferSize(osmpbf.MaxBlobSize)
+	if err := decoder.Start(workers); err != nil {
+		framing.finish()
+		return nil, fmt.Errorf("failed to read OSM header: %w", err)
+	}
+	return &framedDecoder{Decoder: decoder, framing: framing}, nil
+}
+
+// countSkippedBlocks records the fileblocks skipped by a finished pass in SkippedBlocks
+func (p *OSMProcessor) countSkippedBlocks(decoder osmDecoder) {
+	if skipping, ok := decoder.(*skippingDecoder); ok {
+		p.SkippedBlocks = max(p.SkippedBlocks, skipping.Skipped())
+	}
+}
+
 // collectNodes collects all nodes from the OSM file
-func (p *OSMProcessor) collectNodes(decoder *osmpbf.Decoder) error {
-	var nodeCount int
+func (p *OSMProcessor) collectNodes(decoder osmDecoder) error {
+	nodeCount := NewProgressCounter("nodes", 1000000)
 
 	for {
 		// Get the next OSM object
@@ -105,30 +232,48 @@ func (p *OSMProcessor) collectNodes(decoder *osmpbf.Decoder) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("error decoding OSM data: %w", err)
+			return fmt.Errorf("error decoding OSM data after %d nodes: %w", nodeCount.Load(), err)
 		}
 
 		// Process only nodes
 		if node, ok := obj.(*osmpbf.Node); ok {
+			point := orb.Point{node.Lon, node.Lat}
+			if !p.keepNode(point) {
+				p.ClippedNodes++
+				continue
+			}
+
 			p.mutex.Lock()
-			p.ProcessedNodes[node.ID] = orb.Point{node.Lon, node.Lat}
+			p.ProcessedNodes[node.ID] = point
 			p.mutex.Unlock()
-			nodeCount++
 
-			// Log progress periodically
-			if nodeCount%1000000 == 0 {
-				log.Printf("Processed %d nodes...", nodeCount)
+			// Standalone points of interest are effect sources of their own
+			if p.ProcessPOIs && p.inROI(point) && mappers.IsNodeOfInterest(node.Tags) {
+				p.addPOI(node)
 			}
+
+			// Count and log progress outside the lock
+			nodeCount.Inc()
 		}
 	}
 
-	log.Printf("Collected %d nodes", nodeCount)
+	logx.Info("Collected %d nodes", nodeCount.Load())
+	if p.roi != nil {
+		logx.Info("Dropped %d nodes outside the region of interest", p.ClippedNodes)
+	}
+	if p.ProcessPOIs {
+		logx.Info("Collected %d points of interest (%d without category mapping)", len(p.POIs), p.UnmappedPOIs)
+	}
 	return nil
 }
 
 // processBuildings processes building ways from the OSM file
-func (p *OSMProcessor) processBuildings(decoder *osmpbf.Decoder) error {
-	var buildingCount int
+func (p *OSMProcessor) processBuildings(decoder osmDecoder) error {
+	if p.ParallelBuildings {
+		return p.processBuildingsParallel(decoder)
+	}
+
+	buildingCount := NewProgressCounter("buildings", 10000)
 
 	for {
 		// Get the next OSM object
@@ -137,37 +282,207 @@ func (p *OSMProcessor) processBuildings(decoder *osmpbf.Decoder) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("error decoding OSM data: %w", err)
+			return fmt.Errorf("error decoding OSM data after %d buildings: %w", buildingCount.Load(), err)
+		}
+
+		// Process only building ways
+		if way, ok := obj.(*osmpbf.Way); ok && p.isCandidateBuilding(way) {
+			building := p.processBuilding(way)
+			if building != nil {
+				p.addBuilding(building)
+
+				// Count and log progress outside the lock
+				buildingCount.Inc()
+			}
 		}
+	}
+
+	p.logBuildingSummary(buildingCount.Load())
+	return nil
+}
 
-		// Process only ways
-		if way, ok := obj.(*osmpbf.Way); ok {
-			// Check if this way is a building
-			if isBuildingTag, ok := way.Tags["building"]; ok && isBuildingTag != "no" {
-				building := p.processBuilding(way)
-				if building != nil {
-					p.mutex.Lock()
-					p.Buildings = append(p.Buildings, building)
-
-					// Add to spatial index
-					p.SpatialIndex.Insert(&model.BuildingSpatial{Building: building})
-					p.mutex.Unlock()
-
-					buildingCount++
-
-					// Log progress periodically
-					if buildingCount%10000 == 0 {
-						log.Printf("Processed %d buildings...", buildingCount)
-					}
+// processBuildingsParallel builds buildings from ways on a pool of workers
+// Results are collected by a single goroutine; workers finish in any order, so the buildings are
+// sorted by ID and bulk-loaded into the spatial index once the pass is done
+func (p *OSMProcessor) processBuildingsParallel(decoder osmDecoder) error {
+	buildingCount := NewProgressCounter("buildings", 10000)
+	numWorkers := runtime.GOMAXPROCS(-1)
+
+	ways := make(chan *osmpbf.Way, numWorkers*64)
+	results := make(chan *model.Building, numWorkers*64)
+
+	// Workers: build polygons, centroids and properties
+	var workersWg sync.WaitGroup
+	workersWg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer workersWg.Done()
+			for way := range ways {
+				if building := p.processBuilding(way); building != nil {
+					results <- building
 				}
 			}
+		}()
+	}
+
+	// Collector: append buildings in completion order
+	collectorDone := make(chan struct{})
+	go func() {
+		defer close(collectorDone)
+		for building := range results {
+			p.mutex.Lock()
+			p.recordBuilding(building)
+			p.mutex.Unlock()
+			buildingCount.Inc()
+		}
+	}()
+
+	// Stop workers and collector, keeping and indexing everything processed so far
+	finish := func() {
+		close(ways)
+		workersWg.Wait()
+		close(results)
+		<-collectorDone
+		p.indexBuildingsByID()
+	}
+
+	logx.Info("Processing buildings with %d workers", numWorkers)
+
+	for {
+		// Get the next OSM object
+		obj, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			finish()
+			return fmt.Errorf("error decoding OSM data after %d buildings: %w", buildingCount.Load(), err)
+		}
+
+		// Dispatch only building ways
+		if way, ok := obj.(*osmpbf.Way); ok && p.isCandidateBuilding(way) {
+			ways <- way
 		}
 	}
 
-	log.Printf("Processed %d buildings", buildingCount)
+	finish()
+	p.logBuildingSummary(buildingCount.Load())
 	return nil
 }
 
+// isCandidateBuilding checks if a way is a building that isn't excluded
+// Counts excluded buildings by type
+func (p *OSMProcessor) isCandidateBuilding(way *osmpbf.Way) bool {
+	buildingTag, ok := way.Tags["building"]
+	if !ok || buildingTag == "no" {
+		return false
+	}
+
+	// Skip excluded (non-habitable) building types
+	if normalizedType := strings.ToLower(buildingTag); p.ExcludedTypes[normalizedType] {
+		p.ExcludedBuildings[normalizedType]++
+		return false
+	}
+
+	return true
+}
+
+// addBuilding stores a processed building and adds it to the spatial index
+func (p *OSMProcessor) addBuilding(building *model.Building) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.recordBuilding(building)
+
+	// Add to spatial index
+	p.SpatialIndex.Insert(&model.BuildingSpatial{Building: building})
+}
+
+// recordBuilding appends a processed building without indexing it, the caller holds p.mutex
+func (p *OSMProcessor) recordBuilding(building *model.Building) {
+	p.Buildings = append(p.Buildings, building)
+
+	// Track buildings that will fall back to the default category
+	if !mappers.IsMappedBuildingType(building.Type) {
+		p.UnmappedBuildings[building.Type]++
+	}
+}
+
+// indexBuildingsByID sorts the buildings by OSM ID and bulk-loads them into a new spatial index
+// Makes the building order (and the first-wins choice of de-duplication) the same on every run
+func (p *OSMProcessor) indexBuildingsByID() {
+	sort.Slice(p.Buildings, func(i, j int) bool {
+		return p.Buildings[i].ID < p.Buildings[j].ID
+	})
+
+	objs := make([]rtreego.Spatial, len(p.Buildings))
+	for i, building := range p.Buildings {
+		objs[i] = &model.BuildingSpatial{Building: building}
+	}
+	p.SpatialIndex = rtreego.NewTree(2, 25, 50, objs...)
+}
+
+// logBuildingSummary logs processed, excluded and unmapped building counts
+func (p *OSMProcessor) logBuildingSummary(buildingCount int64) {
+	logx.Info("Processed %d buildings", buildingCount)
+
+	excludedCount := 0
+	for buildingType, count := range p.ExcludedBuildings {
+		logx.Info("Excluded %d buildings of type %q", count, buildingType)
+		excludedCount += count
+	}
+	logx.Info("Excluded %d buildings in total", excludedCount)
+	if p.MinBuildingArea > 0 {
+		logx.Info("Skipped %d buildings smaller than %.1f m2", atomic.LoadInt64(&p.SmallBuildings), p.MinBuildingArea)
+	}
+
+	unmappedCount := 0
+	for _, count := range p.UnmappedBuildings {
+		unmappedCount += count
+	}
+	logx.Info("%d buildings (%d types) have no category mapping and use fallback category %q",
+		unmappedCount, len(p.UnmappedBuildings), p.FallbackCategory)
+}
+
+// gameCategory maps an OSM building type to its game category using the configured fallback
+func (p *OSMProcessor) gameCategory(osmBuildingType string) string {
+	return mappers.GetSimplifiedBuildingTypeWithDefault(osmBuildingType, p.FallbackCategory)
+}
+
+// buildingCategories returns the game categories of a building including mixed-use secondary tags
+// The first category is the dominant one
+func (p *OSMProcessor) buildingCategories(building *model.Building) []mappers.CategoryShare {
+	return mappers.ResolveBuildingCategories(p.gameCategory(building.Type), building.Tags)
+}
+
+// LogTopUnmappedTypes logs the most frequent OSM building types without a category mapping
+func (p *OSMProcessor) LogTopUnmappedTypes(topN int) {
+	if len(p.UnmappedBuildings) == 0 {
+		logx.Info("All building types have a category mapping")
+		return
+	}
+
+	types := make([]string, 0, len(p.UnmappedBuildings))
+	for buildingType := range p.UnmappedBuildings {
+		types = append(types, buildingType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if p.UnmappedBuildings[types[i]] != p.UnmappedBuildings[types[j]] {
+			return p.UnmappedBuildings[types[i]] > p.UnmappedBuildings[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	if topN > 0 && len(types) > topN {
+		types = types[:topN]
+	}
+
+	logx.Info("Top %d unmapped building types:", len(types))
+	for _, buildingType := range types {
+		logx.Info("  %q: %d buildings", buildingType, p.UnmappedBuildings[buildingType])
+	}
+}
+
 // processBuilding processes a single building way
 func (p *OSMProcessor) processBuilding(way *osmpbf.Way) *model.Building {
 	// Skip if not enough nodes to form a polygon
@@ -200,18 +515,30 @@ func (p *OSMProcessor) processBuilding(way *osmpbf.Way) *model.Building {
 	// Calculate centroid
 	centroid := utils.CalculateCentroid(points)
 
+	// Buildings belong to the region of interest by their centroid only
+	if !p.inROI(centroid) {
+		return nil
+	}
+
 	// Extract building properties
-	levels := 1 // Default to 1 level
+	height := 0.0
+	if heightStr, ok := way.Tags["height"]; ok {
+		if h, ok := util.ParseOSMHeight(heightStr); ok {
+			height = h
+		}
+	}
+
+	levels := 0
 	if levelsStr, ok := way.Tags["building:levels"]; ok {
 		if l, err := strconv.Atoi(levelsStr); err == nil && l > 0 {
 			levels = l
 		}
 	}
-
-	height := 0.0
-	if heightStr, ok := way.Tags["height"]; ok {
-		if h, err := strconv.ParseFloat(heightStr, 64); err == nil && h > 0 {
-			height = h
+	if levels == 0 {
+		// Estimate levels from the height if known, default to 1 level
+		levels = 1
+		if height > 0 {
+			levels = util.EstimateLevelsFromHeight(height)
 		}
 	}
 
@@ -229,6 +556,12 @@ func (p *OSMProcessor) processBuilding(way *osmpbf.Way) *model.Building {
 		CentroidLon: centroid[0], // Lon
 	}
 
+	// Drop tiny fragments (sheds, mapping noise) before they are indexed
+	if p.MinBuildingArea > 0 && building.FootprintArea() < p.MinBuildingArea {
+		atomic.AddInt64(&p.SmallBuildings, 1)
+		return nil
+	}
+
 	return building
 }
 
@@ -242,11 +575,11 @@ func (p *OSMProcessor) GetZonesForProcessedBuildings(bufferMeters float64) ([]*m
 	// Calculate bounding box of all processed buildings
 	boundingBox := p.calculateBuildingsBoundingBox()
 
-	log.Printf("Buildings bounding box: [%.6f, %.6f] to [%.6f, %.6f]",
+	logx.Info("Buildings bounding box: [%.6f, %.6f] to [%.6f, %.6f]",
 		boundingBox.minLat, boundingBox.minLng, boundingBox.maxLat, boundingBox.maxLng)
 
 	// Query zones that intersect with the buildings' bounding box
-	zones, err := parser_db.QueryZonesFromDB(
+	zones, err := p.queryZones(
 		boundingBox.minLat,
 		boundingBox.minLng,
 		boundingBox.maxLat,
@@ -254,12 +587,20 @@ func (p *OSMProcessor) GetZonesForProcessedBuildings(bufferMeters float64) ([]*m
 		bufferMeters,
 	)
 	if err != nil {
-		log.Fatalf("Failed to query zones from database: %v", err)
+		return nil, fmt.Errorf("failed to query zones from database: %w", err)
 	}
 
-	err = utils.ExportZonesToGeoJSON(zones, "output_zones.geojson", false, false)
-	if err != nil {
-		log.Fatalf("Failed to export zones: %v", err)
+	// Nothing to update without zones, most likely the base grid doesn't cover this region
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("%w: bounding box [%.6f, %.6f] to [%.6f, %.6f] (is the base grid initialized for this region? run -mode 1 with matching -region-* flags)",
+			ErrNoZonesForBuildings, boundingBox.minLat, boundingBox.minLng, boundingBox.maxLat, boundingBox.maxLng)
+	}
+
+	if !p.DryRun {
+		err = utils.ExportZonesToGeoJSON(zones, "output_zones.geojson", false, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export zones: %w", err)
+		}
 	}
 
 	return zones, nil
@@ -304,37 +645,63 @@ func (p *OSMProcessor) calculateBuildingsBoundingBox() BoundingBox {
 }
 
 // UpdateZonesWithBuildingStats updates zones with building statistics using adaptive subdivision
-func (p *OSMProcessor) UpdateZonesWithBuildingStats(zones []*model.Zone, clearZones bool, exportZonesJSON bool, exportBuildingsJSON bool) error {
+// Returns the final zones, which replace split zones by their parts
+func (p *OSMProcessor) UpdateZonesWithBuildingStats(zones []*model.Zone, clearZones bool, exportZonesJSON bool, exportBuildingsJSON bool) ([]*model.Zone, error) {
 	if len(p.Buildings) == 0 {
-		return fmt.Errorf("no buildings processed yet")
+		return nil, fmt.Errorf("no buildings processed yet")
 	}
 
-	log.Printf("Updating %d zones with building statistics from %d buildings using adaptive subdivision", len(zones), len(p.Buildings))
+	logx.Info("Updating %d zones with building statistics from %d buildings using adaptive subdivision", len(zones), len(p.Buildings))
+
+	// Remember the loaded zones to report created and deleted zones in dry-run mode
+	var initialZoneIDs map[string]bool
+	if p.DryRun {
+		initialZoneIDs = make(map[string]bool, len(zones))
+		for _, zone := range zones {
+			initialZoneIDs[zone.ID] = true
+		}
+	}
 
 	// Clear zones from database if requested
-	if clearZones {
+	if clearZones && !p.DryRun {
 		if err := parser_db.ClearAllZonesFromDB(); err != nil {
-			return fmt.Errorf("failed to clear zones from database: %w", err)
+			return nil, fmt.Errorf("failed to clear zones from database: %w", err)
 		}
 	}
 
 	// Run the adaptive zone subdivision algorithm and get deleted zone IDs
 	deletedZoneIDs, err := p.runAdaptiveZoneSubdivision(&zones)
 	if err != nil {
-		return fmt.Errorf("adaptive zone subdivision failed: %w", err)
+		return nil, fmt.Errorf("adaptive zone subdivision failed: %w", err)
+	}
+
+	// POIs don't take part in splitting, they're added to the final zones
+	if p.ProcessPOIs {
+		if err := p.distributePOIsToZones(zones); err != nil {
+			return nil, fmt.Errorf("failed to distribute points of interest: %w", err)
+		}
+	}
+
+	if p.DryRun {
+		stats, err := p.collectDryRunStats(zones, initialZoneIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect dry-run stats: %w", err)
+		}
+		logDryRunSummary(stats, len(zones))
+		return zones, nil
 	}
 
 	// Save results to database (including deletion of old zones)
 	if err := p.saveProcessingResultsToDB(zones, nil, deletedZoneIDs); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Export results to files
 	if err := p.saveProcessingResultsToGeoJSON(zones, exportZonesJSON, exportBuildingsJSON, nil); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return zones, nil
 }
 
 // saveProcessingResultsToDB saves updated zones and test zone to database, and deletes removed zones
@@ -344,7 +711,7 @@ func (p *OSMProcessor) saveProcessingResultsToDB(zones []*model.Zone, testZone *
 		if err := parser_db.DeleteZonesFromDB(deletedZoneIDs); err != nil {
 			return fmt.Errorf("failed to delete old zones from database: %w", err)
 		}
-		log.Printf("Successfully deleted %d old zones from database", len(deletedZoneIDs))
+		logx.Info("Successfully deleted %d old zones from database", len(deletedZoneIDs))
 	}
 
 	// Save updated zones to database
@@ -367,23 +734,23 @@ func (p *OSMProcessor) saveProcessingResultsToGeoJSON(zones []*model.Zone, expor
 	// Export zones to GeoJSON if enabled
 	if exportZonesJSON {
 		if err := utils.ExportZonesToGeoJSON(zones, "processed_zones.geojson", false, false); err != nil {
-			log.Printf("Warning: Failed to export zones to GeoJSON: %v", err)
+			logx.Warn("Failed to export zones to GeoJSON: %v", err)
 		}
 	}
 
 	// Export buildings as squares to GeoJSON if enabled
 	if exportBuildingsJSON {
 		if err := utils.ExportBuildingsToGeoJSON(p.Buildings, "buildings.geojson", 0); err != nil {
-			log.Printf("Warning: Failed to export buildings to GeoJSON: %v", err)
+			logx.Warn("Failed to export buildings to GeoJSON: %v", err)
 		} else {
-			log.Printf("Successfully exported buildings to buildings.geojson")
+			logx.Info("Successfully exported buildings to buildings.geojson")
 		}
 	}
 
 	// Save test zone to JSON
 	if testZone != nil {
 		if err := p.SaveTestZoneToJSON(testZone, "test_zone.json"); err != nil {
-			log.Printf("Warning: Failed to save test zone to JSON: %v", err)
+			logx.Warn("Failed to save test zone to JSON: %v", err)
 		}
 	}
 
@@ -396,7 +763,7 @@ func (p *OSMProcessor) SaveAllBuildingsToTestZone(exportZonesJSON bool, exportBu
 		return fmt.Errorf("no buildings processed yet")
 	}
 
-	log.Printf("Creating test zone and saving all %d buildings to it", len(p.Buildings))
+	logx.Info("Creating test zone and saving all %d buildings to it", len(p.Buildings))
 
 	// Create test zone
 	testZone := p.createTestZone()
@@ -410,22 +777,22 @@ func (p *OSMProcessor) SaveAllBuildingsToTestZone(exportZonesJSON bool, exportBu
 	if err := p.saveTestZoneToDB(testZone); err != nil {
 		return fmt.Errorf("failed to save test zone to database: %w", err)
 	}
-	log.Println("Successfully saved test zone to database")
+	logx.Info("Successfully saved test zone to database")
 
 	// Export buildings to GeoJSON if enabled
 	if exportBuildingsJSON {
 		if err := utils.ExportBuildingsToGeoJSON(p.Buildings, "test_zone_buildings.geojson", 0); err != nil {
-			log.Printf("Warning: Failed to export buildings to GeoJSON: %v", err)
+			logx.Warn("Failed to export buildings to GeoJSON: %v", err)
 		} else {
-			log.Printf("Successfully exported buildings to test_zone_buildings.geojson")
+			logx.Info("Successfully exported buildings to test_zone_buildings.geojson")
 		}
 	}
 
 	// Save test zone to JSON
 	if err := p.SaveTestZoneToJSON(testZone, "test_zone_complete.json"); err != nil {
-		log.Printf("Warning: Failed to save test zone to JSON: %v", err)
+		logx.Warn("Failed to save test zone to JSON: %v", err)
 	} else {
-		log.Printf("Successfully saved test zone statistics to test_zone_complete.json")
+		logx.Info("Successfully saved test zone statistics to test_zone_complete.json")
 	}
 
 	return nil
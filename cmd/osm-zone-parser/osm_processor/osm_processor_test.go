@@ -0,0 +1,315 @@
+package osm_processor
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+	"github.com/qedus/osmpbf"
+)
+
+// sliceDecoder replays decoded OSM objects
+type sliceDecoder struct {
+	objs []interface{}
+	next int
+}
+
+func (d *sliceDecoder) Decode() (interface{}, error) {
+	if d.next >= len(d.objs) {
+		return nil, io.EOF
+	}
+	d.next++
+	return d.objs[d.next-1], nil
+}
+
+// syntheticBuildings returns the nodes and the ways of n square buildings about 20 m wide,
+// with ways in file (ID) order and irregular outlines so processing takes some work
+func syntheticBuildings(n int) (map[int64]orb.Point, []interface{}) {
+	rng := rand.New(rand.NewSource(1))
+	nodes := make(map[int64]orb.Point, n*12)
+	ways := make([]interface{}, 0, n)
+
+	const verticesPerBuilding = 12
+	nodeID := int64(1)
+	for i := 0; i < n; i++ {
+		lon := -100 + float64(i%500)*0.0005
+		lat := 40 + float64(i/500)*0.0005
+
+		nodeIDs := make([]int64, 0, verticesPerBuilding+1)
+		for v := 0; v < verticesPerBuilding; v++ {
+			side, t := v/3, float64(v%3)/3
+			corner := [4][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}}[side]
+			nextCorner := [4][2]float64{{1, 0}, {1, 1}, {0, 1}, {0, 0}}[side]
+			x := corner[0] + (nextCorner[0]-corner[0])*t + rng.Float64()*0.01
+			y := corner[1] + (nextCorner[1]-corner[1])*t + rng.Float64()*0.01
+			nodes[nodeID] = orb.Point{lon + x*0.0002, lat + y*0.0002}
+			nodeIDs = append(nodeIDs, nodeID)
+			nodeID++
+		}
+		nodeIDs = append(nodeIDs, nodeIDs[0])
+
+		ways = append(ways, &osmpbf.Way{
+			ID:      int64(i + 1),
+			NodeIDs: nodeIDs,
+			Tags:    map[string]string{"building": "house", "building:levels": "2"},
+		})
+	}
+	return nodes, ways
+}
+
+// processSynthetic runs the building pass over the synthetic ways
+func processSynthetic(tb testing.TB, nodes map[int64]orb.Point, ways []interface{}, parallel bool) *OSMProcessor {
+	tb.Helper()
+	p := NewOSMProcessor(1000)
+	p.ProcessedNodes = nodes
+	p.ParallelBuildings = parallel
+	if err := p.processBuildings(&sliceDecoder{objs: ways}); err != nil {
+		tb.Fatal(err)
+	}
+	return p
+}
+
+func TestParallelBuildingsMatchSequential(t *testing.T) {
+	nodes, ways := syntheticBuildings(5000)
+	sequential := processSynthetic(t, nodes, ways, false)
+	parallel := processSynthetic(t, nodes, ways, true)
+
+	if len(parallel.Buildings) != len(sequential.Buildings) {
+		t.Fatalf("parallel pass found %d buildings, sequential %d", len(parallel.Buildings), len(sequential.Buildings))
+	}
+	for i := range sequential.Buildings {
+		if parallel.Buildings[i].ID != sequential.Buildings[i].ID {
+			t.Fatalf("building %d: parallel ID %d, sequential ID %d", i, parallel.Buildings[i].ID, sequential.Buildings[i].ID)
+		}
+	}
+	if parallel.SpatialIndex.Size() != len(parallel.Buildings) {
+		t.Fatalf("spatial index has %d buildings, want %d", parallel.SpatialIndex.Size(), len(parallel.Buildings))
+	}
+}
+
+func benchmarkProcessBuildings(b *testing.B, parallel bool) {
+	nodes, ways := syntheticBuildings(100000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		processSynthetic(b, nodes, ways, parallel)
+	}
+}
+
+func BenchmarkProcessBuildingsSequential(b *testing.B) {
+	benchmarkProcessBuildings(b, false)
+}
+
+func BenchmarkProcessBuildingsParallel(b *testing.B) {
+	benchmarkProcessBuildings(b, true)
+}
+
+func TestProcessOSMFileFailsOnTruncatedFile(t *testing.T) {
+	data := testPBF(t, 20)
+	complete := writeTestFile(t, "complete.osm.pbf", data)
+	// Cut inside the blob of the last fileblock and inside a fileblock header mid-stream
+	truncated := []string{
+		writeTestFile(t, "end.osm.pbf", data[:len(data)-10]),
+		writeTestFile(t, "middle.osm.pbf", data[:len(data)/2+3]),
+	}
+
+	for _, maxDecodeErrors := range []int{0, 3} {
+		p := NewOSMProcessor(1000)
+		p.MaxDecodeErrors = maxDecodeErrors
+		if err := p.ProcessOSMFile(complete); err != nil {
+			t.Fatalf("max decode errors %d, complete file: %v", maxDecodeErrors, err)
+		}
+		if len(p.Buildings) != 20 {
+			t.Fatalf("max decode errors %d, complete file: %d buildings, want 20", maxDecodeErrors, len(p.Buildings))
+		}
+
+		for _, path := range truncated {
+			p := NewOSMProcessor(1000)
+			p.MaxDecodeErrors = maxDecodeErrors
+			if err := p.ProcessOSMFile(path); err == nil {
+				t.Errorf("max decode errors %d, %s: expected an error, got %d buildings", maxDecodeErrors, filepath.Base(path), len(p.Buildings))
+			}
+		}
+	}
+}
+
+// squareWay adds the nodes of a square building of size degrees with its south-west corner at lon, lat
+// and returns its way; node IDs are 10*id to 10*id+3
+func squareWay(nodes map[int64]orb.Point, id int64, lon, lat, size float64, buildingType string) *osmpbf.Way {
+	nodeIDs := make([]int64, 0, 5)
+	for k, corner := range [][2]float64{{0, 0}, {size, 0}, {size, size}, {0, size}} {
+		nodeID := 10*id + int64(k)
+		nodes[nodeID] = orb.Point{lon + corner[0], lat + corner[1]}
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	return &osmpbf.Way{ID: id, NodeIDs: append(nodeIDs, nodeIDs[0]), Tags: map[string]string{"building": buildingType}}
+}
+
+// buildingIDs returns the IDs of the processed buildings in order
+func buildingIDs(p *OSMProcessor) []int64 {
+	ids := make([]int64, len(p.Buildings))
+	for i, building := range p.Buildings {
+		ids[i] = building.ID
+	}
+	return ids
+}
+
+func TestExcludedBuildingTypesAreSkipped(t *testing.T) {
+	nodes := make(map[int64]orb.Point)
+	var ways []interface{}
+	for i, buildingType := range []string{"house", "roof", "apartments", "Bridge", "garage", "shed"} {
+		ways = append(ways, squareWay(nodes, int64(i+1), -100+float64(i)*0.001, 40, 0.0002, buildingType))
+	}
+
+	p := NewOSMProcessor(1000)
+	p.ProcessedNodes = nodes
+	if err := p.processBuildings(&sliceDecoder{objs: ways}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := buildingIDs(p); !reflect.DeepEqual(ids, []int64{1, 3, 5, 6}) {
+		t.Fatalf("buildings %v, want all but the roof and the bridge", ids)
+	}
+	if p.ExcludedBuildings["roof"] != 1 || p.ExcludedBuildings["bridge"] != 1 {
+		t.Fatalf("excluded counts %v, want one roof and one bridge", p.ExcludedBuildings)
+	}
+
+	// A custom list replaces the defaults
+	p = NewOSMProcessor(1000)
+	p.ProcessedNodes = nodes
+	p.SetExcludedTypes([]string{" Shed ", "garage", ""})
+	if err := p.processBuildings(&sliceDecoder{objs: ways}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := buildingIDs(p); !reflect.DeepEqual(ids, []int64{1, 2, 3, 4}) {
+		t.Fatalf("buildings %v, want all but the garage and the shed", ids)
+	}
+}
+
+func TestMinBuildingAreaSkipsSmallFootprints(t *testing.T) {
+	// Footprints of about 4, 94, 380, 1500 and 6000 m² at 40°N
+	nodes := make(map[int64]orb.Point)
+	var ways []interface{}
+	for i, size := range []float64{0.00002, 0.0001, 0.0002, 0.0004, 0.0008} {
+		ways = append(ways, squareWay(nodes, int64(i+1), -100+float64(i)*0.001, 40, size, "house"))
+	}
+
+	counts := make([]int, 0, 3)
+	for _, minArea := range []float64{0, 50, 1000} {
+		p := NewOSMProcessor(1000)
+		p.ProcessedNodes = nodes
+		p.MinBuildingArea = minArea
+		if err := p.processBuildings(&sliceDecoder{objs: ways}); err != nil {
+			t.Fatal(err)
+		}
+		if p.SpatialIndex.Size() != len(p.Buildings) || int(p.SmallBuildings)+len(p.Buildings) != len(ways) {
+			t.Fatalf("min area %v: %d buildings, %d indexed, %d small", minArea, len(p.Buildings), p.SpatialIndex.Size(), p.SmallBuildings)
+		}
+		counts = append(counts, p.SpatialIndex.Size())
+	}
+	if !reflect.DeepEqual(counts, []int{5, 4, 2}) {
+		t.Fatalf("indexed counts %v for minimum areas 0, 50 and 1000 m², want [5 4 2]", counts)
+	}
+}
+
+func TestKeepRawTypesRecordsOSMTypes(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		p := NewOSMProcessor(1000)
+		p.KeepRawTypes = keep
+		for i, buildingType := range []string{"house", "apartments", "house"} {
+			building := testBuilding(int64(i+1), squareRing(-99.999+float64(i)*0.001, 40.004, 0.0002))
+			building.Type = buildingType
+			p.addBuilding(building)
+		}
+
+		zones := testZones([2]float64{-100, 40})
+		distribute(t, p, zones)
+
+		stats := zones[0].Buildings
+		if stats.TotalCount != 3 {
+			t.Fatalf("keep raw types %v: %d buildings in the zone, want 3", keep, stats.TotalCount)
+		}
+		if !keep {
+			if stats.RawBuildingTypes != nil {
+				t.Fatalf("raw types %v recorded without the flag", stats.RawBuildingTypes)
+			}
+			continue
+		}
+		if want := map[string]int{"house": 2, "apartments": 1}; !reflect.DeepEqual(stats.RawBuildingTypes, want) {
+			t.Fatalf("raw types %v, want %v", stats.RawBuildingTypes, want)
+		}
+	}
+}
+
+func TestRawTypesAreConsistentWithGameTypes(t *testing.T) {
+	// The default mapping is loaded relative to the repo root, so map the test types explicitly
+	mappers.SetBuildingCategoryOverrides(map[string]string{
+		"house": "residential", "apartments": "residential", "garage": "transportation_automotive",
+		"supermarket": "commercial_retail", "church": "religious_worship",
+	})
+	t.Cleanup(func() { mappers.SetBuildingCategoryOverrides(nil) })
+
+	p := NewOSMProcessor(1000)
+	p.KeepRawTypes = true
+	for i, buildingType := range []string{"house", "house", "apartments", "garage", "supermarket", "church"} {
+		building := testBuilding(int64(i+1), squareRing(-99.9995+float64(i)*0.0015, 40.004, 0.0002))
+		building.Type = buildingType
+		p.addBuilding(building)
+	}
+
+	zones := testZones([2]float64{-100, 40})
+	distribute(t, p, zones)
+	stats := zones[0].Buildings
+
+	// Every counted building has one raw type
+	rawTotal := 0
+	for _, count := range stats.RawBuildingTypes {
+		rawTotal += count
+	}
+	if rawTotal != stats.TotalCount || rawTotal != 6 {
+		t.Fatalf("raw types count %d buildings, zone counts %d, want 6", rawTotal, stats.TotalCount)
+	}
+
+	// Raw counts folded into their game categories give the game type counts
+	byCategory := make(map[string]int)
+	for rawType, count := range stats.RawBuildingTypes {
+		byCategory[mappers.MapBuildingCategory(rawType)] += count
+	}
+	if len(byCategory) != 4 || !reflect.DeepEqual(byCategory, stats.BuildingTypes) {
+		t.Fatalf("raw types %v map to %v, game types are %v", stats.RawBuildingTypes, byCategory, stats.BuildingTypes)
+	}
+}
+
+func TestGetZonesForProcessedBuildingsFailsWithoutZones(t *testing.T) {
+	p := NewOSMProcessor(1000)
+	p.DryRun = true
+	p.addBuilding(testBuilding(1, squareRing(-99.995, 40.005, 0.0002)))
+
+	var zones []*model.Zone
+	p.queryZones = func(minLat, minLng, maxLat, maxLng, bufferMeters float64) ([]*model.Zone, error) {
+		if minLat > 40.006 || maxLat < 40.005 || minLng > -99.995 || maxLng < -99.996 {
+			t.Fatalf("queried [%v, %v] to [%v, %v], want the building's bounding box", minLat, minLng, maxLat, maxLng)
+		}
+		return zones, nil
+	}
+
+	_, err := p.GetZonesForProcessedBuildings(500)
+	if !errors.Is(err, ErrNoZonesForBuildings) {
+		t.Fatalf("err = %v, want ErrNoZonesForBuildings", err)
+	}
+	if !strings.Contains(err.Error(), "base grid") {
+		t.Fatalf("err = %v, want a hint about the base grid", err)
+	}
+
+	zones = testZones([2]float64{-100, 40})
+	got, err := p.GetZonesForProcessedBuildings(500)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("got %d zones, err %v, want the 1 zone around the building", len(got), err)
+	}
+}
@@ -0,0 +1,246 @@
+package osm_processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"metalink/internal/logx"
+
+	"github.com/qedus/osmpbf"
+	"github.com/qedus/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// skippingDecoderChunkSize is how many fileblocks a worker decodes together
+const skippingDecoderChunkSize = 8
+
+// osmDecoder is the part of osmpbf.Decoder the processing passes read objects from
+type osmDecoder interface {
+	Decode() (interface{}, error)
+}
+
+// blockChunk is a run of raw fileblocks and, once done is closed, their decoded objects
+type blockChunk struct {
+	firstBlock int
+	blocks     [][]byte
+	objects    []interface{}
+	errs       []error // One per fileblock that failed to decode
+	done       chan struct{}
+}
+
+// skippingDecoder decodes an OSM PBF stream in chunks of fileblocks on a pool of workers,
+// skipping fileblocks that fail to decode with a warning
+// More than maxErrors skipped fileblocks, or broken framing that leaves the rest of the stream
+// unreadable, stop decoding with an error. Objects are returned in stream order
+type skippingDecoder struct {
+	header    []byte // Raw OSMHeader fileblock, prepended to every chunk
+	maxErrors int
+	chunks    chan *blockChunk
+	readErr   error // Set by the reader before chunks is closed
+
+	current *blockChunk
+	pos     int
+	skipped int
+	err     error
+}
+
+// newSkippingDecoder reads the file header and starts decoding the stream with the given number of workers
+func newSkippingDecoder(r io.Reader, workers, maxErrors int) (*skippingDecoder, error) {
+	header, blockType, err := readRawFileBlock(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSM header: %w", err)
+	}
+	if blockType != "OSMHeader" {
+		return nil, fmt.Errorf("unexpected first fileblock of type %s", blockType)
+	}
+	if _, err := decodeFileBlocks(header, nil); err != nil {
+		return nil, fmt.Errorf("failed to decode OSM header: %w", err)
+	}
+
+	d := &skippingDecoder{
+		header:    header,
+		maxErrors: maxErrors,
+		chunks:    make(chan *blockChunk, workers*2),
+	}
+
+	jobs := make(chan *blockChunk, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for chunk := range jobs {
+				d.decodeChunk(chunk)
+				close(chunk.done)
+			}
+		}()
+	}
+
+	go d.readChunks(r, jobs)
+	return d, nil
+}
+
+// readChunks splits the stream into chunks, queuing each for the consumer (in order) and the workers
+func (d *skippingDecoder) readChunks(r io.Reader, jobs chan<- *blockChunk) {
+	defer close(d.chunks)
+	defer close(jobs)
+
+	for blockIndex := 1; ; {
+		chunk := &blockChunk{firstBlock: blockIndex, done: make(chan struct{})}
+		var err error
+		for len(chunk.blocks) < skippingDecoderChunkSize {
+			var block []byte
+			if block, _, err = readRawFileBlock(r); err != nil {
+				break
+			}
+			chunk.blocks = append(chunk.blocks, block)
+		}
+		blockIndex += len(chunk.blocks)
+
+		if len(chunk.blocks) > 0 {
+			d.chunks <- chunk
+			jobs <- chunk
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			d.readErr = fmt.Errorf("fileblock %d: %w", blockIndex, err)
+			return
+		}
+	}
+}
+
+// decodeChunk decodes all fileblocks of a chunk at once, falling back to one by one to isolate corrupt blocks
+func (d *skippingDecoder) decodeChunk(chunk *blockChunk) {
+	objects, err := decodeFileBlocks(d.header, chunk.blocks)
+	if err == nil {
+		chunk.objects = objects
+		return
+	}
+
+	for i, block := range chunk.blocks {
+		objects, err := decodeFileBlocks(d.header, [][]byte{block})
+		if err != nil {
+			chunk.errs = append(chunk.errs, fmt.Errorf("fileblock %d: %w", chunk.firstBlock+i, err))
+			continue
+		}
+		chunk.objects = append(chunk.objects, objects...)
+	}
+}
+
+// Decode returns the next OSM object, or io.EOF at the end of the stream
+func (d *skippingDecoder) Decode() (interface{}, error) {
+	for {
+		if d.current != nil && d.pos < len(d.current.objects) {
+			obj := d.current.objects[d.pos]
+			d.pos++
+			return obj, nil
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		chunk, ok := <-d.chunks
+		if !ok {
+			d.current = nil
+			d.err = io.EOF
+			if d.readErr != nil {
+				d.err = fmt.Errorf("OSM stream unreadable: %w", d.readErr)
+			}
+			continue
+		}
+
+		<-chunk.done
+		for _, err := range chunk.errs {
+			d.skipped++
+			if d.skipped > d.maxErrors {
+				d.err = fmt.Errorf("more than %d corrupt fileblocks: %w", d.maxErrors, err)
+				break
+			}
+			logx.Warn("Skipping corrupt OSM data (%d of at most %d): %v", d.skipped, d.maxErrors, err)
+		}
+		chunk.blocks = nil // Raw data isn't needed once decoded
+		d.current, d.pos = chunk, 0
+		if d.err != nil {
+			d.current = nil
+		}
+	}
+}
+
+// Skipped returns the number of fileblocks skipped so far
+func (d *skippingDecoder) Skipped() int {
+	return d.skipped
+}
+
+// decodeFileBlocks decodes raw data fileblocks with a dedicated osmpbf.Decoder
+// A decoder that fails on a data blob leaves its goroutines blocked, which is bounded by the
+// number of skipped fileblocks
+func decodeFileBlocks(header []byte, blocks [][]byte) ([]interface{}, error) {
+	readers := make([]io.Reader, 0, len(blocks)+1)
+	readers = append(readers, bytes.NewReader(header))
+	for _, block := range blocks {
+		readers = append(readers, bytes.NewReader(block))
+	}
+
+	decoder := osmpbf.NewDecoder(io.MultiReader(readers...))
+	if err := decoder.Start(1); err != nil {
+		return nil, err
+	}
+
+	var objects []interface{}
+	for {
+		obj, err := decoder.Decode()
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// readRawFileBlock reads one fileblock (size, header and blob) without decoding the blob
+// Returns io.EOF at a clean end of the stream and io.ErrUnexpectedEOF inside a fileblock
+func readRawFileBlock(r io.Reader) ([]byte, string, error) {
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+		return nil, "", err
+	}
+
+	headerSize := binary.BigEndian.Uint32(sizeBuf[:])
+	if headerSize >= maxBlobHeaderSize {
+		return nil, "", fmt.Errorf("fileblock header size %d exceeds the 64KB limit", headerSize)
+	}
+
+	block := make([]byte, 4+headerSize)
+	copy(block, sizeBuf[:])
+	if _, err := io.ReadFull(r, block[4:]); err != nil {
+		return nil, "", noEOF(err)
+	}
+
+	header := new(OSMPBF.BlobHeader)
+	if err := proto.Unmarshal(block[4:], header); err != nil {
+		return nil, "", fmt.Errorf("invalid fileblock header: %w", err)
+	}
+
+	dataSize := int(header.GetDatasize())
+	if dataSize < 0 || dataSize > osmpbf.MaxBlobSize {
+		return nil, "", fmt.Errorf("fileblock blob size %d exceeds the %d byte limit", dataSize, osmpbf.MaxBlobSize)
+	}
+
+	block = append(block, make([]byte, dataSize)...)
+	if _, err := io.ReadFull(r, block[4+headerSize:]); err != nil {
+		return nil, "", noEOF(err)
+	}
+	return block, header.GetType(), nil
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF for reads inside a fileblock
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package osm_processor
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/qedus/osmpbf"
+)
+
+// decodeAll reads a decoder to the end and returns the IDs of the decoded ways and the final error
+func decodeAll(decoder osmDecoder) ([]int64, error) {
+	var wayIDs []int64
+	for {
+		obj, err := decoder.Decode()
+		if err == io.EOF {
+			return wayIDs, nil
+		}
+		if err != nil {
+			return wayIDs, err
+		}
+		if way, ok := obj.(*osmpbf.Way); ok {
+			wayIDs = append(wayIDs, way.ID)
+		}
+	}
+}
+
+func TestSkippingDecoderReadsFixture(t *testing.T) {
+	decoder, err := newSkippingDecoder(bytes.NewReader(testPBF(t, 20)), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wayIDs, err := decodeAll(decoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wayIDs) != 20 || wayIDs[0] != 1 || wayIDs[19] != 20 || decoder.Skipped() != 0 {
+		t.Fatalf("ways %v, %d skipped; want 1..20 in order and none skipped", wayIDs, decoder.Skipped())
+	}
+}
+
+func TestSkippingDecoderSkipsCorruptFileBlock(t *testing.T) {
+	// Building 10 sits in the second chunk of fileblocks, the others decode in the same chunks
+	decoder, err := newSkippingDecoder(bytes.NewReader(testPBF(t, 20, 10)), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wayIDs, err := decodeAll(decoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []int64
+	for id := int64(1); id <= 20; id++ {
+		if id != 11 {
+			want = append(want, id)
+		}
+	}
+	if !reflect.DeepEqual(wayIDs, want) || decoder.Skipped() != 1 {
+		t.Fatalf("ways %v, %d skipped; want all but way 11 in order and 1 skipped fileblock", wayIDs, decoder.Skipped())
+	}
+}
+
+func TestSkippingDecoderEnforcesErrorLimit(t *testing.T) {
+	decoder, err := newSkippingDecoder(bytes.NewReader(testPBF(t, 20, 3, 12)), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wayIDs, err := decodeAll(decoder)
+	if err == nil {
+		t.Fatalf("decoded %d ways past the error limit, want an error", len(wayIDs))
+	}
+	if decoder.Skipped() != 2 || len(wayIDs) >= 18 {
+		t.Fatalf("%d skipped, %d ways decoded; want to stop at the second corrupt fileblock", decoder.Skipped(), len(wayIDs))
+	}
+
+	// The processor stops the pass with the same limit and keeps the count
+	p := NewOSMProcessor(1000)
+	p.MaxDecodeErrors = 1
+	if err := p.ProcessOSMFile(writeTestFile(t, "corrupt.osm.pbf", testPBF(t, 20, 3, 12))); err == nil {
+		t.Fatal("expected the processor to fail past the error limit")
+	}
+	p = NewOSMProcessor(1000)
+	p.MaxDecodeErrors = 2
+	if err := p.ProcessOSMFile(writeTestFile(t, "corrupt.osm.pbf", testPBF(t, 20, 3, 12))); err != nil {
+		t.Fatal(err)
+	}
+	if p.SkippedBlocks != 2 || len(p.Buildings) != 18 {
+		t.Fatalf("%d skipped, %d buildings; want 2 and 18", p.SkippedBlocks, len(p.Buildings))
+	}
+}
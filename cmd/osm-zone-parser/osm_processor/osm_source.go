@@ -0,0 +1,150 @@
+package osm_processor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"metalink/internal/logx"
+	"os"
+)
+
+// osmCompression is the compression wrapper detected around an OSM PBF file
+type osmCompression string
+
+const (
+	osmCompressionNone  osmCompression = "none"
+	osmCompressionGzip  osmCompression = "gzip"
+	osmCompressionBzip2 osmCompression = "bzip2"
+)
+
+// osmSource provides a fresh reader of the decompressed PBF data for each processing pass
+type osmSource struct {
+	file        *os.File
+	compression osmCompression
+	gzipReader  *gzip.Reader
+	tempPath    string // Decompressed copy, removed on close
+}
+
+// openOSMSource opens an OSM PBF file, detecting gzip and bzip2 wrappers by their magic bytes
+// With decompressToTemp a compressed file is decompressed once into a temp file that is read
+// on every pass; otherwise the file is decompressed again on each pass
+func openOSMSource(path string, decompressToTemp bool) (*osmSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OSM file: %w", err)
+	}
+
+	compression, err := detectOSMCompression(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	source := &osmSource{file: file, compression: compression}
+	if compression == osmCompressionNone {
+		return source, nil
+	}
+	logx.Info("Detected %s compressed OSM file", compression)
+
+	if decompressToTemp {
+		if err := source.decompressToTempFile(); err != nil {
+			source.Close()
+			return nil, err
+		}
+	}
+	return source, nil
+}
+
+// detectOSMCompression sniffs the magic bytes at the start of the file and rewinds it
+func detectOSMCompression(file *os.File) (osmCompression, error) {
+	magic := make([]byte, 3)
+	n, err := io.ReadFull(file, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read OSM file header: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind OSM file: %w", err)
+	}
+
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return osmCompressionGzip, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return osmCompressionBzip2, nil
+	}
+	return osmCompressionNone, nil
+}
+
+// decompressToTempFile replaces the compressed source with a decompressed temp file
+func (s *osmSource) decompressToTempFile() error {
+	reader, err := s.reader()
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp("", "osm-*.pbf")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	s.tempPath = temp.Name()
+
+	written, err := io.Copy(temp, reader)
+	if err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to decompress OSM file to %s: %w", s.tempPath, err)
+	}
+	logx.Info("Decompressed OSM file to %s (%d MB)", s.tempPath, written/1024/1024)
+
+	s.closeReaders()
+	s.file = temp
+	s.compression = osmCompressionNone
+	return nil
+}
+
+// reader rewinds the source and returns a reader of the decompressed data from the start
+func (s *osmSource) reader() (io.Reader, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind OSM file: %w", err)
+	}
+
+	switch s.compression {
+	case osmCompressionGzip:
+		if s.gzipReader == nil {
+			gzipReader, err := gzip.NewReader(s.file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+			}
+			s.gzipReader = gzipReader
+		} else if err := s.gzipReader.Reset(s.file); err != nil {
+			return nil, fmt.Errorf("failed to reset gzip stream: %w", err)
+		}
+		return s.gzipReader, nil
+	case osmCompressionBzip2:
+		return bzip2.NewReader(bufio.NewReader(s.file)), nil
+	}
+	return s.file, nil
+}
+
+// closeReaders closes the current file and decompressor
+func (s *osmSource) closeReaders() {
+	if s.gzipReader != nil {
+		s.gzipReader.Close()
+		s.gzipReader = nil
+	}
+	s.file.Close()
+}
+
+// Close closes the source and removes the temp file if one was created
+func (s *osmSource) Close() error {
+	s.closeReaders()
+	if s.tempPath != "" {
+		if err := os.Remove(s.tempPath); err != nil {
+			return fmt.Errorf("failed to remove temp file %s: %w", s.tempPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package osm_processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+)
+
+// gzipped returns data compressed with gzip
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOSMSourceReadsGzip(t *testing.T) {
+	data := testPBF(t, 5)
+	path := writeTestFile(t, "tiny.osm.pbf.gz", gzipped(t, data))
+
+	for _, decompressToTemp := range []bool{false, true} {
+		source, err := openOSMSource(path, decompressToTemp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decompressToTemp != (source.tempPath != "") {
+			t.Fatalf("decompress to temp %v: temp file %q", decompressToTemp, source.tempPath)
+		}
+
+		// Every pass reads the decompressed data from the start
+		for pass := 0; pass < 2; pass++ {
+			reader, err := source.reader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(read, data) {
+				t.Fatalf("decompress to temp %v, pass %d: read %d bytes, want the %d decompressed bytes", decompressToTemp, pass, len(read), len(data))
+			}
+		}
+
+		tempPath := source.tempPath
+		if err := source.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(tempPath); tempPath != "" && !os.IsNotExist(err) {
+			t.Fatalf("temp file %s was kept: %v", tempPath, err)
+		}
+	}
+}
+
+func TestProcessOSMFileReadsGzip(t *testing.T) {
+	path := writeTestFile(t, "tiny.osm.pbf.gz", gzipped(t, testPBF(t, 5)))
+
+	report, err := ValidateOSMFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Nodes != 20 || report.BuildingWays != 5 {
+		t.Fatalf("report %+v, want 20 nodes and 5 building ways", report)
+	}
+
+	p := NewOSMProcessor(1000)
+	if err := p.ProcessOSMFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Buildings) != 5 {
+		t.Fatalf("%d buildings, want 5", len(p.Buildings))
+	}
+}
@@ -0,0 +1,217 @@
+package osm_processor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+
+	"metalink/internal/logx"
+
+	"github.com/qedus/osmpbf"
+	"github.com/qedus/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxBlobHeaderSize is the PBF limit for the size of a fileblock header
+const maxBlobHeaderSize = 64 * 1024
+
+// ErrInvalidOSMFile is returned when an OSM file is corrupt or contains no usable data
+var ErrInvalidOSMFile = errors.New("invalid OSM file")
+
+// OSMValidationReport summarizes a single streaming pass over an OSM PBF file
+type OSMValidationReport struct {
+	Nodes          int64
+	Ways           int64
+	Relations      int64
+	BuildingWays   int64
+	HeaderBBox     *osmpbf.BoundingBox // nil if the header has no bounding box
+	MissingNodes   int64               // Way node references without a node in the file
+	IncompleteWays int64               // Ways with at least one missing node
+}
+
+// IsPartialExtract reports whether ways reference nodes that aren't in the file
+func (r *OSMValidationReport) IsPartialExtract() bool {
+	return r.MissingNodes > 0
+}
+
+// Log logs the report
+func (r *OSMValidationReport) Log() {
+	logx.Info("Nodes: %d, ways: %d (%d buildings), relations: %d", r.Nodes, r.Ways, r.BuildingWays, r.Relations)
+	if r.HeaderBBox != nil {
+		logx.Info("Header bounding box: lat %.6f..%.6f, lon %.6f..%.6f",
+			r.HeaderBBox.Bottom, r.HeaderBBox.Top, r.HeaderBBox.Left, r.HeaderBBox.Right)
+	} else {
+		logx.Info("Header has no bounding box")
+	}
+	if r.IsPartialExtract() {
+		logx.Warn("%d ways reference %d nodes missing from the file (partial extract?)", r.IncompleteWays, r.MissingNodes)
+	}
+}
+
+// ValidateOSMFile checks an OSM PBF file in a single pass without building the node index
+// Only node IDs are kept to detect missing way nodes, which relies on the usual PBF order of nodes before ways
+// Returns the report gathered so far together with an ErrInvalidOSMFile error on corruption or an empty file
+func ValidateOSMFile(osmFilePath string, decompressToTemp bool) (*OSMValidationReport, error) {
+	logx.Info("Validating OSM file: %s", osmFilePath)
+
+	source, err := openOSMSource(osmFilePath, decompressToTemp)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	reader, err := source.reader()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OSMValidationReport{}
+
+	// The decoder reports a blob cut off mid-way as a regular EOF, so the framing is checked separately
+	reader, framing := withFramingCheck(reader)
+
+	decoder := osmpbf.NewDecoder(reader)
+	decoder.SetBufferSize(osmpbf.MaxBlobSize)
+	if err := decoder.Start(runtime.GOMAXPROCS(-1)); err != nil {
+		framing.finish()
+		return report, fmt.Errorf("%w: failed to read header: %v", ErrInvalidOSMFile, err)
+	}
+
+	if header, err := decoder.Header(); err == nil && header != nil {
+		report.HeaderBBox = header.BoundingBox
+	}
+
+	nodeIDs := make(map[int64]struct{})
+	progress := NewProgressCounter("objects", 1000000)
+
+	for {
+		obj, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			framing.finish()
+			return report, fmt.Errorf("%w: decode error after %d objects: %v", ErrInvalidOSMFile, progress.Load(), err)
+		}
+
+		switch v := obj.(type) {
+		case *osmpbf.Node:
+			report.Nodes++
+			nodeIDs[v.ID] = struct{}{}
+		case *osmpbf.Way:
+			report.Ways++
+			if building, ok := v.Tags["building"]; ok && building != "no" {
+				report.BuildingWays++
+			}
+
+			missing := 0
+			for _, nodeID := range v.NodeIDs {
+				if _, ok := nodeIDs[nodeID]; !ok {
+					missing++
+				}
+			}
+			if missing > 0 {
+				report.MissingNodes += int64(missing)
+				report.IncompleteWays++
+			}
+		case *osmpbf.Relation:
+			report.Relations++
+		}
+		progress.Inc()
+	}
+
+	if err := framing.finish(); err != nil {
+		return report, fmt.Errorf("%w: %v", ErrInvalidOSMFile, err)
+	}
+
+	if report.Nodes == 0 {
+		return report, fmt.Errorf("%w: file contains no nodes", ErrInvalidOSMFile)
+	}
+
+	return report, nil
+}
+
+// framingCheck checks the fileblock framing of a PBF stream on a copy of the data read by a decoder
+type framingCheck struct {
+	writer *io.PipeWriter
+	result chan error
+	err    error
+	done   bool
+}
+
+// withFramingCheck returns a reader of the same stream whose fileblock framing is checked as it is read
+func withFramingCheck(reader io.Reader) (io.Reader, *framingCheck) {
+	framingReader, framingWriter := io.Pipe()
+	check := &framingCheck{writer: framingWriter, result: make(chan error, 1)}
+	go func() {
+		err := checkBlockFraming(framingReader)
+		io.Copy(io.Discard, framingReader) // Keep draining so the decoder never blocks
+		check.result <- err
+	}()
+	return io.TeeReader(reader, framingWriter), check
+}
+
+// finish ends the check and returns its result, which is only meaningful once the stream was read to the end
+// Later calls return the same result
+func (c *framingCheck) finish() error {
+	if !c.done {
+		c.writer.Close()
+		c.err = <-c.result
+		c.done = true
+	}
+	return c.err
+}
+
+// framedDecoder is an osmpbf.Decoder that reports a truncated stream as an error instead of a clean EOF
+type framedDecoder struct {
+	*osmpbf.Decoder
+	framing *framingCheck
+}
+
+// Decode returns the next OSM object, or io.EOF at the end of a complete stream
+func (d *framedDecoder) Decode() (interface{}, error) {
+	obj, err := d.Decoder.Decode()
+	if err == io.EOF {
+		if framingErr := d.framing.finish(); framingErr != nil {
+			return nil, fmt.Errorf("OSM stream unreadable: %w", framingErr)
+		}
+	} else if err != nil {
+		d.framing.finish()
+	}
+	return obj, err
+}
+
+// checkBlockFraming reads the fileblock sizes of a PBF stream without decoding the blobs
+// Fails if the stream ends inside a fileblock, i.e. the file is truncated
+func checkBlockFraming(r io.Reader) error {
+	var sizeBuf [4]byte
+	for blocks := 0; ; blocks++ {
+		if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("file truncated in the size of fileblock %d", blocks+1)
+		}
+
+		headerSize := binary.BigEndian.Uint32(sizeBuf[:])
+		if headerSize >= maxBlobHeaderSize {
+			return fmt.Errorf("fileblock %d header size %d exceeds the 64KB limit", blocks+1, headerSize)
+		}
+		headerData := make([]byte, headerSize)
+		if _, err := io.ReadFull(r, headerData); err != nil {
+			return fmt.Errorf("file truncated in the header of fileblock %d", blocks+1)
+		}
+
+		header := new(OSMPBF.BlobHeader)
+		if err := proto.Unmarshal(headerData, header); err != nil {
+			return fmt.Errorf("invalid header of fileblock %d: %v", blocks+1, err)
+		}
+
+		copied, err := io.CopyN(io.Discard, r, int64(header.GetDatasize()))
+		if err != nil {
+			return fmt.Errorf("file truncated in fileblock %d: %d of %d blob bytes present", blocks+1, copied, header.GetDatasize())
+		}
+	}
+}
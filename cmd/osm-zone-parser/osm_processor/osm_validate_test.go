@@ -0,0 +1,33 @@
+package osm_processor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateOSMFile(t *testing.T) {
+	data := testPBF(t, 5)
+	report, err := ValidateOSMFile(writeTestFile(t, "tiny.osm.pbf", data), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Nodes != 20 || report.Ways != 5 || report.BuildingWays != 5 || report.Relations != 0 {
+		t.Fatalf("report %+v, want 20 nodes and 5 building ways", report)
+	}
+	if report.IsPartialExtract() || report.HeaderBBox != nil {
+		t.Fatalf("report %+v, want a complete extract without a header bounding box", report)
+	}
+
+	// Cut inside the blob of the last fileblock: the decoder alone sees a clean end
+	report, err = ValidateOSMFile(writeTestFile(t, "truncated.osm.pbf", data[:len(data)-10]), false)
+	if !errors.Is(err, ErrInvalidOSMFile) {
+		t.Fatalf("truncated file: err = %v, want ErrInvalidOSMFile", err)
+	}
+	if report == nil || report.Nodes != 16 {
+		t.Fatalf("truncated file: report %+v, want the 16 nodes read before the cut", report)
+	}
+
+	if _, err := ValidateOSMFile(writeTestFile(t, "empty.osm.pbf", headerFileBlock(t)), false); !errors.Is(err, ErrInvalidOSMFile) {
+		t.Fatalf("file without nodes: err = %v, want ErrInvalidOSMFile", err)
+	}
+}
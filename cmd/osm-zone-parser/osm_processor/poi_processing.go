@@ -0,0 +1,71 @@
+package osm_processor
+
+import (
+	"fmt"
+	mappers "metalink/cmd/osm-zone-parser/mappers"
+	utils "metalink/cmd/osm-zone-parser/utils"
+	"metalink/internal/logx"
+	"metalink/internal/model"
+
+	"github.com/qedus/osmpbf"
+)
+
+// POI is a standalone amenity, shop or leisure node mapped to a game category
+type POI struct {
+	ID       int64
+	Category string
+	Lat      float64
+	Lon      float64
+}
+
+// addPOI records a node of interest if its tags map to a game category
+func (p *OSMProcessor) addPOI(node *osmpbf.Node) {
+	category := mappers.MapPOICategory(node.Tags)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if category == "" {
+		p.UnmappedPOIs++
+		return
+	}
+	p.POIs = append(p.POIs, &POI{
+		ID:       node.ID,
+		Category: category,
+		Lat:      node.Lat,
+		Lon:      node.Lon,
+	})
+}
+
+// distributePOIsToZones counts every POI in all zones within its influence radius
+// POIs have no area, so the radius is the base radius scaled by the category's radius coefficient
+// POI stats are recalculated from scratch, replacing counts loaded from the database
+func (p *OSMProcessor) distributePOIsToZones(zones []*model.Zone) error {
+	logx.Info("Distributing %d points of interest to %d zones (%d unmapped POIs skipped)",
+		len(p.POIs), len(zones), p.UnmappedPOIs)
+
+	for _, zone := range zones {
+		zone.POIStats = model.POIStats{}
+	}
+
+	zoneIndex, err := p.updateSpatialIndexWithNewZones(zones)
+	if err != nil {
+		return fmt.Errorf("failed to build spatial index for POIs: %w", err)
+	}
+
+	distributed := 0
+	for _, poi := range p.POIs {
+		influenceRadius := utils.CalculateBuildingInfluenceRadius(0, mappers.GetBuildingEffectsConfig(poi.Category))
+
+		zonesInRadius := p.findZonesInRadius(zoneIndex, poi.Lon, poi.Lat, influenceRadius)
+		for _, zoneSpatial := range zonesInRadius {
+			zoneSpatial.Zone.AddPOI(poi.Category)
+		}
+		if len(zonesInRadius) > 0 {
+			distributed++
+		}
+	}
+
+	logx.Info("Distributed %d/%d points of interest to zones", distributed, len(p.POIs))
+	return nil
+}
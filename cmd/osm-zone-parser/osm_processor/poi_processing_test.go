@@ -0,0 +1,42 @@
+package osm_processor
+
+import (
+	"reflect"
+	"testing"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+
+	"github.com/qedus/osmpbf"
+)
+
+func TestSupermarketNodeCountsInItsZone(t *testing.T) {
+	mappers.SetBuildingCategoryOverrides(map[string]string{"supermarket": "commercial_retail"})
+	t.Cleanup(func() { mappers.SetBuildingCategoryOverrides(nil) })
+
+	p := NewOSMProcessor(1000)
+	p.ProcessPOIs = true
+	nodes := []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 40.005, Lon: -99.995, Tags: map[string]string{"shop": "supermarket"}},
+		&osmpbf.Node{ID: 2, Lat: 40.005, Lon: -99.994, Tags: map[string]string{"amenity": "bench"}},
+		&osmpbf.Node{ID: 3, Lat: 40.005, Lon: -99.993, Tags: map[string]string{"shop": "no"}},
+		&osmpbf.Node{ID: 4, Lat: 40.005, Lon: -99.992},
+	}
+	if err := p.collectNodes(&sliceDecoder{objs: nodes}); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.POIs) != 1 || p.POIs[0].ID != 1 || p.POIs[0].Category != "commercial_retail" || p.UnmappedPOIs != 1 {
+		t.Fatalf("POIs %v with %d unmapped, want the supermarket and one unmapped bench", p.POIs, p.UnmappedPOIs)
+	}
+
+	zones := testZones([2]float64{-100, 40}, [2]float64{-99.98, 40})
+	if err := p.distributePOIsToZones(zones); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]int{"commercial_retail": 1}; !reflect.DeepEqual(zones[0].POIStats.Categories, want) || zones[0].POIStats.TotalCount != 1 {
+		t.Fatalf("supermarket zone POI stats %+v, want one commercial_retail POI", zones[0].POIStats)
+	}
+	if zones[1].POIStats.TotalCount != 0 {
+		t.Fatalf("distant zone POI stats %+v, want none", zones[1].POIStats)
+	}
+}
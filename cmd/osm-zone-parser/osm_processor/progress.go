@@ -0,0 +1,36 @@
+package osm_processor
+
+import (
+	"metalink/internal/logx"
+	"sync/atomic"
+)
+
+// ProgressCounter is a concurrency-safe counter that logs progress periodically
+// It doesn't share a lock with the work being counted
+type ProgressCounter struct {
+	label    string
+	interval int64
+	count    atomic.Int64
+}
+
+// NewProgressCounter creates a counter that logs every interval increments
+func NewProgressCounter(label string, interval int64) *ProgressCounter {
+	return &ProgressCounter{
+		label:    label,
+		interval: interval,
+	}
+}
+
+// Inc increments the counter and logs progress when an interval boundary is reached
+func (c *ProgressCounter) Inc() int64 {
+	n := c.count.Add(1)
+	if c.interval > 0 && n%c.interval == 0 {
+		logx.Debug("Processed %d %s...", n, c.label)
+	}
+	return n
+}
+
+// Load returns the current counter value
+func (c *ProgressCounter) Load() int64 {
+	return c.count.Load()
+}
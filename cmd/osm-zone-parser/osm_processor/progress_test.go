@@ -0,0 +1,40 @@
+package osm_processor
+
+import (
+	"sync"
+	"testing"
+)
+
+// Run with -race: Inc is called without any other lock
+func TestProgressCounterConcurrentInc(t *testing.T) {
+	const workers, perWorker = 8, 10000
+	counter := NewProgressCounter("items", 1000)
+
+	var wg sync.WaitGroup
+	values := make([][]int64, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				values[w] = append(values[w], counter.Inc())
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := counter.Load(); n != workers*perWorker {
+		t.Fatalf("counter = %d, want %d", n, workers*perWorker)
+	}
+
+	// Every increment returns a distinct value
+	seen := make([]bool, workers*perWorker+1)
+	for _, workerValues := range values {
+		for _, v := range workerValues {
+			if v < 1 || v > workers*perWorker || seen[v] {
+				t.Fatalf("Inc returned %d twice or out of range", v)
+			}
+			seen[v] = true
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package osm_processor
+
+import (
+	"github.com/paulmach/orb"
+)
+
+// roiNodeMarginDegrees is how far (about 1km) outside the region of interest nodes are still kept,
+// so buildings straddling the edge keep their full outline
+const roiNodeMarginDegrees = 0.01
+
+// SetBoundingBox limits processing to a region of interest
+// Nodes outside the box (plus a small margin) are dropped in the first pass, POIs must be inside the box,
+// and a building is kept only if its centroid is inside the box. The centroid rule assigns every
+// straddling building to exactly one side, so adjacent boxes never count a building twice
+func (p *OSMProcessor) SetBoundingBox(minLat, minLng, maxLat, maxLng float64) {
+	roi := orb.Bound{Min: orb.Point{minLng, minLat}, Max: orb.Point{maxLng, maxLat}}
+	p.roi = &roi
+	p.roiNodeBound = roi.Pad(roiNodeMarginDegrees)
+}
+
+// keepNode reports whether a node at the point is needed for the region of interest
+func (p *OSMProcessor) keepNode(point orb.Point) bool {
+	return p.roi == nil || p.roiNodeBound.Contains(point)
+}
+
+// inROI reports whether a point is inside the region of interest (always true without one)
+func (p *OSMProcessor) inROI(point orb.Point) bool {
+	return p.roi == nil || p.roi.Contains(point)
+}
@@ -0,0 +1,50 @@
+package osm_processor
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// processInBox processes the file limited to the box and returns the IDs of the kept buildings
+func processInBox(t *testing.T, path string, minLat, minLng, maxLat, maxLng float64) (*OSMProcessor, []int64) {
+	t.Helper()
+	p := NewOSMProcessor(1000)
+	p.SetBoundingBox(minLat, minLng, maxLat, maxLng)
+	if err := p.ProcessOSMFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return p, buildingIDs(p)
+}
+
+func TestBoundingBoxAssignsStraddlingBuildingsOnce(t *testing.T) {
+	// 20 houses 0.001° apart; the split runs through house 5 (way 6), just west of its centroid
+	path := writeTestFile(t, "houses.osm.pbf", testPBF(t, 20))
+	const split = -100 + 5*0.001 + 0.00005
+
+	west, westIDs := processInBox(t, path, 39.9, -100.1, 40.1, split)
+	east, eastIDs := processInBox(t, path, 39.9, split, 40.1, -99.9)
+
+	if !reflect.DeepEqual(westIDs, []int64{1, 2, 3, 4, 5}) {
+		t.Fatalf("west box buildings %v, want 1..5", westIDs)
+	}
+	if len(eastIDs) != 15 || eastIDs[0] != 6 || eastIDs[14] != 20 {
+		t.Fatalf("east box buildings %v, want 6..20", eastIDs)
+	}
+
+	// The straddling house keeps its full outline from the nodes in the margin
+	straddling, whole := east.Buildings[0].FootprintArea(), west.Buildings[0].FootprintArea()
+	if math.Abs(straddling-whole) > 0.01*whole {
+		t.Fatalf("straddling house footprint %.1f m², want %.1f m² like the others", straddling, whole)
+	}
+
+	// A smaller box lowers the count and drops the nodes of houses past the 0.01° margin
+	small, smallIDs := processInBox(t, path, 39.9, -100.1, 40.1, -100+2*0.001+0.00005)
+	if !reflect.DeepEqual(smallIDs, []int64{1, 2}) || small.ClippedNodes == 0 {
+		t.Fatalf("small box buildings %v, %d clipped nodes; want 1 and 2 and clipped nodes", smallIDs, small.ClippedNodes)
+	}
+	_, farIDs := processInBox(t, path, 50, 10, 51, 11)
+	if len(farIDs) != 0 {
+		t.Fatalf("box without houses kept %v", farIDs)
+	}
+}
@@ -2,20 +2,20 @@ package osm_processor
 
 import (
 	"fmt"
-	"log"
 	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 )
 
 // runAdaptiveZoneSubdivision runs the main iterative algorithm for zone subdivision
 func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]string, error) {
-	log.Printf("Starting adaptive zone subdivision algorithm with %d initial zones", len(*zones))
+	logx.Info("Starting adaptive zone subdivision algorithm with %d initial zones", len(*zones))
 
 	// Track all deleted zone IDs throughout the algorithm
 	var deletedZoneIDs []string
 
 	// Step 1: Mark ALL zones for recalculation (ONLY ONCE at the beginning)
-	log.Printf("Marking all %d zones for recalculation", len(*zones))
+	logx.Info("Marking all %d zones for recalculation", len(*zones))
 	for _, zone := range *zones {
 		zone.RecalculateNeeded = true
 	}
@@ -25,7 +25,7 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 
 	for iteration < maxIterations {
 		iteration++
-		log.Printf("=== Iteration %d ===", iteration)
+		logx.Info("=== Iteration %d ===", iteration)
 
 		// Step 2: Create backups of all recalculation-needed zones
 		zoneBackups := p.createZoneBackupsForRecalcNeeded(*zones)
@@ -42,15 +42,15 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 		}
 
 		// Step 4: Find overweight zones
-		weightThreshold := mappers.GetWeightThreshold()
+		weightThreshold := p.splitThreshold()
 		overweightZoneIDs := p.findOverweightZonesWithMinSize(*zones, weightThreshold)
 
 		if len(overweightZoneIDs) == 0 {
-			log.Printf("No overweight zones found.")
+			logx.Info("No overweight zones found.")
 			break
 		}
 
-		log.Printf("Found %d overweight zones in iteration %d", len(overweightZoneIDs), iteration)
+		logx.Info("Found %d overweight zones in iteration %d", len(overweightZoneIDs), iteration)
 
 		// Step 5: Find affected zones (connected through building dependencies)
 		affectedZoneIDs := p.findConnectedZones(overweightZoneIDs, stats.ZoneDependencies)
@@ -58,7 +58,7 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 		// Remove overweight zones from affected list to avoid duplicates
 		affectedZoneIDs = p.removeOverweightFromAffected(overweightZoneIDs, affectedZoneIDs)
 
-		log.Printf("Found %d additional affected zones", len(affectedZoneIDs))
+		logx.Info("Found %d additional affected zones", len(affectedZoneIDs))
 
 		// Step 6: Restore overweight and affected zones from backups
 		// (this automatically restores recalculate_needed flag)
@@ -71,13 +71,13 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 		for _, zoneID := range overweightZoneIDs {
 			zone := p.findZoneByID(*zones, zoneID)
 			if zone == nil {
-				log.Printf("Warning: Could not find zone %s for splitting", zoneID)
+				logx.Warn("Could not find zone %s for splitting", zoneID)
 				continue
 			}
 
 			newZones, err := p.splitZoneIntoFour(zone)
 			if err != nil {
-				log.Printf("Warning: Failed to split zone %s: %v", zoneID, err)
+				logx.Warn("Failed to split zone %s: %v", zoneID, err)
 				continue
 			}
 
@@ -85,16 +85,16 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 		}
 
 		// Step 8: Remove overweight zones from list AND track them for DB deletion
-		log.Printf("Tracking %d zones for deletion from database", len(overweightZoneIDs))
+		logx.Info("Tracking %d zones for deletion from database", len(overweightZoneIDs))
 		deletedZoneIDs = append(deletedZoneIDs, overweightZoneIDs...)
 		p.removeZonesFromList(zones, overweightZoneIDs)
 
 		// Step 9: Add new split zones to list
 		p.addZonesToList(zones, allNewZones)
 
-		log.Printf("Iteration %d completed: removed %d zones, added %d zones. Total zones: %d",
+		logx.Info("Iteration %d completed: removed %d zones, added %d zones. Total zones: %d",
 			iteration, len(overweightZoneIDs), len(allNewZones), len(*zones))
-		log.Printf("Total deleted zone IDs so far: %d", len(deletedZoneIDs))
+		logx.Info("Total deleted zone IDs so far: %d", len(deletedZoneIDs))
 
 		// Continue to next iteration (goto step 2, NOT step 1!)
 	}
@@ -103,9 +103,17 @@ func (p *OSMProcessor) runAdaptiveZoneSubdivision(zones *[]*model.Zone) ([]strin
 		return deletedZoneIDs, fmt.Errorf("algorithm did not converge after %d iterations", maxIterations)
 	}
 
-	log.Printf("Adaptive zone subdivision completed successfully after %d iterations with %d final zones",
+	logx.Info("Adaptive zone subdivision completed successfully after %d iterations with %d final zones",
 		iteration, len(*zones))
-	log.Printf("Total zones to delete from database: %d", len(deletedZoneIDs))
+	logx.Info("Total zones to delete from database: %d", len(deletedZoneIDs))
 
 	return deletedZoneIDs, nil
 }
+
+// splitThreshold returns the zone split threshold: the configured override or the effects config value
+func (p *OSMProcessor) splitThreshold() float64 {
+	if p.WeightThreshold > 0 {
+		return p.WeightThreshold
+	}
+	return mappers.GetWeightThreshold()
+}
@@ -0,0 +1,36 @@
+package osm_processor
+
+import "testing"
+
+func TestUpdateZonesWithBuildingStatsReturnsSplitZones(t *testing.T) {
+	p := NewOSMProcessor(100)
+	p.DryRun = true
+	p.ProcessPOIs = false
+	p.SplitByArea = true
+	p.WeightThreshold = 1
+
+	// One building in every quadrant of the zone, so the zone is split
+	for i, corner := range [][2]float64{{-99.998, 40.002}, {-99.993, 40.002}, {-99.998, 40.007}, {-99.993, 40.007}} {
+		p.addBuilding(testBuilding(int64(i+1), squareRing(corner[0], corner[1], 0.0002)))
+	}
+
+	zones := testZones([2]float64{-100, 40})
+	updated, err := p.UpdateZonesWithBuildingStats(zones, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated) < 4 {
+		t.Fatalf("got %d zones, want the parts of the split zone", len(updated))
+	}
+	buildings := 0
+	for _, zone := range updated {
+		if zone.ID == zones[0].ID {
+			t.Fatal("the split zone is still returned")
+		}
+		buildings += zone.Buildings.TotalCount
+	}
+	if buildings != 4 {
+		t.Fatalf("returned zones count %d buildings, want 4", buildings)
+	}
+}
@@ -1,7 +1,7 @@
 package osm_processor
 
 import (
-	"log"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 )
 
@@ -13,7 +13,7 @@ type ZoneBackup struct {
 
 // createZoneBackups creates clean copies of zones before processing starts
 func (p *OSMProcessor) createZoneBackups(zones []*model.Zone) map[string]*ZoneBackup {
-	log.Printf("Creating backup copies of %d zones before processing", len(zones))
+	logx.Info("Creating backup copies of %d zones before processing", len(zones))
 
 	backups := make(map[string]*ZoneBackup, len(zones))
 
@@ -26,6 +26,7 @@ func (p *OSMProcessor) createZoneBackups(zones []*model.Zone) map[string]*ZoneBa
 			TopRightLatLon:    make([]float64, len(zone.TopRightLatLon)),
 			BottomLeftLatLon:  make([]float64, len(zone.BottomLeftLatLon)),
 			BottomRightLatLon: make([]float64, len(zone.BottomRightLatLon)),
+			Geometry:          zone.Geometry,
 			UpdatedAt:         zone.UpdatedAt,
 			CreatedAt:         zone.CreatedAt,
 			DeletedAt:         zone.DeletedAt,
@@ -63,6 +64,18 @@ func (p *OSMProcessor) createZoneBackups(zones []*model.Zone) map[string]*ZoneBa
 		for buildingType, area := range zone.Buildings.BuildingAreas {
 			buildingStatsCopy.BuildingAreas[buildingType] = area
 		}
+		if zone.Buildings.LevelBuckets != nil {
+			buildingStatsCopy.LevelBuckets = make(map[string]model.BucketStat, len(zone.Buildings.LevelBuckets))
+			for name, stat := range zone.Buildings.LevelBuckets {
+				buildingStatsCopy.LevelBuckets[name] = stat
+			}
+		}
+		if zone.Buildings.RawBuildingTypes != nil {
+			buildingStatsCopy.RawBuildingTypes = make(map[string]int, len(zone.Buildings.RawBuildingTypes))
+			for rawType, count := range zone.Buildings.RawBuildingTypes {
+				buildingStatsCopy.RawBuildingTypes[rawType] = count
+			}
+		}
 
 		// Set the copy as zone's buildings
 		zoneCopy.Buildings = buildingStatsCopy
@@ -76,7 +89,7 @@ func (p *OSMProcessor) createZoneBackups(zones []*model.Zone) map[string]*ZoneBa
 		backups[zone.ID] = backup
 	}
 
-	log.Printf("Successfully created %d zone backups", len(backups))
+	logx.Info("Successfully created %d zone backups", len(backups))
 	return backups
 }
 
@@ -90,7 +103,7 @@ func (p *OSMProcessor) createZoneBackupsForRecalcNeeded(zones []*model.Zone) map
 		}
 	}
 
-	log.Printf("Found %d zones marked for recalculation out of %d total zones", len(zonesToBackup), len(zones))
+	logx.Info("Found %d zones marked for recalculation out of %d total zones", len(zonesToBackup), len(zones))
 
 	// Use existing createZoneBackups function
 	return p.createZoneBackups(zonesToBackup)
@@ -98,7 +111,7 @@ func (p *OSMProcessor) createZoneBackupsForRecalcNeeded(zones []*model.Zone) map
 
 // restoreZonesFromBackups restores zones from their backup copies
 func (p *OSMProcessor) restoreZonesFromBackups(zoneIDs []string, zoneBackups map[string]*ZoneBackup, zones []*model.Zone) {
-	log.Printf("Restoring %d zones from backups", len(zoneIDs))
+	logx.Info("Restoring %d zones from backups", len(zoneIDs))
 
 	for _, zoneID := range zoneIDs {
 		if backup, exists := zoneBackups[zoneID]; exists {
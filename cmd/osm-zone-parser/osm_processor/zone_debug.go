@@ -3,16 +3,14 @@ package osm_processor
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"time"
 
 	mappers "metalink/cmd/osm-zone-parser/mappers"
 	parser_model "metalink/cmd/osm-zone-parser/models"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 	pg "metalink/internal/postgres"
-
-	"github.com/paulmach/orb/geo"
 )
 
 // createTestZone creates a test zone that will contain all buildings
@@ -50,7 +48,7 @@ func (p *OSMProcessor) saveTestZoneToDB(testZone *model.Zone) error {
 		CreatedAt:         now,
 	}
 
-	// Use UPSERT (ON CONFLICT DO UPDATE)
+	// Use UPSERT (ON CONFLICT DO UPDATE), created_at is kept on update
 	query := `
 		INSERT INTO zones (
 			id, name, top_left_lat_lon, top_right_lat_lon, 
@@ -116,7 +114,7 @@ func (p *OSMProcessor) saveTestZoneToDB(testZone *model.Zone) error {
 		return fmt.Errorf("failed to upsert test zone: %w", result.Error)
 	}
 
-	log.Printf("Saved test zone with ID 'TESTID' to database")
+	logx.Info("Saved test zone with ID 'TESTID' to database")
 	return nil
 }
 
@@ -154,52 +152,54 @@ func (p *OSMProcessor) SaveTestZoneToJSON(testZone *model.Zone, outputFile strin
 		return fmt.Errorf("failed to write test zone JSON file: %w", err)
 	}
 
-	log.Printf("Successfully saved test zone to %s", outputFile)
+	logx.Info("Successfully saved test zone to %s", outputFile)
 	return nil
 }
 
 // addBuildingToTestZoneWithGameType adds building statistics to the test zone using game type
-func (p *OSMProcessor) addBuildingToTestZoneWithGameType(building *model.Building, buildingArea float64, gameCategory string, testZone *model.Zone) {
+func (p *OSMProcessor) addBuildingToTestZoneWithGameType(building *model.Building, buildingArea float64, categories []mappers.CategoryShare, testZone *model.Zone) {
 	// Update building count by game type
-	testZone.Buildings.BuildingTypes[gameCategory]++
-	testZone.Buildings.BuildingAreas[gameCategory] += buildingArea
+	for _, category := range categories {
+		testZone.Buildings.BuildingTypes[category.Category]++
+		testZone.Buildings.BuildingAreas[category.Category] += buildingArea * category.Share
+	}
 	testZone.Buildings.TotalCount++
 	testZone.Buildings.TotalArea += buildingArea
 
+	// Keep the original OSM type alongside the game category if requested
+	if p.KeepRawTypes {
+		if testZone.Buildings.RawBuildingTypes == nil {
+			testZone.Buildings.RawBuildingTypes = make(map[string]int)
+		}
+		testZone.Buildings.RawBuildingTypes[building.Type]++
+	}
+
 	// Update stats based on building height
-	if building.Levels <= 1 {
-		testZone.Buildings.SingleFloorCount++
-		testZone.Buildings.SingleFloorTotalArea += buildingArea
-	} else if building.Levels >= 2 && building.Levels <= 9 {
-		testZone.Buildings.LowRiseCount++
-		testZone.Buildings.LowRiseTotalArea += buildingArea
-	} else if building.Levels >= 10 && building.Levels <= 29 {
-		testZone.Buildings.HighRiseCount++
-		testZone.Buildings.HighRiseTotalArea += buildingArea
-	} else if building.Levels >= 30 {
-		testZone.Buildings.SkyscraperCount++
-		testZone.Buildings.SkyscraperTotalArea += buildingArea
+	testZone.Buildings.AddToHeightClass(model.ClassifyBuildingHeight(building.Levels), buildingArea)
+
+	if len(p.LevelBuckets) > 0 {
+		testZone.Buildings.AddToLevelBuckets(p.LevelBuckets, building.Levels, buildingArea)
 	}
 }
 
 // fillTestZoneWithAllBuildings fills the test zone with all buildings (only called once)
 func (p *OSMProcessor) fillTestZoneWithAllBuildings(testZone *model.Zone) error {
-	log.Printf("Filling test zone with all %d buildings", len(p.Buildings))
+	logx.Info("Filling test zone with all %d buildings", len(p.Buildings))
 
 	for i, building := range p.Buildings {
 		// Calculate building properties
-		buildingArea := geo.Area(building.Outline) * float64(building.Levels)
-		gameCategory := mappers.MapBuildingCategory(building.Type)
+		buildingArea := building.FootprintArea() * float64(building.Levels)
+		categories := p.buildingCategories(building)
 
-		// Add building to test zone with full area and game category
-		p.addBuildingToTestZoneWithGameType(building, buildingArea, gameCategory, testZone)
+		// Add building to test zone with full area and game categories
+		p.addBuildingToTestZoneWithGameType(building, buildingArea, categories, testZone)
 
 		// Log progress
 		if (i+1)%20000 == 0 {
-			log.Printf("Added %d/%d buildings to test zone...", i+1, len(p.Buildings))
+			logx.Debug("Added %d/%d buildings to test zone...", i+1, len(p.Buildings))
 		}
 	}
 
-	log.Printf("Successfully filled test zone with %d buildings", len(p.Buildings))
+	logx.Info("Successfully filled test zone with %d buildings", len(p.Buildings))
 	return nil
 }
@@ -1,6 +1,6 @@
 package osm_processor
 
-import "log"
+import "metalink/internal/logx"
 
 // ZoneDependencies tracks connections between zones through shared buildings
 type ZoneDependencies struct {
@@ -81,7 +81,7 @@ func (p *OSMProcessor) findConnectedZones(overweightZoneIDs []string, dependenci
 
 	connectedZones := dependencies.getConnectedZones(overweightZoneIDs)
 
-	log.Printf("Found %d zones connected to %d overweight zones through building dependencies",
+	logx.Info("Found %d zones connected to %d overweight zones through building dependencies",
 		len(connectedZones), len(overweightZoneIDs))
 
 	return connectedZones
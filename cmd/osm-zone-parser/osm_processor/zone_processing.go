@@ -2,14 +2,13 @@ package osm_processor
 
 import (
 	"fmt"
-	"log"
 	mappers "metalink/cmd/osm-zone-parser/mappers"
 	utils "metalink/cmd/osm-zone-parser/utils"
+	"metalink/internal/logx"
 	"metalink/internal/model"
+	"metalink/internal/util/geoproj"
 
 	"github.com/dhconnelly/rtreego"
-	"github.com/paulmach/orb"
-	"github.com/paulmach/orb/geo"
 )
 
 // processRecalculationNeededZones processes only zones marked for recalculation
@@ -26,7 +25,7 @@ func (p *OSMProcessor) processRecalculationNeededZones(zones []*model.Zone, zone
 			recalcZones++
 		}
 	}
-	log.Printf("Processing buildings for %d zones marked for recalculation", recalcZones)
+	logx.Info("Processing buildings for %d zones marked for recalculation", recalcZones)
 
 	// Process each building
 	for i, building := range p.Buildings {
@@ -36,7 +35,7 @@ func (p *OSMProcessor) processRecalculationNeededZones(zones []*model.Zone, zone
 
 		// Log progress
 		if (i+1)%20000 == 0 {
-			log.Printf("Processed %d/%d buildings for recalculation zones...", i+1, len(p.Buildings))
+			logx.Debug("Processed %d/%d buildings for recalculation zones...", i+1, len(p.Buildings))
 		}
 	}
 
@@ -47,30 +46,14 @@ func (p *OSMProcessor) processRecalculationNeededZones(zones []*model.Zone, zone
 		}
 	}
 
-	log.Printf("Cleared RecalculateNeeded flag for processed zones")
+	logx.Info("Cleared RecalculateNeeded flag for processed zones")
 	return stats, nil
 }
 
 // processSingleBuildingForRecalcZones processes a building only for zones needing recalculation
 func (p *OSMProcessor) processSingleBuildingForRecalcZones(building *model.Building, zoneIndex *rtreego.Rtree, stats *ProcessingStats) error {
-	// Calculate building properties FIRST
-	buildingArea := geo.Area(building.Outline) * float64(building.Levels)
-	gameCategory := mappers.MapBuildingCategory(building.Type)
-
-	// Get building configuration
-	buildingConfig := mappers.GetBuildingEffectsConfig(gameCategory)
-	if buildingConfig == nil {
-		buildingConfig = &mappers.BuildingTypeConfig{
-			ExtraRadiusKf: 1.0,
-			Weight:        1,
-		}
-	}
-
-	// Calculate influence radius
-	influenceRadius := utils.CalculateBuildingInfluenceRadius(buildingArea, buildingConfig.ExtraRadiusKf)
-
-	// Find all zones within influence radius
-	zonesInRadius := p.findZonesInRadius(zoneIndex, building.CentroidLon, building.CentroidLat, influenceRadius)
+	buildingArea, categories, zonesInRadius := p.buildingInfluence(building, zoneIndex)
+	zonesInRadius = p.withoutOriginalZones(building, zoneIndex, zonesInRadius)
 
 	// Filter to only zones that need recalculation
 	var recalcZonesInRadius []*ZoneSpatial
@@ -92,7 +75,7 @@ func (p *OSMProcessor) processSingleBuildingForRecalcZones(building *model.Build
 		}
 
 		// Distribute building to recalculation zones
-		p.distributeBuildingToZones(building, buildingArea, gameCategory, recalcZonesInRadius)
+		p.distributeBuildingToZones(building, buildingArea, categories, recalcZonesInRadius)
 		stats.ProcessedBuildings++
 	}
 
@@ -101,13 +84,35 @@ func (p *OSMProcessor) processSingleBuildingForRecalcZones(building *model.Build
 	return nil
 }
 
+// buildingInfluence returns the building area, its game categories (dominant first)
+// and all zones within the influence radius of the building
+func (p *OSMProcessor) buildingInfluence(building *model.Building, zoneIndex *rtreego.Rtree) (float64, []mappers.CategoryShare, []*ZoneSpatial) {
+	buildingArea := building.FootprintArea() * float64(building.Levels)
+	categories := p.buildingCategories(building)
+
+	// Get building configuration of the dominant category
+	buildingConfig := mappers.GetBuildingEffectsConfig(categories[0].Category)
+	if buildingConfig == nil {
+		buildingConfig = &mappers.BuildingTypeConfig{
+			ExtraRadiusKf: 1.0,
+			Weight:        1,
+		}
+	}
+
+	// Find all zones within influence radius
+	influenceRadius := utils.CalculateBuildingInfluenceRadius(buildingArea, buildingConfig)
+	zonesInRadius := p.findZonesInRadius(zoneIndex, building.CentroidLon, building.CentroidLat, influenceRadius)
+
+	return buildingArea, categories, zonesInRadius
+}
+
 // removeZonesFromList removes zones with specified IDs from the zones list
 func (p *OSMProcessor) removeZonesFromList(zones *[]*model.Zone, zoneIDsToRemove []string) {
 	if len(zoneIDsToRemove) == 0 {
 		return
 	}
 
-	log.Printf("Removing %d zones from zones list", len(zoneIDsToRemove))
+	logx.Debug("Removing %d zones from zones list", len(zoneIDsToRemove))
 
 	// Create a map for fast lookup
 	removeMap := make(map[string]bool, len(zoneIDsToRemove))
@@ -124,7 +129,7 @@ func (p *OSMProcessor) removeZonesFromList(zones *[]*model.Zone, zoneIDsToRemove
 	}
 
 	*zones = filteredZones
-	log.Printf("Zones list now contains %d zones", len(*zones))
+	logx.Debug("Zones list now contains %d zones", len(*zones))
 }
 
 // addZonesToList adds new zones to the zones list
@@ -133,9 +138,9 @@ func (p *OSMProcessor) addZonesToList(zones *[]*model.Zone, newZones []*model.Zo
 		return
 	}
 
-	log.Printf("Adding %d new zones to zones list", len(newZones))
+	logx.Debug("Adding %d new zones to zones list", len(newZones))
 	*zones = append(*zones, newZones...)
-	log.Printf("Zones list now contains %d zones", len(*zones))
+	logx.Debug("Zones list now contains %d zones", len(*zones))
 }
 
 // updateSpatialIndexWithNewZones rebuilds spatial index to include new zones
@@ -161,17 +166,15 @@ func (p *OSMProcessor) updateSpatialIndexWithNewZones(zones []*model.Zone) (*rtr
 		zoneIndex.Insert(zoneSpatial)
 	}
 
-	log.Printf("Rebuilt spatial index for %d zones", len(zones))
+	logx.Debug("Rebuilt spatial index for %d zones", len(zones))
 	return zoneIndex, nil
 }
 
 // findZonesInRadius finds all zones that intersect with a circle of given radius around a point
 func (p *OSMProcessor) findZonesInRadius(zoneIndex *rtreego.Rtree, centerLon, centerLat, radiusMeters float64) []*ZoneSpatial {
-	// Convert radius from meters to degrees (approximate)
-	// For latitude: 1 degree ≈ 111km
-	radiusLat := radiusMeters / 111000.0
-	// For longitude: depends on latitude
-	radiusLon := utils.MetersToDegrees(radiusMeters, centerLat)
+	// Convert radius from meters to degrees; longitude degrees shrink towards the poles
+	radiusLat := geoproj.MetersToDegreesLat(radiusMeters, centerLat)
+	radiusLon := geoproj.MetersToDegreesLon(radiusMeters, centerLat)
 
 	// Create a search rectangle that contains the circle
 	searchRect, _ := rtreego.NewRect(
@@ -197,14 +200,7 @@ func (p *OSMProcessor) findZonesInRadius(zoneIndex *rtreego.Rtree, centerLon, ce
 // prepareZoneGeometry creates polygon and bounding box for zone if not already created
 func (p *OSMProcessor) prepareZoneGeometry(zone *model.Zone) error {
 	if zone.Polygon == nil {
-		ring := orb.Ring{
-			orb.Point{zone.TopLeftLatLon[1], zone.TopLeftLatLon[0]},         // [lon, lat]
-			orb.Point{zone.TopRightLatLon[1], zone.TopRightLatLon[0]},       // [lon, lat]
-			orb.Point{zone.BottomRightLatLon[1], zone.BottomRightLatLon[0]}, // [lon, lat]
-			orb.Point{zone.BottomLeftLatLon[1], zone.BottomLeftLatLon[0]},   // [lon, lat]
-			orb.Point{zone.TopLeftLatLon[1], zone.TopLeftLatLon[0]},         // Close the ring
-		}
-		polygon := orb.Polygon{ring}
+		polygon := zone.BuildPolygon()
 		bound := polygon.Bound()
 		zone.Polygon = &polygon
 		zone.BoundingBox = &bound
@@ -223,19 +219,37 @@ func (p *OSMProcessor) initializeZoneBuildingStats(zone *model.Zone) {
 }
 
 // distributeBuildingToZones distributes a building's area and stats to affected zones
-func (p *OSMProcessor) distributeBuildingToZones(building *model.Building, buildingArea float64, gameCategory string, zonesInRadius []*ZoneSpatial) {
+// Mixed-use buildings are counted in every category they contribute to, with the area split by share
+func (p *OSMProcessor) distributeBuildingToZones(building *model.Building, buildingArea float64, categories []mappers.CategoryShare, zonesInRadius []*ZoneSpatial) {
 	// Distribute building area equally among all affected zones
 	areaPerZone := buildingArea / float64(len(zonesInRadius))
 
 	for _, zoneSpatial := range zonesInRadius {
 		zone := zoneSpatial.Zone
 
-		// Update building count and area by game type (not OSM type)
-		zone.Buildings.BuildingTypes[gameCategory]++
-		zone.Buildings.BuildingAreas[gameCategory] += areaPerZone
+		for _, category := range categories {
+			categoryArea := areaPerZone * category.Share
+
+			// Update effects incrementally (before the area is added to BuildingAreas)
+			if p.IncrementalEffects {
+				zone.AddBuildingEffects(category.Category, categoryArea)
+			}
+
+			// Update building count and area by game type (not OSM type)
+			zone.Buildings.BuildingTypes[category.Category]++
+			zone.Buildings.BuildingAreas[category.Category] += categoryArea
+		}
 		zone.Buildings.TotalCount++
 		zone.Buildings.TotalArea += areaPerZone
 
+		// Keep the original OSM type alongside the game category if requested
+		if p.KeepRawTypes {
+			if zone.Buildings.RawBuildingTypes == nil {
+				zone.Buildings.RawBuildingTypes = make(map[string]int)
+			}
+			zone.Buildings.RawBuildingTypes[building.Type]++
+		}
+
 		// Update stats based on building height
 		p.updateZoneHeightStats(zone, building, areaPerZone)
 	}
@@ -243,18 +257,10 @@ func (p *OSMProcessor) distributeBuildingToZones(building *model.Building, build
 
 // updateZoneHeightStats updates zone statistics based on building height
 func (p *OSMProcessor) updateZoneHeightStats(zone *model.Zone, building *model.Building, area float64) {
-	if building.Levels <= 1 {
-		zone.Buildings.SingleFloorCount++
-		zone.Buildings.SingleFloorTotalArea += area
-	} else if building.Levels >= 2 && building.Levels <= 9 {
-		zone.Buildings.LowRiseCount++
-		zone.Buildings.LowRiseTotalArea += area
-	} else if building.Levels >= 10 && building.Levels <= 29 {
-		zone.Buildings.HighRiseCount++
-		zone.Buildings.HighRiseTotalArea += area
-	} else if building.Levels >= 30 {
-		zone.Buildings.SkyscraperCount++
-		zone.Buildings.SkyscraperTotalArea += area
+	zone.Buildings.AddToHeightClass(model.ClassifyBuildingHeight(building.Levels), area)
+
+	if len(p.LevelBuckets) > 0 {
+		zone.Buildings.AddToLevelBuckets(p.LevelBuckets, building.Levels, area)
 	}
 }
 
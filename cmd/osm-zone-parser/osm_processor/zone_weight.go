@@ -1,8 +1,8 @@
 package osm_processor
 
 import (
-	"log"
 	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 	"metalink/internal/util"
 )
@@ -32,10 +32,8 @@ func (p *OSMProcessor) calculateZoneWeight(zone *model.Zone) float64 {
 func (p *OSMProcessor) calculateZoneDimensions(zone *model.Zone) float64 {
 	// For square zones, we only need to calculate one side
 	// Use top width as representative dimension
-	topWidth := util.HaversineDistance(
-		zone.TopLeftLatLon[0], zone.TopLeftLatLon[1],
-		zone.TopRightLatLon[0], zone.TopRightLatLon[1],
-	)
+	topLeft, topRight, _, _ := zone.Corners()
+	topWidth := util.HaversineDistance(topLeft.Lat(), topLeft.Lon(), topRight.Lat(), topRight.Lon())
 
 	// Assume zone is square
 	return topWidth
@@ -43,13 +41,20 @@ func (p *OSMProcessor) calculateZoneDimensions(zone *model.Zone) float64 {
 
 // findOverweightZones finds zones that exceed the weight threshold and can be safely split
 func (p *OSMProcessor) findOverweightZonesWithMinSize(zones []*model.Zone, weightThreshold float64) []string {
-	log.Printf("Analyzing %d zones for weight threshold %.2f and minimum split size %.2f meters",
-		len(zones), weightThreshold, p.MinZoneSize)
+	metric := "weight"
+	if p.SplitByArea {
+		metric = "building area"
+	}
+	logx.Info("Analyzing %d zones for %s threshold %.2f and minimum split size %.2f meters",
+		len(zones), metric, weightThreshold, p.MinZoneSize)
 
 	var overweightZoneIDs []string
 
 	for _, zone := range zones {
 		zoneWeight := p.calculateZoneWeight(zone)
+		if p.SplitByArea {
+			zoneWeight = zone.Buildings.TotalArea
+		}
 
 		if zoneWeight > weightThreshold {
 			// Check if zone can be safely split without going below minimum size
@@ -65,6 +70,6 @@ func (p *OSMProcessor) findOverweightZonesWithMinSize(zones []*model.Zone, weigh
 		}
 	}
 
-	log.Printf("Found %d zones that can be split safely", len(overweightZoneIDs))
+	logx.Info("Found %d zones that can be split safely", len(overweightZoneIDs))
 	return overweightZoneIDs
 }
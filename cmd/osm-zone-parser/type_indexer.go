@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/model"
 )
 
 // ZoneData represents the structure of the JSON file with zone data
 type ZoneData struct {
-	Zone      ZoneGeometry  `json:"zone"`
-	Buildings BuildingStats `json:"buildings"`
+	Zone      ZoneGeometry        `json:"zone"`
+	Buildings model.BuildingStats `json:"buildings"`
 }
 
 // ZoneGeometry represents the geometric data of the zone
@@ -24,22 +28,6 @@ type ZoneGeometry struct {
 	Size              float64    `json:"Size"`
 }
 
-// BuildingStats represents the building statistics in the zone
-type BuildingStats struct {
-	SingleFloorCount     int                `json:"single_floor_count"`
-	SingleFloorTotalArea float64            `json:"single_floor_total_area"`
-	LowRiseCount         int                `json:"low_rise_count"`
-	LowRiseTotalArea     float64            `json:"low_rise_total_area"`
-	HighRiseCount        int                `json:"high_rise_count"`
-	HighRiseTotalArea    float64            `json:"high_rise_total_area"`
-	SkyscraperCount      int                `json:"skyscraper_count"`
-	SkyscraperTotalArea  float64            `json:"skyscraper_total_area"`
-	TotalCount           int                `json:"total_count"`
-	TotalArea            float64            `json:"total_area"`
-	BuildingTypes        map[string]int     `json:"building_types"`
-	BuildingAreas        map[string]float64 `json:"building_areas"`
-}
-
 // MergedStats represents the result of merging building statistics
 type MergedStats struct {
 	BuildingTypes map[string]int     `json:"building_types"`
@@ -102,11 +90,8 @@ func processTypeIndexer(config TypeIndexerConfig) error {
 	log.Printf("Filter long names (>50 chars): %v", config.FilterLongNames)
 	log.Printf("Filter logic: Remove if count < min-occurrences AND area < min-area")
 
-	// Create an object to store merged data
-	mergedStats := MergedStats{
-		BuildingTypes: make(map[string]int),
-		BuildingAreas: make(map[string]float64),
-	}
+	// Sum of the building stats of all zones
+	totalStats := model.MergeBuildingStats()
 
 	// Process each input file
 	for _, filePath := range files {
@@ -128,15 +113,13 @@ func processTypeIndexer(config TypeIndexerConfig) error {
 			continue
 		}
 
-		// Merge building type data
-		for buildingType, count := range zoneData.Buildings.BuildingTypes {
-			mergedStats.BuildingTypes[buildingType] += count
-		}
+		// Merge building type counts and areas
+		totalStats.Add(zoneData.Buildings)
+	}
 
-		// Merge building area data
-		for buildingType, area := range zoneData.Buildings.BuildingAreas {
-			mergedStats.BuildingAreas[buildingType] += area
-		}
+	mergedStats := MergedStats{
+		BuildingTypes: totalStats.BuildingTypes,
+		BuildingAreas: totalStats.BuildingAreas,
 	}
 
 	log.Printf("Before filtering: %d building types", len(mergedStats.BuildingTypes))
@@ -188,8 +171,8 @@ func applyFilters(mergedStats MergedStats, config TypeIndexerConfig) (MergedStat
 			stats.RemovedByShortName++
 		}
 
-		// Check semicolon filter
-		if config.FilterSemicolon && strings.Contains(buildingType, ";") {
+		// Check semicolon filter, keeping combined types that resolve through one of their parts
+		if config.FilterSemicolon && strings.Contains(buildingType, ";") && !mappers.IsMappedBuildingType(buildingType) {
 			shouldRemove = true
 			stats.RemovedBySemicolon++
 		}
@@ -258,50 +241,45 @@ func printStatistics(stats MergedStats) {
 
 // getTopBuildingTypes returns the top-N building types by count
 func getTopBuildingTypes(types map[string]int, n int) []TypeValue {
-	// Create a slice for sorting
-	var items []TypeValue
+	items := make([]TypeValue, 0, len(types))
 	for t, count := range types {
 		items = append(items, TypeValue{Type: t, Value: count})
 	}
 
-	// Sort by descending count
-	for i := 0; i < len(items); i++ {
-		for j := i + 1; j < len(items); j++ {
-			if items[i].Value.(int) < items[j].Value.(int) {
-				items[i], items[j] = items[j], items[i]
-			}
+	// Descending count, ties by type name for deterministic output
+	return topN(items, n, func(a, b TypeValue) bool {
+		if a.Value.(int) != b.Value.(int) {
+			return a.Value.(int) > b.Value.(int)
 		}
-	}
-
-	// Limit the number of results
-	if len(items) > n {
-		items = items[:n]
-	}
-
-	return items
+		return a.Type < b.Type
+	})
 }
 
 // getTopBuildingAreas returns the top-N building types by area
 func getTopBuildingAreas(areas map[string]float64, n int) []TypeValue {
-	// Create a slice for sorting
-	var items []TypeValue
+	items := make([]TypeValue, 0, len(areas))
 	for t, area := range areas {
 		items = append(items, TypeValue{Type: t, Value: area})
 	}
 
-	// Sort by descending area
-	for i := 0; i < len(items); i++ {
-		for j := i + 1; j < len(items); j++ {
-			if items[i].Value.(float64) < items[j].Value.(float64) {
-				items[i], items[j] = items[j], items[i]
-			}
+	// Descending area, ties by type name for deterministic output
+	return topN(items, n, func(a, b TypeValue) bool {
+		if a.Value.(float64) != b.Value.(float64) {
+			return a.Value.(float64) > b.Value.(float64)
 		}
-	}
+		return a.Type < b.Type
+	})
+}
 
-	// Limit the number of results
-	if len(items) > n {
+// topN sorts items in place by less and returns the first n of them
+// less must be a strict total order for the result to be deterministic
+func topN[T any](items []T, n int, less func(a, b T) bool) []T {
+	sort.Slice(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+
+	if n >= 0 && len(items) > n {
 		items = items[:n]
 	}
-
 	return items
 }
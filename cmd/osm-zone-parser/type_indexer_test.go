@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// typeNames returns the types of the items in order
+func typeNames(items []TypeValue) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Type
+	}
+	return names
+}
+
+func TestTopBuildingTypesBreaksTiesByName(t *testing.T) {
+	types := map[string]int{"shed": 5, "house": 9, "garage": 5, "barn": 5, "church": 1}
+	want := []string{"house", "barn", "garage", "shed"}
+
+	// Map iteration order changes between runs, so repeat to catch unstable ties
+	for i := 0; i < 20; i++ {
+		if got := typeNames(getTopBuildingTypes(types, 4)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("top types = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTopBuildingAreasBreaksTiesByName(t *testing.T) {
+	areas := map[string]float64{"shed": 50, "house": 900, "garage": 50, "barn": 50}
+	want := []string{"house", "barn", "garage"}
+
+	for i := 0; i < 20; i++ {
+		if got := typeNames(getTopBuildingAreas(areas, 3)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("top areas = %v, want %v", got, want)
+		}
+	}
+}
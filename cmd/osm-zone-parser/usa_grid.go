@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"math"
 	parser_model "metalink/cmd/osm-zone-parser/models"
+	"metalink/internal/util"
+	"metalink/internal/util/geoproj"
+
+	"github.com/paulmach/orb"
 )
 
 // USA map boundaries in [lat, lon] format
@@ -15,14 +19,58 @@ var (
 )
 
 // buildBaseUSAGrid creates a grid of zones covering the USA
+// Kept for compatibility, use BuildBaseGrid for arbitrary regions
 func buildBaseUSAGrid() []parser_model.GameZone {
+	return BuildBaseGrid(USATopLeft, USATopRight, USABottomLeft, USABottomRight, baseZoneSize)
+}
+
+// BuildBaseGrid creates a grid of zones covering the region defined by its four corners
+// Corners are in [lat, lon] format, tileSize is in meters
+func BuildBaseGrid(topLeft, topRight, bottomLeft, bottomRight [2]float64, tileSize float64) []parser_model.GameZone {
 	// Build dynamic grid
-	zones := buildFixeSizedGrid(USATopLeft, USATopRight, USABottomLeft, USABottomRight, baseZoneSize)
-	fmt.Printf("Created %d zones with buildBaseUSAGrid\n", len(zones))
+	zones := buildFixeSizedGrid(topLeft, topRight, bottomLeft, bottomRight, tileSize)
+	fmt.Printf("Created %d zones with BuildBaseGrid\n", len(zones))
 
 	return zones
 }
 
+// regionCorners returns the corners of the region configured via command line flags
+// in [lat, lon] format: top-left, top-right, bottom-left, bottom-right
+func regionCorners() (topLeft, topRight, bottomLeft, bottomRight [2]float64) {
+	topLeft = [2]float64{regionTopLat, regionLeftLon}
+	topRight = [2]float64{regionTopLat, regionRightLon}
+	bottomLeft = [2]float64{regionBottomLat, regionLeftLon}
+	bottomRight = [2]float64{regionBottomLat, regionRightLon}
+	return
+}
+
+// expectedRegionBounds returns the extent a parser run is expected to cover in [lon, lat] order:
+// the -region-* flags, narrowed to -roi-bbox when set
+func expectedRegionBounds() orb.Bound {
+	region := orb.Bound{
+		Min: orb.Point{math.Min(regionLeftLon, regionRightLon), math.Min(regionBottomLat, regionTopLat)},
+		Max: orb.Point{math.Max(regionLeftLon, regionRightLon), math.Max(regionBottomLat, regionTopLat)},
+	}
+	if roiBBox == "" {
+		return region
+	}
+
+	roi, err := util.ParseBounds(roiBBox)
+	if err != nil || !roi.Intersects(region) {
+		return region
+	}
+	return orb.Bound{
+		Min: orb.Point{math.Max(roi.Min[0], region.Min[0]), math.Max(roi.Min[1], region.Min[1])},
+		Max: orb.Point{math.Min(roi.Max[0], region.Max[0]), math.Min(roi.Max[1], region.Max[1])},
+	}
+}
+
+// buildBaseRegionGrid creates a grid of zones covering the region configured via command line flags
+func buildBaseRegionGrid() []parser_model.GameZone {
+	topLeft, topRight, bottomLeft, bottomRight := regionCorners()
+	return BuildBaseGrid(topLeft, topRight, bottomLeft, bottomRight, baseZoneSize)
+}
+
 // buildFixeSizedGrid creates a grid of zones with area of maxZoneSize*maxZoneSize sq. meters
 // The height is always maxZoneSize meters, and width is adjusted to achieve the target area
 func buildFixeSizedGrid(topLeft, topRight, bottomLeft, bottomRight [2]float64, maxZoneSize float64) []parser_model.GameZone {
@@ -43,7 +91,7 @@ func buildFixeSizedGrid(topLeft, topRight, bottomLeft, bottomRight [2]float64, m
 	// Continue creating rows until we've covered the entire area and beyond if needed
 	for {
 		// Calculate the next latitude that is exactly maxZoneSize meters south
-		nextLat := getDestinationPoint(lat, minLon, 180, maxZoneSize)[0]
+		nextLat := lat - geoproj.MetersToDegreesLat(maxZoneSize, lat)
 
 		// Start at the westernmost longitude (min) and move east
 		lon := minLon
@@ -59,12 +107,8 @@ func buildFixeSizedGrid(topLeft, topRight, bottomLeft, bottomRight [2]float64, m
 			// We know height is maxZoneSize, so width = targetArea / height
 			targetWidth := targetArea / maxZoneSize
 
-			// Calculate how far to go east in longitude degrees
-			// This is based on the formula for distance along a parallel of latitude
-			earthRadius := 6371000.0 // Earth's radius in meters
-			latRad := midLat * math.Pi / 180
-			lonDiffRad := targetWidth / (earthRadius * math.Cos(latRad))
-			lonDiff := lonDiffRad * 180 / math.Pi
+			// Calculate how far to go east in longitude degrees along the parallel of latitude
+			lonDiff := geoproj.MetersToDegreesLon(targetWidth, midLat)
 
 			// Calculate the next longitude
 			nextLon := lon + lonDiff
@@ -109,37 +153,3 @@ func buildFixeSizedGrid(topLeft, topRight, bottomLeft, bottomRight [2]float64, m
 
 	return zones
 }
-
-// getDestinationPoint calculates a destination point given a starting point, bearing and distance
-// lat, lon are in degrees, bearing in degrees (0=north, 90=east, etc), distance in meters
-// Returns [lat, lon] in degrees
-func getDestinationPoint(lat, lon, bearing, distance float64) [2]float64 {
-	// Convert to radians
-	latRad := lat * math.Pi / 180
-	lonRad := lon * math.Pi / 180
-	bearingRad := bearing * math.Pi / 180
-
-	// Earth's radius in meters
-	earthRadius := 6371000.0
-
-	// Calculate
-	distRatio := distance / earthRadius
-
-	// Calculate new latitude
-	newLatRad := math.Asin(
-		math.Sin(latRad)*math.Cos(distRatio) +
-			math.Cos(latRad)*math.Sin(distRatio)*math.Cos(bearingRad),
-	)
-
-	// Calculate new longitude
-	newLonRad := lonRad + math.Atan2(
-		math.Sin(bearingRad)*math.Sin(distRatio)*math.Cos(latRad),
-		math.Cos(distRatio)-math.Sin(latRad)*math.Sin(newLatRad),
-	)
-
-	// Convert back to degrees
-	newLat := newLatRad * 180 / math.Pi
-	newLon := newLonRad * 180 / math.Pi
-
-	return [2]float64{newLat, newLon}
-}
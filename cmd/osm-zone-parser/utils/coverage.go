@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"math"
+
+	"metalink/internal/model"
+	"metalink/internal/util/geoproj"
+
+	"github.com/paulmach/orb"
+)
+
+// ComputeCoverage returns the fraction (0..1) of the region bounds covered by the given zones
+// Each zone contributes the part of its bounding box that lies inside the region.
+// Zones are expected not to overlap (as produced by the grid and subdivision)
+func ComputeCoverage(zones []*model.Zone, regionBounds orb.Bound) float64 {
+	regionArea := boundArea(regionBounds)
+	if regionArea <= 0 {
+		return 0
+	}
+
+	var coveredArea float64
+	for _, zone := range zones {
+		zoneBounds := zone.CornersBound()
+		if !zoneBounds.Intersects(regionBounds) {
+			continue
+		}
+
+		coveredArea += boundArea(clipBound(zoneBounds, regionBounds))
+	}
+
+	// Overlapping zones may push the sum above the region area
+	return math.Min(coveredArea/regionArea, 1.0)
+}
+
+// clipBound returns the intersection of two bounds, assuming they intersect
+func clipBound(b, clip orb.Bound) orb.Bound {
+	return orb.Bound{
+		Min: orb.Point{math.Max(b.Min[0], clip.Min[0]), math.Max(b.Min[1], clip.Min[1])},
+		Max: orb.Point{math.Min(b.Max[0], clip.Max[0]), math.Min(b.Max[1], clip.Max[1])},
+	}
+}
+
+// boundArea returns the area of a [lon, lat] bound in square meters
+func boundArea(b orb.Bound) float64 {
+	return geoproj.BoundArea(b.Min[1], b.Min[0], b.Max[1], b.Max[0])
+}
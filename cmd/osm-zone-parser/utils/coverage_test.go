@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+)
+
+// rectZone returns a zone covering the given lat/lon rectangle
+func rectZone(id string, minLat, minLon, maxLat, maxLon float64) *model.Zone {
+	return &model.Zone{
+		ID:                id,
+		TopLeftLatLon:     []float64{maxLat, minLon},
+		TopRightLatLon:    []float64{maxLat, maxLon},
+		BottomRightLatLon: []float64{minLat, maxLon},
+		BottomLeftLatLon:  []float64{minLat, minLon},
+	}
+}
+
+func TestComputeCoverageHalfRegion(t *testing.T) {
+	region := orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-99, 41}}
+
+	// Two zones covering the western half of the region
+	zones := []*model.Zone{
+		rectZone("a", 40, -100, 40.5, -99.5),
+		rectZone("b", 40.5, -100, 41, -99.5),
+	}
+
+	coverage := ComputeCoverage(zones, region)
+	if math.Abs(coverage-0.5) > 0.001 {
+		t.Fatalf("coverage = %.4f, want 0.5", coverage)
+	}
+}
+
+func TestComputeCoverageUsesMetersNotDegrees(t *testing.T) {
+	region := orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{1, 80}}
+
+	// The southern half in degrees holds far more than half of the area in meters
+	zones := []*model.Zone{rectZone("south", 0, 0, 40, 1)}
+
+	coverage := ComputeCoverage(zones, region)
+	want := math.Sin(40*math.Pi/180) / math.Sin(80*math.Pi/180)
+	if math.Abs(coverage-want) > 0.001 {
+		t.Fatalf("coverage = %.4f, want %.4f", coverage, want)
+	}
+}
+
+func TestComputeCoverageClipsZonesToRegion(t *testing.T) {
+	region := orb.Bound{Min: orb.Point{10, 10}, Max: orb.Point{11, 11}}
+	zones := []*model.Zone{
+		rectZone("outside", 20, 20, 21, 21),
+		rectZone("covering", 9, 9, 12, 12),
+	}
+
+	if coverage := ComputeCoverage(zones, region); coverage != 1 {
+		t.Fatalf("coverage = %.4f, want 1", coverage)
+	}
+	if coverage := ComputeCoverage(nil, region); coverage != 0 {
+		t.Fatalf("coverage of no zones = %.4f, want 0", coverage)
+	}
+}
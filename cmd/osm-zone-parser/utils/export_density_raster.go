@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+
+	zonegeojson "metalink/internal/geojson"
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/paulmach/orb"
+)
+
+// ExportDensityRaster writes a width x height PNG of building density over the bounding box of the zones
+// Each pixel is colored by the max building TotalArea of the zones covering its center, with the
+// log-normalized palette of the GeoJSON exports. Pixels without buildings get the empty zone color
+func ExportDensityRaster(zones []*model.Zone, width, height int, path string) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid density raster size %dx%d", width, height)
+	}
+
+	bounds := model.ZonesBounds(zones)
+	minArea, maxArea := zonegeojson.BuildingAreaRange(zones)
+
+	// Index zones with buildings, the others can't raise a pixel above the background
+	index := rtreego.NewTree(2, 25, 50)
+	for _, zone := range zones {
+		if zone.Buildings.TotalArea <= 0 {
+			continue
+		}
+		polygon := zone.BuildPolygon()
+		index.Insert(&rasterZone{polygon: polygon, bound: polygon.Bound(), value: zone.Buildings.TotalArea})
+	}
+
+	// Sample pixel centers, row 0 is the northern edge
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	stepLon := (bounds.Max[0] - bounds.Min[0]) / float64(width)
+	stepLat := (bounds.Max[1] - bounds.Min[1]) / float64(height)
+	searchLengths := []float64{stepLon / 2, stepLat / 2}
+	for y := 0; y < height; y++ {
+		lat := bounds.Max[1] - (float64(y)+0.5)*stepLat
+		for x := 0; x < width; x++ {
+			lon := bounds.Min[0] + (float64(x)+0.5)*stepLon
+
+			var area float64
+			if searchRect, err := rtreego.NewRect(rtreego.Point{lon, lat}, searchLengths); err == nil {
+				for _, item := range index.SearchIntersect(searchRect) {
+					zone := item.(*rasterZone)
+					if util.PointInPolygon(zone.polygon, orb.Point{lon, lat}) {
+						area = max(area, zone.value)
+					}
+				}
+			}
+			img.SetNRGBA(x, y, zonegeojson.ZoneNRGBA(area, minArea, maxArea))
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create density raster file: %w", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode density raster: %w", err)
+	}
+
+	log.Printf("Exported %dx%d building density raster of %d zones to %s", width, height, len(zones), path)
+	return nil
+}
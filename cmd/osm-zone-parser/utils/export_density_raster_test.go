@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	zonegeojson "metalink/internal/geojson"
+	"metalink/internal/model"
+)
+
+// uniformColor returns the color of the image and whether all its pixels have it
+func uniformColor(img image.Image) (color.Color, bool) {
+	bounds := img.Bounds()
+	first := img.At(bounds.Min.X, bounds.Min.Y)
+	r0, g0, b0, a0 := first.RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if r, g, b, a := img.At(x, y).RGBA(); r != r0 || g != g0 || b != b0 || a != a0 {
+				return first, false
+			}
+		}
+	}
+	return first, true
+}
+
+func TestExportDensityRasterEmptyZonesAreUniform(t *testing.T) {
+	// Zones without buildings, and no zones at all
+	empty := []*model.Zone{
+		rectZone("a", 40, -100, 41, -99),
+		rectZone("b", 40, -99, 41, -98),
+		rectZone("c", 41, -100, 42, -99),
+	}
+	dir := t.TempDir()
+
+	for name, zones := range map[string][]*model.Zone{"empty zones": empty, "no zones": nil} {
+		path := filepath.Join(dir, "density.png")
+		if err := ExportDensityRaster(zones, 30, 20, path); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		img := readPNG(t, path)
+		if size := img.Bounds().Size(); size.X != 30 || size.Y != 20 {
+			t.Fatalf("%s: image is %v, want 30x20", name, size)
+		}
+		background, uniform := uniformColor(img)
+		if !uniform {
+			t.Fatalf("%s: image isn't uniform", name)
+		}
+		minArea, maxArea := zonegeojson.BuildingAreaRange(zones)
+		if want := zonegeojson.ZoneNRGBA(0, minArea, maxArea); color.NRGBAModel.Convert(background) != want {
+			t.Fatalf("%s: background = %v, want the empty zone color %v", name, background, want)
+		}
+	}
+
+	// A zone with buildings stands out of the background
+	empty[0].Buildings.TotalArea = 5000
+	path := filepath.Join(dir, "dense.png")
+	if err := ExportDensityRaster(empty, 30, 20, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, uniform := uniformColor(readPNG(t, path)); uniform {
+		t.Fatal("image with a built zone is uniform")
+	}
+}
+
+func TestExportDensityRasterRejectsInvalidSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "density.png")
+	if err := ExportDensityRaster(nil, 0, 10, path); err == nil {
+		t.Fatal("no error for a zero width")
+	}
+}
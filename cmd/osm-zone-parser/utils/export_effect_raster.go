@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/paulmach/orb"
+)
+
+// rasterZone is a zone polygon with its effect value for raster sampling
+type rasterZone struct {
+	polygon orb.Polygon
+	bound   orb.Bound
+	value   float64
+}
+
+// Bounds implements the rtreego.Spatial interface
+func (z *rasterZone) Bounds() rtreego.Rect {
+	rect, _ := rtreego.NewRectFromPoints(
+		rtreego.Point{z.bound.Min[0], z.bound.Min[1]},
+		rtreego.Point{z.bound.Max[0], z.bound.Max[1]},
+	)
+	return rect
+}
+
+// ExportEffectRaster samples the combined effect of one parameter over the bounds and writes it as a PNG
+// resolution is the number of pixels along the longer side of the bounds, the other side keeps the aspect ratio.
+// Values use a diverging palette: debuffs are blue, buffs are red, brighter means stronger; pixels without effect are transparent
+func ExportEffectRaster(zones []*model.Zone, param model.TargetParamType, bounds orb.Bound, resolution int, outputFile string) error {
+	width, height := RasterSize(bounds, resolution)
+	if width == 0 || height == 0 {
+		return fmt.Errorf("invalid raster bounds %v or resolution %d", bounds, resolution)
+	}
+
+	// Index zones with a non-zero effect for this parameter
+	index := rtreego.NewTree(2, 25, 50)
+	maxAbs := 0.0
+	for _, zone := range zones {
+		if len(zone.Effects) == 0 {
+			zone.CalculateEffects()
+		}
+
+		var value float64
+		for _, effect := range zone.Effects {
+			if effect.ResourceType == param {
+				value += float64(effect.Value)
+			}
+		}
+		if value == 0 {
+			continue
+		}
+
+		polygon := zone.BuildPolygon()
+		index.Insert(&rasterZone{polygon: polygon, bound: polygon.Bound(), value: value})
+	}
+
+	// Sample pixel centers, row 0 is the northern edge
+	values := make([]float64, width*height)
+	stepLon := (bounds.Max[0] - bounds.Min[0]) / float64(width)
+	stepLat := (bounds.Max[1] - bounds.Min[1]) / float64(height)
+	searchLengths := []float64{stepLon / 2, stepLat / 2}
+	for y := 0; y < height; y++ {
+		lat := bounds.Max[1] - (float64(y)+0.5)*stepLat
+		for x := 0; x < width; x++ {
+			lon := bounds.Min[0] + (float64(x)+0.5)*stepLon
+			searchRect, err := rtreego.NewRect(rtreego.Point{lon, lat}, searchLengths)
+			if err != nil {
+				continue
+			}
+
+			var value float64
+			for _, item := range index.SearchIntersect(searchRect) {
+				zone := item.(*rasterZone)
+				if util.PointInPolygon(zone.polygon, orb.Point{lon, lat}) {
+					value += zone.value
+				}
+			}
+			values[y*width+x] = value
+			maxAbs = math.Max(maxAbs, math.Abs(value))
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, value := range values {
+		img.Set(i%width, i/width, divergingColor(value, maxAbs))
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create raster file: %w", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode raster: %w", err)
+	}
+
+	log.Printf("Exported %dx%d %s effect raster to %s (max |value| %.2f)", width, height, param, outputFile, maxAbs)
+	return nil
+}
+
+// RasterSize returns the raster dimensions for the bounds with resolution pixels along the longer side
+func RasterSize(bounds orb.Bound, resolution int) (int, int) {
+	lonSpan := bounds.Max[0] - bounds.Min[0]
+	latSpan := bounds.Max[1] - bounds.Min[1]
+	if resolution <= 0 || lonSpan <= 0 || latSpan <= 0 {
+		return 0, 0
+	}
+
+	if lonSpan >= latSpan {
+		return resolution, max(1, int(math.Round(float64(resolution)*latSpan/lonSpan)))
+	}
+	return max(1, int(math.Round(float64(resolution)*lonSpan/latSpan))), resolution
+}
+
+// divergingColor maps a signed value to blue (negative) or red (positive) with strength scaled by maxAbs
+// Zero values are transparent
+func divergingColor(value, maxAbs float64) color.NRGBA {
+	if value == 0 || maxAbs == 0 {
+		return color.NRGBA{}
+	}
+
+	// Square root keeps weak effects visible next to very strong ones
+	t := math.Sqrt(math.Min(math.Abs(value)/maxAbs, 1))
+	alpha := uint8(80 + 175*t)
+	if value > 0 {
+		// Light orange (#ffcc80) to bright red (#ff1744)
+		return color.NRGBA{R: 255, G: uint8(204 + (23-204)*t), B: uint8(128 + (68-128)*t), A: alpha}
+	}
+	// Light blue (#90caf9) to bright blue (#2979ff)
+	return color.NRGBA{R: uint8(144 + (41-144)*t), G: uint8(202 + (121-202)*t), B: 255, A: alpha}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+)
+
+// readPNG decodes a PNG file written by a test
+func readPNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestRasterSizeKeepsAspectRatio(t *testing.T) {
+	cases := []struct {
+		bounds        orb.Bound
+		width, height int
+	}{
+		{orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-98, 41}}, 200, 100},
+		{orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-99, 44}}, 50, 200},
+		{orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-90, 40.001}}, 200, 1},
+		{orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-100, 41}}, 0, 0},
+	}
+	for _, c := range cases {
+		if width, height := RasterSize(c.bounds, 200); width != c.width || height != c.height {
+			t.Errorf("RasterSize(%v) = %dx%d, want %dx%d", c.bounds, width, height, c.width, c.height)
+		}
+	}
+}
+
+func TestExportEffectRasterBrightZone(t *testing.T) {
+	health := func(value float32) []model.ZoneEffect {
+		return []model.ZoneEffect{{ResourceType: model.TargetParamTypeHealth, Value: value}}
+	}
+	strong := rectZone("strong", 40, -100, 41, -99)
+	strong.Effects = health(10)
+	weak := rectZone("weak", 40, -99, 41, -98)
+	weak.Effects = health(1)
+
+	path := filepath.Join(t.TempDir(), "raster.png")
+	bounds := orb.Bound{Min: orb.Point{-100, 40}, Max: orb.Point{-96, 42}}
+	if err := ExportEffectRaster([]*model.Zone{strong, weak}, model.TargetParamTypeHealth, bounds, 40, path); err != nil {
+		t.Fatal(err)
+	}
+
+	img := readPNG(t, path)
+	if size := img.Bounds().Size(); size.X != 40 || size.Y != 20 {
+		t.Fatalf("raster is %dx%d, want 40x20", size.X, size.Y)
+	}
+
+	// Row 0 is the northern edge, the zones fill the bottom half of the western half
+	_, _, _, strongAlpha := img.At(5, 15).RGBA()
+	_, _, _, weakAlpha := img.At(15, 15).RGBA()
+	_, _, _, emptyAlpha := img.At(5, 5).RGBA()
+	if strongAlpha != 0xffff {
+		t.Fatalf("strongest zone alpha = %#x, want fully opaque", strongAlpha)
+	}
+	if weakAlpha == 0 || weakAlpha >= strongAlpha {
+		t.Fatalf("weak zone alpha = %#x, want visible but fainter than %#x", weakAlpha, strongAlpha)
+	}
+	if emptyAlpha != 0 {
+		t.Fatalf("pixel without zones alpha = %#x, want transparent", emptyAlpha)
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+
+	"metalink/internal/model"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/paulmach/orb"
+)
+
+// adjacencyEpsilonDegrees is how far apart (in degrees, about 0.1 mm) two edges may be and still count as shared
+const adjacencyEpsilonDegrees = 1e-9
+
+// adjacencySpatial indexes a zone by its corner bounds
+type adjacencySpatial struct {
+	index int
+	rect  rtreego.Rect
+}
+
+// Bounds implements the rtreego.Spatial interface
+func (s *adjacencySpatial) Bounds() rtreego.Rect {
+	return s.rect
+}
+
+// BuildZoneAdjacency returns the edge neighbors of every zone by zone ID, each list sorted
+// Two zones are neighbors when an edge of one overlaps an edge of the other along a positive length,
+// so zones touching only at a corner are not; a large zone can have several smaller neighbors on one edge
+func BuildZoneAdjacency(zones []*model.Zone) map[string][]string {
+	spatials := make([]rtreego.Spatial, 0, len(zones))
+	for i, zone := range zones {
+		rect, err := adjacencyRect(zone.CornersBound())
+		if err != nil {
+			log.Printf("Warning: skipping zone %s in adjacency: %v", zone.ID, err)
+			continue
+		}
+		spatials = append(spatials, &adjacencySpatial{index: i, rect: rect})
+	}
+	index := rtreego.NewTree(2, 25, 50, spatials...)
+
+	neighbors := make(map[string]map[string]struct{}, len(zones))
+	for _, zone := range zones {
+		neighbors[zone.ID] = make(map[string]struct{})
+	}
+
+	for _, item := range spatials {
+		spatial := item.(*adjacencySpatial)
+		zone := zones[spatial.index]
+		for _, candidate := range index.SearchIntersect(spatial.rect) {
+			other := zones[candidate.(*adjacencySpatial).index]
+			// Check each pair once
+			if other.ID <= zone.ID {
+				continue
+			}
+			if zonesShareEdge(zone, other) {
+				neighbors[zone.ID][other.ID] = struct{}{}
+				neighbors[other.ID][zone.ID] = struct{}{}
+			}
+		}
+	}
+
+	adjacency := make(map[string][]string, len(neighbors))
+	for id, ids := range neighbors {
+		list := make([]string, 0, len(ids))
+		for neighborID := range ids {
+			list = append(list, neighborID)
+		}
+		sort.Strings(list)
+		adjacency[id] = list
+	}
+	return adjacency
+}
+
+// ExportZoneAdjacencyToJSON writes the zone neighbor graph as a JSON object of zone ID -> neighbor IDs
+func ExportZoneAdjacencyToJSON(zones []*model.Zone, path string) error {
+	adjacency := BuildZoneAdjacency(zones)
+
+	// Every edge is listed from both sides
+	edges := 0
+	for _, ids := range adjacency {
+		edges += len(ids)
+	}
+
+	data, err := json.MarshalIndent(adjacency, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal zone adjacency: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write zone adjacency %q: %w", path, err)
+	}
+
+	log.Printf("Exported adjacency of %d zones (%d neighbor pairs) to %s", len(adjacency), edges/2, path)
+	return nil
+}
+
+// adjacencyRect returns the bound as an R-tree rectangle grown by the adjacency epsilon,
+// so zones sharing an edge intersect in the index
+func adjacencyRect(bound orb.Bound) (rtreego.Rect, error) {
+	return rtreego.NewRect(
+		rtreego.Point{bound.Min[0] - adjacencyEpsilonDegrees, bound.Min[1] - adjacencyEpsilonDegrees},
+		[]float64{bound.Max[0] - bound.Min[0] + 2*adjacencyEpsilonDegrees, bound.Max[1] - bound.Min[1] + 2*adjacencyEpsilonDegrees},
+	)
+}
+
+// zonesShareEdge reports whether an edge of one zone quad overlaps an edge of the other along a positive length
+func zonesShareEdge(a, b *model.Zone) bool {
+	ringA, ringB := a.CornersRing(), b.CornersRing()
+	for i := 0; i+1 < len(ringA); i++ {
+		for j := 0; j+1 < len(ringB); j++ {
+			if edgesOverlap(ringA[i], ringA[i+1], ringB[j], ringB[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// edgesOverlap reports whether segment r-s lies on the line of segment p-q and overlaps it along a positive length
+func edgesOverlap(p, q, r, s orb.Point) bool {
+	dx, dy := q[0]-p[0], q[1]-p[1]
+	length := math.Hypot(dx, dy)
+	if length <= adjacencyEpsilonDegrees {
+		return false
+	}
+
+	// Both ends of r-s must be on the line of p-q
+	distanceFromLine := func(pt orb.Point) float64 {
+		return math.Abs(dx*(pt[1]-p[1])-dy*(pt[0]-p[0])) / length
+	}
+	if distanceFromLine(r) > adjacencyEpsilonDegrees || distanceFromLine(s) > adjacencyEpsilonDegrees {
+		return false
+	}
+
+	// Positions of r and s along p-q, with p at 0 and q at length
+	along := func(pt orb.Point) float64 {
+		return (dx*(pt[0]-p[0]) + dy*(pt[1]-p[1])) / length
+	}
+	start, end := along(r), along(s)
+	if start > end {
+		start, end = end, start
+	}
+	overlap := math.Min(end, length) - math.Max(start, 0)
+	return overlap > adjacencyEpsilonDegrees
+}
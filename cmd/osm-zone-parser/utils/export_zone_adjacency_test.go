@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// gridOf2x2 returns four 0.01° zones sharing exact edges, named by their compass corner
+func gridOf2x2() []*model.Zone {
+	return []*model.Zone{
+		rectZone("nw", 40.01, -100, 40.02, -99.99),
+		rectZone("ne", 40.01, -99.99, 40.02, -99.98),
+		rectZone("sw", 40, -100, 40.01, -99.99),
+		rectZone("se", 40, -99.99, 40.01, -99.98),
+	}
+}
+
+func TestBuildZoneAdjacency2x2Grid(t *testing.T) {
+	want := map[string][]string{
+		"nw": {"ne", "sw"},
+		"ne": {"nw", "se"},
+		"sw": {"nw", "se"},
+		"se": {"ne", "sw"},
+	}
+	// Diagonal zones touch only at the center corner and aren't neighbors
+	if got := BuildZoneAdjacency(gridOf2x2()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("adjacency = %v, want %v", got, want)
+	}
+}
+
+func TestBuildZoneAdjacencyLargeZoneAndIsolatedZone(t *testing.T) {
+	zones := append(gridOf2x2(),
+		// Spans the eastern edge of both ne and se
+		rectZone("tall", 40, -99.98, 40.02, -99.97),
+		// Far from the others
+		rectZone("island", 41, -100, 41.01, -99.99),
+	)
+
+	adjacency := BuildZoneAdjacency(zones)
+	if got, want := adjacency["tall"], []string{"ne", "se"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("neighbors of tall = %v, want %v", got, want)
+	}
+	if got, want := adjacency["ne"], []string{"nw", "se", "tall"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("neighbors of ne = %v, want %v", got, want)
+	}
+	if got, ok := adjacency["island"]; !ok || len(got) != 0 {
+		t.Fatalf("neighbors of island = %v (listed %v), want an empty list", got, ok)
+	}
+}
+
+func TestExportZoneAdjacencyToJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adjacency.json")
+	if err := ExportZoneAdjacencyToJSON(gridOf2x2(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string][]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := BuildZoneAdjacency(gridOf2x2()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("exported adjacency = %v, want %v", got, want)
+	}
+}
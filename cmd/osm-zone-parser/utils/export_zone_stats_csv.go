@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"metalink/internal/model"
+)
+
+// zoneStatsCSVColumns are the fixed leading columns of the zone stats CSV
+var zoneStatsCSVColumns = []string{
+	"id", "name", "total_count", "total_area",
+	"single_floor_count", "single_floor_area",
+	"low_rise_count", "low_rise_area",
+	"high_rise_count", "high_rise_area",
+	"skyscraper_count", "skyscraper_area",
+}
+
+// ExportZoneStatsToCSV writes one row of building statistics per zone
+// Besides the fixed columns there is one area_<category> column per game category found in any zone,
+// sorted by name so the header doesn't depend on the zone order. Areas are in square meters
+func ExportZoneStatsToCSV(zones []*model.Zone, path string) error {
+	categories := zoneStatsCategories(zones)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create zone stats CSV %q: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := append([]string{}, zoneStatsCSVColumns...)
+	for _, category := range categories {
+		header = append(header, "area_"+category)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write zone stats CSV header: %w", err)
+	}
+
+	for _, zone := range zones {
+		stats := zone.Buildings
+		row := []string{
+			zone.ID, zone.Name,
+			strconv.Itoa(stats.TotalCount), formatCSVFloat(stats.TotalArea),
+			strconv.Itoa(stats.SingleFloorCount), formatCSVFloat(stats.SingleFloorTotalArea),
+			strconv.Itoa(stats.LowRiseCount), formatCSVFloat(stats.LowRiseTotalArea),
+			strconv.Itoa(stats.HighRiseCount), formatCSVFloat(stats.HighRiseTotalArea),
+			strconv.Itoa(stats.SkyscraperCount), formatCSVFloat(stats.SkyscraperTotalArea),
+		}
+		// Missing categories (or a nil map) are written as 0
+		for _, category := range categories {
+			row = append(row, formatCSVFloat(stats.BuildingAreas[category]))
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write zone stats CSV row of zone %s: %w", zone.ID, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write zone stats CSV %q: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close zone stats CSV %q: %w", path, err)
+	}
+
+	log.Printf("Exported building stats of %d zones (%d categories) to %s", len(zones), len(categories), path)
+	return nil
+}
+
+// zoneStatsCategories returns the sorted union of the game categories with an area in any zone
+func zoneStatsCategories(zones []*model.Zone) []string {
+	seen := make(map[string]struct{})
+	for _, zone := range zones {
+		for category := range zone.Buildings.BuildingAreas {
+			seen[category] = struct{}{}
+		}
+	}
+
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// formatCSVFloat formats a float with the fewest digits that keep its value
+func formatCSVFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// readCSV returns the records of a CSV file
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestExportZoneStatsCSVHeaderIsStable(t *testing.T) {
+	a := rectZone("a", 40, -100, 40.1, -99.9)
+	a.Buildings = model.BuildingStats{
+		TotalCount:    2,
+		TotalArea:     300,
+		BuildingAreas: map[string]float64{"residential": 200, "food_services": 100},
+	}
+	b := rectZone("b", 40, -99.9, 40.1, -99.8)
+	b.Buildings = model.BuildingStats{
+		TotalCount:    1,
+		TotalArea:     50.5,
+		BuildingAreas: map[string]float64{"education": 50.5},
+	}
+	// Zone without buildings, its maps are nil
+	empty := rectZone("empty", 40, -99.8, 40.1, -99.7)
+
+	wantHeader := append(slices.Clone(zoneStatsCSVColumns), "area_education", "area_food_services", "area_residential")
+
+	dir := t.TempDir()
+	orders := [][]*model.Zone{{a, b, empty}, {empty, b, a}, {b, empty, a}}
+	for i, zones := range orders {
+		path := filepath.Join(dir, "stats.csv")
+		if err := ExportZoneStatsToCSV(zones, path); err != nil {
+			t.Fatal(err)
+		}
+
+		records := readCSV(t, path)
+		if !slices.Equal(records[0], wantHeader) {
+			t.Fatalf("order %d: header = %v, want %v", i, records[0], wantHeader)
+		}
+		if len(records) != len(zones)+1 {
+			t.Fatalf("order %d: %d rows, want %d", i, len(records)-1, len(zones))
+		}
+		for _, row := range records[1:] {
+			if len(row) != len(wantHeader) {
+				t.Fatalf("order %d: row of zone %s has %d columns, want %d", i, row[0], len(row), len(wantHeader))
+			}
+		}
+	}
+
+	// Rows of the last export, in zone order b, empty, a
+	records := readCSV(t, filepath.Join(dir, "stats.csv"))
+	categories := len(zoneStatsCSVColumns)
+	want := map[string][]string{
+		"b":     {"50.5", "0", "0"},
+		"empty": {"0", "0", "0"},
+		"a":     {"0", "100", "200"},
+	}
+	for _, row := range records[1:] {
+		if got := row[categories:]; !slices.Equal(got, want[row[0]]) {
+			t.Errorf("category areas of zone %s = %v, want %v", row[0], got, want[row[0]])
+		}
+	}
+	if total := records[1][3]; total != "50.5" {
+		t.Errorf("total_area of zone b = %s, want 50.5", total)
+	}
+}
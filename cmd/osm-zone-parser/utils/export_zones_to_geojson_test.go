@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	zonegeojson "metalink/internal/geojson"
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// gridZones returns count 0.01° zones laid out in rows of 500, with building areas for the color scale,
+// in a shuffled order
+func gridZones(count int) []*model.Zone {
+	rng := rand.New(rand.NewSource(1))
+	zones := make([]*model.Zone, count)
+	for i := range zones {
+		lat, lon := 40+float64(i/500)*0.01, -100+float64(i%500)*0.01
+		zone := rectZone(fmt.Sprintf("zone-%06d", i), lat, lon, lat+0.01, lon+0.01)
+		zone.Name = zone.ID
+		zone.Buildings.TotalCount = i % 7
+		zone.Buildings.TotalArea = float64(rng.Intn(100000))
+		zones[i] = zone
+	}
+	rng.Shuffle(len(zones), func(i, j int) { zones[i], zones[j] = zones[j], zones[i] })
+	return zones
+}
+
+// serialZoneFeatures is the serial reference of buildZoneFeatures
+func serialZoneFeatures(zones []*model.Zone, boundary orb.Polygon, minBuildingArea, maxBuildingArea float64) []*geojson.Feature {
+	sorted := append([]*model.Zone{}, zones...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	var features []*geojson.Feature
+	for _, zone := range sorted {
+		topLeftPoint, topRightPoint, bottomRightPoint, bottomLeftPoint := zone.Corners()
+		if !util.PointInPolygon(boundary, topLeftPoint) &&
+			!util.PointInPolygon(boundary, topRightPoint) &&
+			!util.PointInPolygon(boundary, bottomLeftPoint) &&
+			!util.PointInPolygon(boundary, bottomRightPoint) {
+			continue
+		}
+		feature := zonegeojson.ZoneFeature(zone, true)
+		zonegeojson.StyleZoneFeature(feature, zone.Buildings.TotalArea, minBuildingArea, maxBuildingArea)
+		features = append(features, feature)
+	}
+	return features
+}
+
+func TestBuildZoneFeaturesMatchesSerialExport(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	zones := gridZones(2000)
+	minBuildingArea, maxBuildingArea := zonegeojson.BuildingAreaRange(zones)
+	// The boundary leaves out the zones of the last rows
+	boundary := orb.Polygon{orb.Ring{{-100, 40}, {-95, 40}, {-95, 40.025}, {-100, 40.025}, {-100, 40}}}
+
+	want, err := json.Marshal(serialZoneFeatures(zones, boundary, minBuildingArea, maxBuildingArea))
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := buildZoneFeatures(zones, boundary, true, true, minBuildingArea, maxBuildingArea, nil)
+	got, err := json.Marshal(features)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(features) == 0 || len(features) == len(zones) {
+		t.Fatalf("%d of %d zones kept, want the boundary to drop some", len(features), len(zones))
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("parallel features differ from the serial export")
+	}
+}
+
+func TestExportZonesToGeoJSONIsByteIdentical(t *testing.T) {
+	zones := gridZones(1000)
+	reversed := make([]*model.Zone, len(zones))
+	for i, zone := range zones {
+		reversed[len(zones)-1-i] = zone
+	}
+
+	dir := t.TempDir()
+	var outputs [][]byte
+	for i, run := range []struct {
+		zones []*model.Zone
+		procs int
+	}{{zones, 1}, {zones, 4}, {reversed, 3}} {
+		path := filepath.Join(dir, fmt.Sprintf("zones-%d.geojson", i))
+		previous := runtime.GOMAXPROCS(run.procs)
+		err := ExportZonesToGeoJSON(run.zones, path, true, true)
+		runtime.GOMAXPROCS(previous)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+
+	for i := 1; i < len(outputs); i++ {
+		if !bytes.Equal(outputs[i], outputs[0]) {
+			t.Fatalf("export %d differs from the serial export", i)
+		}
+	}
+}
+
+func benchmarkExportZonesToGeoJSON(b *testing.B, procs int) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+	zones := gridZones(200000)
+	path := filepath.Join(b.TempDir(), "zones.geojson")
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if err := ExportZonesToGeoJSON(zones, path, false, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExportZonesToGeoJSONSerial(b *testing.B) {
+	benchmarkExportZonesToGeoJSON(b, 1)
+}
+
+func BenchmarkExportZonesToGeoJSONParallel(b *testing.B) {
+	benchmarkExportZonesToGeoJSON(b, runtime.NumCPU())
+}
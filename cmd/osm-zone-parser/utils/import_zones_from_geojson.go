@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/google/uuid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// areaUnits lists the units an exported area property may be suffixed with
+var areaUnits = []util.AreaUnit{util.AreaUnitM2, util.AreaUnitKM2, util.AreaUnitAcres, util.AreaUnitHectares}
+
+// ImportZonesFromGeoJSON reads zones from a GeoJSON FeatureCollection as written by ExportZonesToGeoJSON
+// Corner marker points are skipped. Non-rectangular polygons keep their full shape in Zone.Geometry
+func ImportZonesFromGeoJSON(path string) ([]*model.Zone, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON file: %w", err)
+	}
+
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON file: %w", err)
+	}
+
+	zones := make([]*model.Zone, 0, len(fc.Features))
+	for i, feature := range fc.Features {
+		var polygon orb.Polygon
+		switch g := feature.Geometry.(type) {
+		case orb.Point:
+			// Corner markers of the parent region
+			continue
+		case orb.Polygon:
+			polygon = g
+		default:
+			return nil, fmt.Errorf("feature %d: expected Polygon geometry, got %T", i, feature.Geometry)
+		}
+
+		if len(polygon) == 0 || len(polygon[0]) < 4 {
+			return nil, fmt.Errorf("feature %d: polygon has no valid outer ring", i)
+		}
+
+		zone, err := zoneFromFeature(polygon, feature.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		zones = append(zones, zone)
+	}
+
+	log.Printf("Read %d zones from %s", len(zones), path)
+	return zones, nil
+}
+
+// zoneFromFeature creates a zone from a polygon and the exported feature properties
+func zoneFromFeature(polygon orb.Polygon, props geojson.Properties) (*model.Zone, error) {
+	id := props.MustString("id", "")
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	bound := polygon.Bound()
+	zone := &model.Zone{
+		ID:                id,
+		Name:              props.MustString("name", fmt.Sprintf("Zone %s", id)),
+		TopLeftLatLon:     []float64{bound.Max[1], bound.Min[0]},
+		TopRightLatLon:    []float64{bound.Max[1], bound.Max[0]},
+		BottomLeftLatLon:  []float64{bound.Min[1], bound.Min[0]},
+		BottomRightLatLon: []float64{bound.Min[1], bound.Max[0]},
+		Buildings: model.BuildingStats{
+			BuildingTypes: make(map[string]int),
+			BuildingAreas: make(map[string]float64),
+		},
+	}
+
+	// Corners only describe the bounding box, keep the exact shape for anything else
+	if !isBoundRectangle(polygon, bound) {
+		geometry, err := geojson.NewGeometry(polygon).MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal zone geometry: %w", err)
+		}
+		zone.Geometry = string(geometry)
+	}
+
+	if err := readBuildingStats(props, &zone.Buildings); err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+// isBoundRectangle reports whether the polygon is exactly its bounding box
+func isBoundRectangle(polygon orb.Polygon, bound orb.Bound) bool {
+	if len(polygon) != 1 || len(polygon[0]) != 5 {
+		return false
+	}
+	for _, p := range polygon[0] {
+		if (p[0] != bound.Min[0] && p[0] != bound.Max[0]) || (p[1] != bound.Min[1] && p[1] != bound.Max[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// readBuildingStats fills building counts and areas (converted back to square meters) from feature properties
+func readBuildingStats(props geojson.Properties, stats *model.BuildingStats) error {
+	stats.TotalCount = props.MustInt("total_buildings", 0)
+	stats.TotalArea, _ = areaPropertyValue(props, "total_area")
+
+	// Height-based stats
+	stats.SingleFloorCount = props.MustInt("single_floor_count", 0)
+	stats.SingleFloorTotalArea, _ = areaPropertyValue(props, "single_floor_area")
+	stats.LowRiseCount = props.MustInt("low_rise_count", 0)
+	stats.LowRiseTotalArea, _ = areaPropertyValue(props, "low_rise_area")
+	stats.HighRiseCount = props.MustInt("high_rise_count", 0)
+	stats.HighRiseTotalArea, _ = areaPropertyValue(props, "high_rise_area")
+	stats.SkyscraperCount = props.MustInt("skyscraper_count", 0)
+	stats.SkyscraperTotalArea, _ = areaPropertyValue(props, "skyscraper_area")
+
+	if raw, ok := props["building_types"]; ok {
+		types, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("property \"building_types\" must be an object, got %T", raw)
+		}
+		for category, v := range types {
+			count, ok := v.(float64)
+			if !ok {
+				return fmt.Errorf("building_types[%q] must be a number, got %T", category, v)
+			}
+			stats.BuildingTypes[category] = int(count)
+		}
+	}
+
+	for _, unit := range areaUnits {
+		key := "building_areas_" + string(unit)
+		raw, ok := props[key]
+		if !ok {
+			continue
+		}
+		areas, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("property %q must be an object, got %T", key, raw)
+		}
+		for category, v := range areas {
+			area, ok := v.(float64)
+			if !ok {
+				return fmt.Errorf("%s[%q] must be a number, got %T", key, category, v)
+			}
+			stats.BuildingAreas[category] = util.AreaToSquareMeters(area, unit)
+		}
+		break
+	}
+
+	return nil
+}
+
+// areaPropertyValue finds an area property exported in any unit and returns it in square meters
+func areaPropertyValue(props geojson.Properties, name string) (float64, bool) {
+	for _, unit := range areaUnits {
+		if v, ok := props[name+"_"+string(unit)].(float64); ok {
+			return util.AreaToSquareMeters(v, unit), true
+		}
+	}
+	return 0, false
+}
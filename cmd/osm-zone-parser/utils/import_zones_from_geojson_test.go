@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	zonegeojson "metalink/internal/geojson"
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+func TestImportZonesFromGeoJSONRoundTripsSmallAreas(t *testing.T) {
+	previous := zonegeojson.AreaUnit
+	zonegeojson.AreaUnit = util.AreaUnitKM2
+	t.Cleanup(func() { zonegeojson.AreaUnit = previous })
+
+	zone := rectZone("zone-1", 40, -100, 40.01, -99.99)
+	zone.Buildings = model.BuildingStats{
+		TotalCount:    2,
+		TotalArea:     1512.5,
+		BuildingTypes: map[string]int{"residential": 1, "retail": 1},
+		BuildingAreas: map[string]float64{"residential": 1500.5, "retail": 12},
+	}
+
+	fc := geojson.NewFeatureCollection()
+	fc.Append(zonegeojson.ZoneFeature(zone, true))
+	data, err := fc.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "zones.geojson")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := ImportZonesFromGeoJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones[0].ID != "zone-1" {
+		t.Fatalf("imported %d zones, want zone-1", len(zones))
+	}
+
+	imported := zones[0].Buildings
+	if math.Abs(imported.TotalArea-1512.5) > 1e-6 {
+		t.Fatalf("total area = %v m², want 1512.5", imported.TotalArea)
+	}
+	for category, want := range zone.Buildings.BuildingAreas {
+		if got := imported.BuildingAreas[category]; math.Abs(got-want) > 1e-6 {
+			t.Fatalf("%s area = %v m², want %v", category, got, want)
+		}
+	}
+	if imported.BuildingTypes["retail"] != 1 {
+		t.Fatalf("building types = %v", imported.BuildingTypes)
+	}
+}
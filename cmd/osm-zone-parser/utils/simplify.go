@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"log"
+	"sync"
+
+	"metalink/internal/util"
+	"metalink/internal/util/geoproj"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/simplify"
+)
+
+// SimplifyToleranceMeters is the Douglas-Peucker tolerance applied to exported zone outlines (0 = disabled)
+// Only zones with a stored geometry have vertices to drop, corner quads and building squares are exported as is
+var SimplifyToleranceMeters = 0.0
+
+// geometrySimplifier simplifies exported geometries and tracks vertex reduction
+// Safe for concurrent use
+type geometrySimplifier struct {
+	toleranceMeters float64
+	simplifier      *simplify.DouglasPeuckerSimplifier // For line geometries
+
+	mu             sync.Mutex // Guards the counters
+	geometries     int
+	verticesBefore int
+	verticesAfter  int
+}
+
+// newGeometrySimplifier creates a simplifier for the configured tolerance, or nil if disabled
+func newGeometrySimplifier() *geometrySimplifier {
+	if SimplifyToleranceMeters <= 0 {
+		return nil
+	}
+
+	// Line geometries are simplified in degrees, using the length of a degree of latitude
+	return &geometrySimplifier{
+		toleranceMeters: SimplifyToleranceMeters,
+		simplifier:      simplify.DouglasPeucker(geoproj.MetersToDegreesLat(SimplifyToleranceMeters, 0)),
+	}
+}
+
+// Simplify returns the simplified geometry and records vertex counts
+// Polygon rings are simplified with util.SimplifyRing, so they stay closed and valid
+func (s *geometrySimplifier) Simplify(g orb.Geometry) orb.Geometry {
+	if s == nil {
+		return g
+	}
+
+	before := countVertices(g)
+	var simplified orb.Geometry
+	switch geom := g.(type) {
+	case orb.Ring:
+		simplified = util.SimplifyRing(geom, s.toleranceMeters)
+	case orb.Polygon:
+		simplified = s.simplifyPolygon(geom)
+	case orb.MultiPolygon:
+		multiPolygon := make(orb.MultiPolygon, len(geom))
+		for i, polygon := range geom {
+			multiPolygon[i] = s.simplifyPolygon(polygon)
+		}
+		simplified = multiPolygon
+	default:
+		simplified = s.simplifier.Simplify(orb.Clone(g))
+	}
+	after := countVertices(simplified)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.geometries++
+	s.verticesBefore += before
+	s.verticesAfter += after
+
+	return simplified
+}
+
+// simplifyPolygon simplifies every ring of a polygon into a new polygon
+func (s *geometrySimplifier) simplifyPolygon(polygon orb.Polygon) orb.Polygon {
+	simplified := make(orb.Polygon, len(polygon))
+	for i, ring := range polygon {
+		simplified[i] = util.SimplifyRing(ring, s.toleranceMeters)
+	}
+	return simplified
+}
+
+// LogStats logs the average vertex reduction
+func (s *geometrySimplifier) LogStats() {
+	if s == nil || s.geometries == 0 {
+		return
+	}
+
+	reduction := 0.0
+	if s.verticesBefore > 0 {
+		reduction = float64(s.verticesBefore-s.verticesAfter) / float64(s.verticesBefore) * 100
+	}
+
+	log.Printf("Simplified %d geometries: %.1f -> %.1f vertices on average (%.1f%% reduction)",
+		s.geometries,
+		float64(s.verticesBefore)/float64(s.geometries),
+		float64(s.verticesAfter)/float64(s.geometries),
+		reduction)
+}
+
+// countVertices counts the vertices of polygon and line geometries
+func countVertices(g orb.Geometry) int {
+	switch geom := g.(type) {
+	case orb.Point:
+		return 1
+	case orb.LineString:
+		return len(geom)
+	case orb.Ring:
+		return len(geom)
+	case orb.Polygon:
+		count := 0
+		for _, ring := range geom {
+			count += len(ring)
+		}
+		return count
+	case orb.MultiPolygon:
+		count := 0
+		for _, polygon := range geom {
+			count += countVertices(polygon)
+		}
+		return count
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// circleZone returns a zone whose stored geometry is a jittered circle of n vertices around (lat, lon)
+func circleZone(t *testing.T, id string, lat, lon float64, n int) *model.Zone {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	ring := make(orb.Ring, 0, n+1)
+	for i := 0; i < n; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		radius := 0.01 * (1 + 0.001*rng.Float64())
+		ring = append(ring, orb.Point{lon + radius*math.Cos(angle), lat + radius*math.Sin(angle)})
+	}
+	ring = append(ring, ring[0])
+
+	geometry, err := geojson.NewGeometry(orb.Polygon{ring}).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone := rectZone(id, lat-0.0101, lon-0.0101, lat+0.0101, lon+0.0101)
+	zone.Geometry = string(geometry)
+	return zone
+}
+
+// exportedRing returns the outer ring of the single feature exported for the zone
+func exportedRing(t *testing.T, zone *model.Zone, simplifier *geometrySimplifier) orb.Ring {
+	t.Helper()
+	boundary := orb.Polygon{orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}.ToRing()}
+	features := buildZoneFeatures([]*model.Zone{zone}, boundary, false, false, 0, 0, simplifier)
+	if len(features) != 1 {
+		t.Fatalf("got %d features, want 1", len(features))
+	}
+	polygon, ok := features[0].Geometry.(orb.Polygon)
+	if !ok {
+		t.Fatalf("geometry is %T, want a polygon", features[0].Geometry)
+	}
+	return polygon[0]
+}
+
+func TestZoneExportSimplifiesStoredGeometry(t *testing.T) {
+	zone := circleZone(t, "circle", 40, -100, 500)
+
+	if ring := exportedRing(t, zone, nil); len(ring) != 501 {
+		t.Fatalf("unsimplified export has %d positions, want the 501 of the stored geometry", len(ring))
+	}
+
+	simplifier := &geometrySimplifier{toleranceMeters: 5}
+	ring := exportedRing(t, zone, simplifier)
+	if len(ring) >= 501 || len(ring) < 4 {
+		t.Fatalf("simplified export has %d positions, want fewer than 501 and at least 4", len(ring))
+	}
+	if !ring.Closed() {
+		t.Fatal("simplified ring is not closed")
+	}
+	if simplifier.verticesBefore != 501 || simplifier.verticesAfter != len(ring) {
+		t.Fatalf("stats %d -> %d, want 501 -> %d", simplifier.verticesBefore, simplifier.verticesAfter, len(ring))
+	}
+}
+
+func TestZoneExportKeepsCornerQuads(t *testing.T) {
+	zone := rectZone("rect", 40, -100, 40.01, -99.99)
+	if ring := exportedRing(t, zone, &geometrySimplifier{toleranceMeters: 5000}); len(ring) != 5 {
+		t.Fatalf("corner quad exported with %d positions, want 5", len(ring))
+	}
+}
@@ -4,12 +4,44 @@ import (
 	"math"
 
 	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/util"
 
 	"github.com/paulmach/orb"
 )
 
-// calculateCentroid calculates the centroid of a polygon
+// CalculateCentroid calculates the area centroid of a polygon using the signed-area formula
+// Falls back to the average of vertices for degenerate (zero-area) polygons
 func CalculateCentroid(points []orb.Point) orb.Point {
+	if len(points) < 3 {
+		return calculateVertexCentroid(points)
+	}
+
+	// Use the first point as origin to reduce floating point error on large coordinates
+	origin := points[0]
+
+	var signedArea, centroidX, centroidY float64
+	for i := range points {
+		x0, y0 := points[i][0]-origin[0], points[i][1]-origin[1]
+		next := points[(i+1)%len(points)]
+		x1, y1 := next[0]-origin[0], next[1]-origin[1]
+
+		cross := x0*y1 - x1*y0
+		signedArea += cross
+		centroidX += (x0 + x1) * cross
+		centroidY += (y0 + y1) * cross
+	}
+
+	if math.Abs(signedArea) < 1e-18 {
+		return calculateVertexCentroid(points)
+	}
+
+	// signedArea holds twice the area, so the factor is 1/(6*A) = 1/(3*signedArea)
+	factor := 1 / (3 * signedArea)
+	return orb.Point{origin[0] + centroidX*factor, origin[1] + centroidY*factor}
+}
+
+// calculateVertexCentroid calculates the average of polygon vertices
+func calculateVertexCentroid(points []orb.Point) orb.Point {
 	var centroidX, centroidY float64
 
 	for _, p := range points {
@@ -21,26 +53,19 @@ func CalculateCentroid(points []orb.Point) orb.Point {
 	return orb.Point{centroidX / n, centroidY / n}
 }
 
-// metersToDegrees converts a distance in meters to degrees at a given latitude
+// MetersToDegrees converts a distance in meters to degrees at a given latitude
 func MetersToDegrees(meters float64, latitude float64) float64 {
-	// Earth's radius in meters
-	earthRadius := 6371000.0
-
-	// Convert to radians
-	latRad := latitude * math.Pi / 180.0
-
-	// For longitude: depends on latitude
-	metersPerDegree := earthRadius * math.Pi / 180.0 * math.Cos(latRad)
-
-	return meters / metersPerDegree
+	return util.MetersToDegrees(meters, latitude)
 }
 
-// CalculateBuildingInfluenceRadius calculates the radius of influence for a building
-// Returns radius in meters, capped at 1000m (1km)
-func CalculateBuildingInfluenceRadius(buildingArea float64, extraRadiusKf float64) float64 {
+// CalculateBuildingInfluenceRadius calculates the radius of influence for a building of a type config
+// Returns radius in meters, capped at the max influence radius of the config (1km by default)
+// A nil typeConfig uses the default radius coefficient and the global cap
+func CalculateBuildingInfluenceRadius(buildingArea float64, typeConfig *mappers.BuildingTypeConfig) float64 {
 	// If extraRadiusKf is 0 or negative, use a default small radius
-	if extraRadiusKf <= 0 {
-		extraRadiusKf = 1
+	extraRadiusKf := 1.0
+	if typeConfig != nil && typeConfig.ExtraRadiusKf > 0 {
+		extraRadiusKf = typeConfig.ExtraRadiusKf
 	}
 
 	// Calculate radius: base_radius + sqrt(area) * extra_radius_kf * base_area_kf
@@ -48,16 +73,6 @@ func CalculateBuildingInfluenceRadius(buildingArea float64, extraRadiusKf float6
 	radius := mappers.GetBuildingBaseRadius() +
 		math.Sqrt(buildingArea)*extraRadiusKf*mappers.GetBaseAreaKf()
 
-	// Cap at 1km for very large buildings
-	if radius > 1000.0 {
-		radius = 1000.0
-	}
-
-	return radius
-}
-
-// RoundToKilometers converts meters to kilometers and rounds to 2 decimal places
-func RoundToKilometers(meters float64) float64 {
-	km := meters / 1000.0
-	return math.Round(km*100) / 100
+	// Cap for very large buildings
+	return min(radius, mappers.GetMaxInfluenceRadius(typeConfig))
 }
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+)
+
+// TestMain loads the effects config once for the whole test binary, before any
+// helper caches the missing default path
+func TestMain(m *testing.M) {
+	config, err := mappers.LoadBuildingEffectsConfig("../../../usa_buildings_data/building_cat_kf_config.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mappers.SetBuildingEffectsConfig(config)
+	if err := mappers.InitBuildingEffectsConfig(); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestBuildingInfluenceRadiusCap(t *testing.T) {
+	huge := 1e12 // m², far past any cap
+
+	if radius := CalculateBuildingInfluenceRadius(huge, nil); radius != mappers.DefaultMaxInfluenceRadius {
+		t.Fatalf("radius without a type config = %v, want the default cap %v", radius, mappers.DefaultMaxInfluenceRadius)
+	}
+	for _, limit := range []float64{50, 3000} {
+		typeConfig := &mappers.BuildingTypeConfig{MaxInfluenceRadius: limit}
+		if radius := CalculateBuildingInfluenceRadius(huge, typeConfig); radius != limit {
+			t.Fatalf("radius with a %v m cap = %v", limit, radius)
+		}
+	}
+
+	// Below the cap the radius grows with the building area
+	typeConfig := &mappers.BuildingTypeConfig{MaxInfluenceRadius: 1e9}
+	small := CalculateBuildingInfluenceRadius(100, typeConfig)
+	large := CalculateBuildingInfluenceRadius(10000, typeConfig)
+	if small <= 0 || large <= small || large >= 3000 {
+		t.Fatalf("radii %v and %v for 100 and 10000 m², want growing values under 3000", small, large)
+	}
+	if capped := CalculateBuildingInfluenceRadius(10000, &mappers.BuildingTypeConfig{MaxInfluenceRadius: 3000}); capped != large {
+		t.Fatalf("radius under a 3000 m cap = %v, want the uncapped %v", capped, large)
+	}
+}
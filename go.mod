@@ -7,10 +7,12 @@ require (
 	github.com/gin-gonic/gin v1.10.0
 	github.com/golang/geo v0.0.0-20250410091149-9ff723126794
 	github.com/google/uuid v1.6.0
+	github.com/jackc/pgx/v5 v5.7.3
 	github.com/paulmach/orb v0.11.1
 	github.com/qedus/osmpbf v1.2.0
 	github.com/redis/go-redis/v9 v9.7.3
 	github.com/spf13/viper v1.20.0
+	google.golang.org/protobuf v1.36.5
 	gorm.io/driver/postgres v1.5.11
 	gorm.io/gorm v1.25.12
 )
@@ -31,7 +33,6 @@ require (
 	github.com/goccy/go-json v0.10.5 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	github.com/jackc/pgx/v5 v5.7.3 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
@@ -59,6 +60,5 @@ require (
 	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
-	google.golang.org/protobuf v1.36.5 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
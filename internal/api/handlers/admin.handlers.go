@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"net/http"
+
+	"metalink/internal/service/target"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminHandlers registers the admin and monitoring endpoints
+func SetupAdminHandlers(router *gin.RouterGroup) {
+	adminGroup := router.Group("/admin")
+
+	adminGroup.GET("/consistency", CheckTargetConsistency)
+}
+
+// CheckTargetConsistency reports drift between in-memory, Redis and PostgreSQL targets
+func CheckTargetConsistency(c *gin.Context) {
+	report, err := target.GetTargetService().CheckConsistency(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"metalink/internal/model"
+	"metalink/internal/service/zone"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCostGridPoints limits the size of a single cost matrix request
+const maxCostGridPoints = 250000
+
+// effectCostRequest is the body of POST /effects/cost
+// The grid spans the bounds with rows x cols evenly spaced points, row 0 is the northern edge
+type effectCostRequest struct {
+	MinLat  float64            `json:"min_lat"`
+	MinLng  float64            `json:"min_lng"`
+	MaxLat  float64            `json:"max_lat"`
+	MaxLng  float64            `json:"max_lng"`
+	Rows    int                `json:"rows" binding:"required,gt=0"`
+	Cols    int                `json:"cols" binding:"required,gt=0"`
+	Weights map[string]float32 `json:"weights"` // Keyed by resource type name, omitted = all types with weight 1
+}
+
+// SetupEffectHandlers registers the effect field endpoints
+func SetupEffectHandlers(router *gin.RouterGroup) {
+	effectGroup := router.Group("/effects")
+
+	effectGroup.POST("/cost", GetEffectCostMatrix)
+}
+
+// GetEffectCostMatrix returns the effect traversal cost over a grid of points
+// Response: {"costs": [[...], ...]} with rows x cols values
+func GetEffectCostMatrix(c *gin.Context) {
+	var req effectCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := validateCostGrid(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var weights map[model.TargetParamType]float32
+	if req.Weights != nil {
+		weights = make(map[model.TargetParamType]float32, len(req.Weights))
+		for name, weight := range req.Weights {
+			paramType, err := model.ParseTargetParamType(name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"status":  "error",
+					"message": err.Error(),
+				})
+				return
+			}
+			weights[paramType] = weight
+		}
+	}
+
+	// Evaluate the whole grid in one batch, row by row from north to south
+	points := make([][2]float64, 0, req.Rows*req.Cols)
+	for row := 0; row < req.Rows; row++ {
+		lat := gridCoordinate(req.MaxLat, req.MinLat, row, req.Rows)
+		for col := 0; col < req.Cols; col++ {
+			points = append(points, [2]float64{lat, gridCoordinate(req.MinLng, req.MaxLng, col, req.Cols)})
+		}
+	}
+	flat := zone.GetZoneService().EffectCostsForPoints(points, weights)
+
+	costs := make([][]float64, req.Rows)
+	for row := range costs {
+		costs[row] = flat[row*req.Cols : (row+1)*req.Cols]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"costs": costs})
+}
+
+// validateCostGrid checks the grid bounds and size
+func validateCostGrid(req effectCostRequest) error {
+	if req.MinLat < -90 || req.MaxLat > 90 || req.MinLat > req.MaxLat {
+		return fmt.Errorf("latitudes must satisfy -90 <= min_lat <= max_lat <= 90")
+	}
+	if req.MinLng < -180 || req.MaxLng > 180 || req.MinLng > req.MaxLng {
+		return fmt.Errorf("longitudes must satisfy -180 <= min_lng <= max_lng <= 180")
+	}
+	if req.Rows > maxCostGridPoints || req.Cols > maxCostGridPoints || req.Rows*req.Cols > maxCostGridPoints {
+		return fmt.Errorf("grid has %d points, at most %d allowed", req.Rows*req.Cols, maxCostGridPoints)
+	}
+	return nil
+}
+
+// gridCoordinate returns the i-th of n evenly spaced values from start to end (start for n == 1)
+func gridCoordinate(start, end float64, i, n int) float64 {
+	if n == 1 {
+		return start
+	}
+	return start + (end-start)*float64(i)/float64(n-1)
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"metalink/internal/config"
+	"metalink/internal/postgres"
+	"metalink/internal/redis"
+	"metalink/internal/service/target"
+	"metalink/internal/service/zone"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthHandlers registers the liveness and readiness endpoints
+func SetupHealthHandlers(router *gin.RouterGroup) {
+	router.GET("/healthz", Healthz)
+	router.GET("/readyz", Readyz)
+}
+
+// Healthz reports that the process is up and serving requests
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports the init status of the target and zone services and the PostgreSQL and Redis connections
+// Returns 503 until both services are initialized
+func Readyz(c *gin.Context) {
+	targetService := target.GetTargetService()
+	zoneService := zone.GetZoneService()
+
+	ready := targetService.IsInitialized() && zoneService.IsInitialized()
+	status := http.StatusOK
+	statusText := "ready"
+	if !ready {
+		status = http.StatusServiceUnavailable
+		statusText = "not_ready"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.HealthPingTimeout)
+	defer cancel()
+
+	c.JSON(status, gin.H{
+		"status": statusText,
+		"services": gin.H{
+			"targets": gin.H{
+				"initialized": targetService.IsInitialized(),
+				"count":       targetService.Count(),
+			},
+			"zones": gin.H{
+				"initialized": zoneService.IsInitialized(),
+				"count":       zoneService.Count(),
+			},
+		},
+		"postgres": pingStatus(postgres.Ping(ctx)),
+		"redis":    pingStatus(redis.Ping(ctx)),
+	})
+}
+
+// pingStatus returns "ok" or the ping error message
+func pingStatus(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "ok"
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/service/target"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyz requests /readyz and returns the status code and decoded body
+func readyz(t *testing.T) (int, map[string]any) {
+	t.Helper()
+	router := gin.New()
+	SetupHealthHandlers(router.Group(""))
+
+	w := get(router, "/readyz")
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, body
+}
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The singletons stay initialized, so the not-ready state is only seen on the first run
+	if !target.GetTargetService().IsInitialized() {
+		code, body := readyz(t)
+		if code != http.StatusServiceUnavailable || body["status"] != "not_ready" {
+			t.Fatalf("before init: status %d, body %v, want 503 not_ready", code, body)
+		}
+	}
+
+	newTestRouter(t) // Seeds the zones
+	target.GetTargetService().LoadTargets([]*model.Target{{ID: "ready-target", State: model.TargetStateStopped}})
+
+	code, body := readyz(t)
+	if code != http.StatusOK || body["status"] != "ready" {
+		t.Fatalf("after init: status %d, body %v, want 200 ready", code, body)
+	}
+	services := body["services"].(map[string]any)
+	if targets := services["targets"].(map[string]any); targets["initialized"] != true || targets["count"].(float64) < 1 {
+		t.Fatalf("targets %v, want initialized with the loaded target", targets)
+	}
+	// PostgreSQL and Redis aren't connected in tests and are reported without failing readiness
+	if body["postgres"] == "ok" || body["redis"] == "ok" {
+		t.Fatalf("postgres %v and redis %v, want not initialized errors", body["postgres"], body["redis"])
+	}
+}
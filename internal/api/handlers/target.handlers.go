@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"metalink/internal/model"
+	"metalink/internal/service/target"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createTargetRequest is the body of POST /targets
+type createTargetRequest struct {
+	Name  string  `json:"name"`
+	Speed float32 `json:"speed" binding:"required,gt=0"` // Meters per second
+	Route string  `json:"route" binding:"required"`
+}
+
+// updateRouteRequest is the body of PUT /targets/:id/route
+type updateRouteRequest struct {
+	Route string `json:"route" binding:"required"`
+}
+
+// targetResponse is the JSON representation of a target
+type targetResponse struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Speed          float32 `json:"speed"`
+	Route          string  `json:"route,omitempty"`
+	State          int     `json:"state"`
+	NextPointIndex int     `json:"next_point_index"`
+	CurrentLat     float32 `json:"current_lat"`
+	CurrentLng     float32 `json:"current_lng"`
+	CurrentBearing float32 `json:"current_bearing"`
+}
+
+// SetupTargetHandlers registers the target management endpoints
+func SetupTargetHandlers(router *gin.RouterGroup) {
+	targetGroup := router.Group("/targets")
+
+	targetGroup.POST("", CreateTarget)
+	targetGroup.GET("/:id", GetTarget)
+	targetGroup.PUT("/:id/route", UpdateTargetRoute)
+}
+
+// CreateTarget creates a new target walking along an encoded route polyline
+func CreateTarget(c *gin.Context) {
+	var req createTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	t, err := target.GetTargetService().CreateTarget(req.Name, model.SpeedFromMs(float64(req.Speed)), req.Route)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newTargetResponse(t))
+}
+
+// GetTarget returns the current in-memory state of a target
+// The encoded route is only included with ?includeRoute=true
+func GetTarget(c *gin.Context) {
+	includeRoute := false
+	if value := c.Query("includeRoute"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "invalid includeRoute: " + value,
+			})
+			return
+		}
+		includeRoute = parsed
+	}
+
+	t, err := target.GetTargetService().GetTarget(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response := newTargetResponse(t)
+	if !includeRoute {
+		response.Route = ""
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateTargetRoute reassigns the route of an existing target
+func UpdateTargetRoute(c *gin.Context) {
+	var req updateRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	t, err := target.GetTargetService().UpdateTargetRoute(c.Param("id"), req.Route)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, target.ErrTargetNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newTargetResponse(t))
+}
+
+// newTargetResponse converts an in-memory target to its JSON representation
+func newTargetResponse(t *model.Target) targetResponse {
+	return targetResponse{
+		ID:             t.ID,
+		Name:           t.Name,
+		Speed:          float32(t.Speed),
+		Route:          t.Route,
+		State:          int(t.State),
+		NextPointIndex: t.NextPointIndex,
+		CurrentLat:     t.CurrentLat,
+		CurrentLng:     t.CurrentLng,
+		CurrentBearing: t.CurrentBearing,
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/service/target"
+	"metalink/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTargetTestRouter returns a router with the target handlers over the target service
+func newTargetTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupTargetHandlers(router.Group(""))
+	return router
+}
+
+// getTarget performs GET /targets/:id and decodes the response
+func getTarget(t *testing.T, router *gin.Engine, url string) targetResponse {
+	t.Helper()
+	w := get(router, url)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status = %d, body %s", url, w.Code, w.Body)
+	}
+	var response targetResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestGetTargetReflectsMovement(t *testing.T) {
+	route := util.EncodePolyline([][2]float64{{10, 0}, {10, 0.001}, {10, 0.002}})
+	service := target.GetTargetService()
+	service.LoadTargets([]*model.Target{{
+		ID:             "walker",
+		Name:           "walker",
+		Speed:          model.SpeedFromMs(5),
+		Route:          route,
+		State:          model.TargetStateLooping,
+		NextPointIndex: model.RouteNotStarted,
+	}})
+	router := newTargetTestRouter()
+
+	before := getTarget(t, router, "/targets/walker")
+	if before.CurrentLat != 0 || before.CurrentLng != 0 || before.NextPointIndex != model.RouteNotStarted {
+		t.Fatalf("before the tick: %+v, want the target not started", before)
+	}
+	if before.Route != "" {
+		t.Fatalf("route included without includeRoute: %q", before.Route)
+	}
+
+	// Simulated tick of the movement loop
+	service.ProcessTargets()
+
+	after := getTarget(t, router, "/targets/walker")
+	live, err := service.GetTarget("walker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.CurrentLat != live.CurrentLat || after.CurrentLng != live.CurrentLng ||
+		after.NextPointIndex != live.NextPointIndex || after.CurrentBearing != live.CurrentBearing {
+		t.Fatalf("after the tick the response is %+v, the service has %v,%v next %d bearing %v",
+			after, live.CurrentLat, live.CurrentLng, live.NextPointIndex, live.CurrentBearing)
+	}
+	if math.Abs(float64(after.CurrentLat)-10) > 0.0001 || after.NextPointIndex == model.RouteNotStarted {
+		t.Fatalf("after the tick the target is at %v,%v next %d, want it on the route",
+			after.CurrentLat, after.CurrentLng, after.NextPointIndex)
+	}
+
+	if withRoute := getTarget(t, router, "/targets/walker?includeRoute=true"); withRoute.Route != route {
+		t.Fatalf("route with includeRoute = %q, want %q", withRoute.Route, route)
+	}
+}
+
+func TestGetTargetErrors(t *testing.T) {
+	router := newTargetTestRouter()
+
+	if w := get(router, "/targets/missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("missing target: status = %d, want 404", w.Code)
+	}
+	if w := get(router, "/targets/missing?includeRoute=maybe"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid includeRoute: status = %d, want 400", w.Code)
+	}
+}
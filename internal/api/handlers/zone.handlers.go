@@ -0,0 +1,253 @@
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	zonegeojson "metalink/internal/geojson"
+	"metalink/internal/model"
+	"metalink/internal/service/zone"
+
+	"github.com/gin-gonic/gin"
+	"github.com/paulmach/orb/geojson"
+)
+
+// SetupZoneHandlers registers the zone query endpoints
+func SetupZoneHandlers(router *gin.RouterGroup) {
+	zoneGroup := router.Group("/zones")
+
+	zoneGroup.GET("", GetZonesInViewport)
+	zoneGroup.GET("/list", ListZones)
+	zoneGroup.GET("/effects", GetZoneEffects)
+}
+
+// Page sizes of the zone listing
+const (
+	defaultZoneListLimit = 100
+	maxZoneListLimit     = 1000
+)
+
+// ListZones pages through all zones sorted by ID
+// Query params: limit (default 100, max 1000), cursor from the previous page, min_buildings, category
+// The response has the zones and next_cursor (empty on the last page);
+// the X-Total-Count header is the number of zones matching the filters
+func ListZones(c *gin.Context) {
+	limit := defaultZoneListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxZoneListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": fmt.Sprintf("limit must be a number between 1 and %d", maxZoneListLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	afterID, err := decodeZoneCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid cursor",
+		})
+		return
+	}
+
+	filter := zone.ZoneFilter{Category: c.Query("category")}
+	if value := c.Query("min_buildings"); value != "" {
+		filter.MinBuildings, err = strconv.Atoi(value)
+		if err != nil || filter.MinBuildings < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "min_buildings must be a non-negative number",
+			})
+			return
+		}
+	}
+
+	zones, nextID, total := zone.GetZoneService().ListZones(afterID, limit, filter)
+
+	items := make([]gin.H, 0, len(zones))
+	for _, z := range zones {
+		items = append(items, gin.H{
+			"id":             z.ID,
+			"name":           z.Name,
+			"top_left":       z.TopLeftLatLon,
+			"top_right":      z.TopRightLatLon,
+			"bottom_right":   z.BottomRightLatLon,
+			"bottom_left":    z.BottomLeftLatLon,
+			"building_count": z.Buildings.TotalCount,
+			"building_area":  z.Buildings.TotalArea,
+			"building_types": z.Buildings.BuildingTypes,
+			"effects":        zoneNamedEffects(z),
+		})
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"zones":       items,
+		"next_cursor": encodeZoneCursor(nextID),
+	})
+}
+
+// encodeZoneCursor turns the ID to continue after into an opaque cursor (empty ID = empty cursor)
+func encodeZoneCursor(afterID string) string {
+	if afterID == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(afterID))
+}
+
+// decodeZoneCursor returns the zone ID a cursor continues after (empty cursor = first page)
+func decodeZoneCursor(cursor string) (string, error) {
+	afterID, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(afterID), nil
+}
+
+// GetZonesInViewport returns the zones intersecting a map viewport as a GeoJSON FeatureCollection
+// Query params: bbox=minLng,minLat,maxLng,maxLat
+// At most MaxViewportZones features are returned; the collection has "truncated": true when capped
+func GetZonesInViewport(c *gin.Context) {
+	minLng, minLat, maxLng, maxLat, err := parseBBox(c.Query("bbox"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	zoneService := zone.GetZoneService()
+	zones := zoneService.GetZonesInBounds(minLat, minLng, maxLat, maxLng)
+
+	truncated := false
+	if limit := zoneService.MaxViewportZones; limit > 0 && len(zones) > limit {
+		zones = zones[:limit]
+		truncated = true
+	}
+
+	fc := zonegeojson.ZonesToFeatureCollection(zones, true, true)
+	if truncated {
+		fc.ExtraMembers = geojson.Properties{"truncated": true}
+	}
+
+	c.JSON(http.StatusOK, fc)
+}
+
+// parseBBox parses a "minLng,minLat,maxLng,maxLat" bounding box
+func parseBBox(bbox string) (minLng, minLat, maxLng, maxLat float64, err error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return 0, 0, 0, 0, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		values[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(values[i]) {
+			return 0, 0, 0, 0, fmt.Errorf("bbox value %q is not a number", part)
+		}
+	}
+	minLng, minLat, maxLng, maxLat = values[0], values[1], values[2], values[3]
+
+	if minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+		return 0, 0, 0, 0, fmt.Errorf("bbox is out of range")
+	}
+	if minLat > maxLat || minLng > maxLng {
+		return 0, 0, 0, 0, fmt.Errorf("bbox min values must not exceed max values")
+	}
+	return minLng, minLat, maxLng, maxLat, nil
+}
+
+// GetZoneEffects returns the combined zone effects at a coordinate
+// Query params: lat, lng, explain=1 for a per-zone breakdown by building category
+// Response is keyed by resource type name
+func GetZoneEffects(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "lat must be a number between -90 and 90",
+		})
+		return
+	}
+
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || math.IsNaN(lng) || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "lng must be a number between -180 and 180",
+		})
+		return
+	}
+
+	zoneService := zone.GetZoneService()
+	result := namedEffects(zoneService.GetEffectsForTarget(lat, lng))
+
+	if c.Query("explain") != "1" {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// Explain mode: add per-zone effects at this point split by contributing building category
+	// A zone's effects are the sums of its weighted contributions, so both come from the same source
+	zonesAtPoint := zoneService.GetZonesAtPoint(lat, lng)
+	contributionsByZone := make(map[string][]zone.EffectContribution, len(zonesAtPoint))
+	for _, contribution := range zoneService.GetEffectsBreakdownForZones(zonesAtPoint, lat, lng) {
+		contributionsByZone[contribution.ZoneID] = append(contributionsByZone[contribution.ZoneID], contribution)
+	}
+
+	zones := []gin.H{}
+	for _, z := range zonesAtPoint {
+		effects := make(map[model.TargetParamType]float32)
+		breakdown := make(map[string]map[string]float32)
+		for _, contribution := range contributionsByZone[z.ID] {
+			effects[contribution.ResourceType] += contribution.Value
+
+			resource := contribution.ResourceType.String()
+			if breakdown[resource] == nil {
+				breakdown[resource] = make(map[string]float32)
+			}
+			breakdown[resource][contribution.Category] += contribution.Value
+		}
+
+		zones = append(zones, gin.H{
+			"id":        z.ID,
+			"name":      z.Name,
+			"effects":   namedEffects(effects),
+			"breakdown": breakdown,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"effects": result,
+		"zones":   zones,
+	})
+}
+
+// zoneNamedEffects returns the effects of a single zone keyed by resource type name
+func zoneNamedEffects(z *model.Zone) map[string]float32 {
+	zoneEffects := make(map[model.TargetParamType]float32, len(z.Effects))
+	for _, effect := range z.Effects {
+		zoneEffects[effect.ResourceType] += effect.Value
+	}
+	return namedEffects(zoneEffects)
+}
+
+// namedEffects converts effects to a map keyed by resource type name
+// Always returns an object, even when there are no effects
+func namedEffects(effects map[model.TargetParamType]float32) map[string]float32 {
+	result := make(map[string]float32, len(effects))
+	for paramType, value := range effects {
+		result[paramType.String()] = value
+	}
+	return result
+}
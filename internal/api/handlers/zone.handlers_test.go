@@ -0,0 +1,280 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/model"
+	"metalink/internal/service/zone"
+
+	"github.com/gin-gonic/gin"
+)
+
+var seedZonesOnce sync.Once
+
+// newTestRouter returns a router with the zone handlers over a zone service seeded with two adjacent
+// zones and a zone with building stats
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	seedZonesOnce.Do(func() {
+		config, err := mappers.LoadBuildingEffectsConfig("../../../usa_buildings_data/building_cat_kf_config.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mappers.SetBuildingEffectsConfig(config)
+
+		// The stats zone keeps a stale cached effect, as zones loaded from the database may
+		stats := testZone("stats", 41, -100, 41.01, -99.99, 99)
+		stats.Buildings.BuildingAreas = map[string]float64{"residential": 5000, "food_services": 2000}
+
+		stats.Buildings.TotalCount = 30
+		west := testZone("west", 40, -100, 40.01, -99.99, 1.5)
+		west.Buildings.TotalCount = 5
+		east := testZone("east", 40, -99.99, 40.01, -99.98, 2)
+		east.Buildings.TotalCount = 20
+
+		zone.GetZoneService().LoadZones([]*model.Zone{west, east, stats})
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupZoneHandlers(router.Group(""))
+	return router
+}
+
+// testZone returns a zone covering the given lat/lon rectangle with a food search effect
+func testZone(id string, minLat, minLon, maxLat, maxLon float64, food float32) *model.Zone {
+	return &model.Zone{
+		ID:                id,
+		Name:              id,
+		TopLeftLatLon:     []float64{maxLat, minLon},
+		TopRightLatLon:    []float64{maxLat, maxLon},
+		BottomRightLatLon: []float64{minLat, maxLon},
+		BottomLeftLatLon:  []float64{minLat, minLon},
+		Effects:           []model.ZoneEffect{{ResourceType: model.TargetParamTypeFoodSearch, Value: food}},
+	}
+}
+
+// get performs a GET request and returns the recorded response
+func get(router *gin.Engine, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	return w
+}
+
+func TestGetZoneEffects(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := get(router, "/zones/effects?lat=40.005&lng=-99.995")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var effects map[string]float32
+	if err := json.Unmarshal(w.Body.Bytes(), &effects); err != nil {
+		t.Fatal(err)
+	}
+	if got := effects[model.TargetParamTypeFoodSearch.String()]; got != 1.5 {
+		t.Fatalf("food effect = %v, want 1.5 (body %s)", got, w.Body)
+	}
+}
+
+func TestGetZoneEffectsOutsideZonesReturnsEmptyObject(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := get(router, "/zones/effects?lat=10&lng=10")
+	if w.Code != http.StatusOK || w.Body.String() != "{}" {
+		t.Fatalf("status = %d, body %s; want 200, {}", w.Code, w.Body)
+	}
+}
+
+func TestGetZoneEffectsExplainSumsBreakdown(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := get(router, "/zones/effects?lat=41.005&lng=-99.995&explain=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var response struct {
+		Zones []struct {
+			ID        string                        `json:"id"`
+			Effects   map[string]float32            `json:"effects"`
+			Breakdown map[string]map[string]float32 `json:"breakdown"`
+		} `json:"zones"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Zones) != 1 || response.Zones[0].ID != "stats" {
+		t.Fatalf("zones = %+v, want the stats zone", response.Zones)
+	}
+
+	z := response.Zones[0]
+	food := model.TargetParamTypeFoodSearch.String()
+	if len(z.Breakdown[food]) != 2 {
+		t.Fatalf("food breakdown = %v, want residential and food_services", z.Breakdown[food])
+	}
+	if z.Effects[food] == 99 {
+		t.Fatal("zone effects come from the cached effects instead of the breakdown")
+	}
+	for resource, byCategory := range z.Breakdown {
+		var sum float32
+		for _, value := range byCategory {
+			sum += value
+		}
+		if diff := sum - z.Effects[resource]; diff > 1e-4 || diff < -1e-4 {
+			t.Errorf("%s: breakdown sums to %v, zone effect is %v", resource, sum, z.Effects[resource])
+		}
+	}
+	if len(z.Effects) != len(z.Breakdown) {
+		t.Errorf("effects %v and breakdown %v cover different resources", z.Effects, z.Breakdown)
+	}
+}
+
+func TestGetZoneEffectsRejectsInvalidCoordinates(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, query := range []string{
+		"",
+		"lat=40",
+		"lat=abc&lng=10",
+		"lat=91&lng=10",
+		"lat=40&lng=-181",
+		"lat=NaN&lng=10",
+		"lat=40&lng=nan",
+	} {
+		if w := get(router, "/zones/effects?"+query); w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestParseBBoxRejectsNaN(t *testing.T) {
+	if _, _, _, _, err := parseBBox("-100,NaN,-99,41"); err == nil {
+		t.Fatal("expected an error for a NaN bbox value")
+	}
+}
+
+// listZones requests a zone listing page and returns the zone IDs, the next cursor and the total count header
+func listZones(t *testing.T, router *gin.Engine, query string) ([]string, string, string) {
+	t.Helper()
+	w := get(router, "/zones/list?"+query)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%q: status = %d, body %s", query, w.Code, w.Body)
+	}
+
+	var response struct {
+		Zones []struct {
+			ID string `json:"id"`
+		} `json:"zones"`
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(response.Zones))
+	for i, z := range response.Zones {
+		ids[i] = z.ID
+	}
+	return ids, response.NextCursor, w.Header().Get("X-Total-Count")
+}
+
+func TestListZonesPages(t *testing.T) {
+	router := newTestRouter(t)
+
+	ids, cursor, total := listZones(t, router, "limit=2")
+	if !reflect.DeepEqual(ids, []string{"east", "stats"}) || cursor == "" || total != "3" {
+		t.Fatalf("first page %v, cursor %q, total %s; want [east stats], a cursor and 3", ids, cursor, total)
+	}
+
+	ids, cursor, total = listZones(t, router, "limit=2&cursor="+cursor)
+	if !reflect.DeepEqual(ids, []string{"west"}) || cursor != "" || total != "3" {
+		t.Fatalf("second page %v, cursor %q, total %s; want [west], no cursor and 3", ids, cursor, total)
+	}
+}
+
+func TestListZonesMinBuildings(t *testing.T) {
+	router := newTestRouter(t)
+
+	ids, cursor, total := listZones(t, router, "min_buildings=10")
+	if !reflect.DeepEqual(ids, []string{"east", "stats"}) || cursor != "" || total != "2" {
+		t.Fatalf("zones %v, cursor %q, total %s; want [east stats], no cursor and 2", ids, cursor, total)
+	}
+}
+
+func TestListZonesRejectsInvalidParams(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, query := range []string{"limit=0", "limit=1001", "limit=abc", "cursor=%21%21", "min_buildings=-1"} {
+		if w := get(router, "/zones/list?"+query); w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+// viewportZones requests the zones in a bbox and returns the feature IDs and the truncated flag
+func viewportZones(t *testing.T, router *gin.Engine, bbox string) ([]string, bool) {
+	t.Helper()
+	w := get(router, "/zones?bbox="+bbox)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bbox %q: status = %d, body %s", bbox, w.Code, w.Body)
+	}
+
+	var response struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type string `json:"type"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Type != "FeatureCollection" {
+		t.Fatalf("response type %q, want FeatureCollection", response.Type)
+	}
+
+	ids := make([]string, len(response.Features))
+	for i, feature := range response.Features {
+		if feature.Geometry.Type != "Polygon" || feature.Properties["fill"] == nil {
+			t.Fatalf("feature %v is not a styled polygon", feature.Properties["id"])
+		}
+		ids[i], _ = feature.Properties["id"].(string)
+	}
+	sort.Strings(ids)
+	return ids, response.Truncated
+}
+
+func TestGetZonesInViewport(t *testing.T) {
+	router := newTestRouter(t)
+
+	ids, truncated := viewportZones(t, router, "-100.5,39.5,-99.5,40.5")
+	if !reflect.DeepEqual(ids, []string{"east", "west"}) || truncated {
+		t.Fatalf("zones %v, truncated %v; want [east west] in full", ids, truncated)
+	}
+
+	service := zone.GetZoneService()
+	defer func(limit int) { service.MaxViewportZones = limit }(service.MaxViewportZones)
+	service.MaxViewportZones = 1
+	if ids, truncated = viewportZones(t, router, "-100.5,39.5,-99.5,40.5"); len(ids) != 1 || !truncated {
+		t.Fatalf("zones %v, truncated %v; want one zone and truncated", ids, truncated)
+	}
+}
+
+func TestGetZonesInViewportRejectsInvalidBBox(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, bbox := range []string{"", "1,2,3", "a,40,-99,41", "-99,40,-100,41", "-100,40,-99,91"} {
+		if w := get(router, "/zones?bbox="+bbox); w.Code != http.StatusBadRequest {
+			t.Errorf("bbox %q: status = %d, want 400", bbox, w.Code)
+		}
+	}
+}
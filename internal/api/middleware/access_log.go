@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"time"
+
+	"metalink/internal/logx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog logs every request as key=value fields through logx
+// Server errors are logged at error level, client errors at warn and the rest at info
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			path += "?" + c.Request.URL.RawQuery
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		logf := logx.Info
+		switch {
+		case status >= 500:
+			logf = logx.Error
+		case status >= 400:
+			logf = logx.Warn
+		}
+		logf("access method=%s path=%q status=%d latency=%v ip=%s bytes=%d",
+			c.Request.Method, path, status, time.Since(start), c.ClientIP(), max(c.Writer.Size(), 0))
+	}
+}
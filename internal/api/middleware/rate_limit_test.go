@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock is a settable time source for the limiter
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+// rateLimitedRouter returns a router limited to rps requests per second with the given burst
+func rateLimitedRouter(rps float64, burst int, clock *fakeClock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	limiter := newRateLimiter(rps, burst)
+	limiter.now = clock.now
+
+	router := gin.New()
+	router.Use(limiter.handler())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+// request performs a GET from the given client address
+func request(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	router := rateLimitedRouter(0.5, 3, clock)
+
+	for i := 0; i < 3; i++ {
+		if w := request(router, "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: status %d", i+1, w.Code)
+		}
+	}
+
+	// One token per 2 seconds
+	w := request(router, "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the burst: status %d, want 429", w.Code)
+	}
+	if retry := w.Header().Get("Retry-After"); retry != "2" {
+		t.Fatalf("Retry-After %q, want 2", retry)
+	}
+
+	// Other clients have their own bucket
+	if w := request(router, "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Fatalf("another client: status %d", w.Code)
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	w = request(router, "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("half a token later: status %d, Retry-After %q, want 429 and 1", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	if w := request(router, "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Fatalf("after the refill: status %d", w.Code)
+	}
+	if w := request(router, "10.0.0.1:1234"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("after using the refilled token: status %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(0, 1))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 10; i++ {
+		if w := request(router, "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d without a limit: status %d", i+1, w.Code)
+		}
+	}
+}
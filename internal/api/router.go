@@ -2,18 +2,48 @@ package api
 
 import (
 	routes "metalink/internal/api/handlers"
+	"metalink/internal/api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RouterOptions configures the optional middleware of the router
+type RouterOptions struct {
+	// RateLimitRPS is the sustained requests per second allowed per client IP on /api (0 = unlimited)
+	RateLimitRPS float64
+	// RateLimitBurst is the number of requests a client IP may make at once
+	RateLimitBurst int
+	// AccessLog logs every request through logx
+	AccessLog bool
+}
+
 // SetupRouter initializes all application routes
-func SetupRouter(r *gin.Engine, config map[string]string) {
-	// API group
-	api := r.Group("/api")
+func SetupRouter(r *gin.Engine, config map[string]string, opts RouterOptions) {
+	if opts.AccessLog {
+		r.Use(middleware.AccessLog())
+	}
+
+	// API group, rate limited so health probes are never throttled
+	api := r.Group("/api", middleware.RateLimit(opts.RateLimitRPS, opts.RateLimitBurst))
 
 	// Setup main handlers
 	routes.SetupMainHandlers(r.Group(""), config)
 
+	// Setup liveness and readiness probes
+	routes.SetupHealthHandlers(r.Group(""))
+
 	// Setup route handlers
 	routes.SetupRouteHandlers(api)
+
+	// Setup zone handlers
+	routes.SetupZoneHandlers(api)
+
+	// Setup effect handlers
+	routes.SetupEffectHandlers(api)
+
+	// Setup target handlers
+	routes.SetupTargetHandlers(api)
+
+	// Setup admin handlers
+	routes.SetupAdminHandlers(api)
 }
@@ -11,6 +11,45 @@ type Config struct {
 	Port     string `mapstructure:"PORT"`
 	DBUrl    string `mapstructure:"DB_URL"`
 	RedisUrl string `mapstructure:"REDIS_URL"`
+
+	// ForceRecalcEffects makes ZoneService ignore the zone effects cache stored in DB
+	ForceRecalcEffects bool `mapstructure:"FORCE_RECALC_EFFECTS"`
+
+	// ActiveHours limits target movement and effects to a daily UTC window, e.g. "08:00-22:00" (empty = always on)
+	ActiveHours string `mapstructure:"ACTIVE_HOURS"`
+
+	// ZoneTransitionsChannel is the Redis pub/sub channel for zone enter/exit events (empty = disabled)
+	ZoneTransitionsChannel string `mapstructure:"ZONE_TRANSITIONS_CHANNEL"`
+
+	// TrackZoneOccupancy counts the targets in each zone as they move
+	TrackZoneOccupancy bool `mapstructure:"TRACK_ZONE_OCCUPANCY"`
+
+	// CompactTargetMovement moves targets from a struct-of-arrays store instead of per-target route slices
+	CompactTargetMovement bool `mapstructure:"COMPACT_TARGET_MOVEMENT"`
+
+	// MaxViewportZones caps the number of zones returned by the viewport GeoJSON endpoint
+	MaxViewportZones int `mapstructure:"MAX_VIEWPORT_ZONES"`
+
+	// GradientEffects makes zone effects fall off from the zone centroid towards its boundary
+	GradientEffects bool `mapstructure:"GRADIENT_EFFECTS"`
+
+	// GradientEdgeWeight is the share of a zone's effects felt at its farthest corner in gradient mode
+	GradientEdgeWeight float64 `mapstructure:"GRADIENT_EDGE_WEIGHT"`
+
+	// RateLimitRPS is the sustained requests per second allowed per client IP on /api (0 = disabled)
+	RateLimitRPS float64 `mapstructure:"RATE_LIMIT_RPS"`
+
+	// RateLimitBurst is the number of requests a client IP may make at once before being limited
+	RateLimitBurst int `mapstructure:"RATE_LIMIT_BURST"`
+
+	// AccessLog logs every API request with its status and latency
+	AccessLog bool `mapstructure:"ACCESS_LOG"`
+
+	// LogLevel is the minimum level of logx messages: debug, info, warn or error
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+
+	// LogJSON writes logx messages as JSON lines instead of key=value text
+	LogJSON bool `mapstructure:"LOG_JSON"`
 }
 
 func LoadConfig() (c Config, err error) {
@@ -22,6 +61,19 @@ func LoadConfig() (c Config, err error) {
 
 	// Set default values
 	viper.SetDefault("PORT", ":8080")
+	viper.SetDefault("FORCE_RECALC_EFFECTS", false)
+	viper.SetDefault("ACTIVE_HOURS", "")
+	viper.SetDefault("ZONE_TRANSITIONS_CHANNEL", "zone-transitions")
+	viper.SetDefault("TRACK_ZONE_OCCUPANCY", true)
+	viper.SetDefault("COMPACT_TARGET_MOVEMENT", false)
+	viper.SetDefault("MAX_VIEWPORT_ZONES", 5000)
+	viper.SetDefault("GRADIENT_EFFECTS", false)
+	viper.SetDefault("GRADIENT_EDGE_WEIGHT", 0.25)
+	viper.SetDefault("RATE_LIMIT_RPS", 0)
+	viper.SetDefault("RATE_LIMIT_BURST", 20)
+	viper.SetDefault("ACCESS_LOG", false)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_JSON", false)
 
 	// Load environment file
 	viper.SetConfigName(fmt.Sprintf(".env.%s", env))
@@ -1,5 +1,5 @@
 package config
 
 const (
-	DefaultTargetSpeed = 1.5
+	DefaultTargetSpeed = 1.5 // Meters per second (walking pace)
 )
@@ -12,4 +12,28 @@ const (
 
 	// PostgresBackupInterval defines how often to save changes to PostgreSQL
 	PostgresBackupInterval = 999 * time.Minute
+
+	// ShutdownFlushTimeout limits how long shutdown waits for targets to be flushed
+	ShutdownFlushTimeout = 30 * time.Second
+
+	// HealthPingTimeout limits how long the readiness check waits for PostgreSQL and Redis
+	HealthPingTimeout = 2 * time.Second
+)
+
+// PostgreSQL batch save retries
+const (
+	// PGSaveRetryAttempts is how many times a failed PostgreSQL batch save is attempted in total
+	PGSaveRetryAttempts = 4
+
+	// PGSaveRetryBackoff is the delay before the first retry, doubled after each failure
+	PGSaveRetryBackoff = 500 * time.Millisecond
+)
+
+// Redis persistence
+const (
+	// RedisSaveWorkers is the maximum number of goroutines saving targets to Redis in parallel
+	RedisSaveWorkers = 8
+
+	// RedisSavePipelineBatch is how many targets each worker sends in one Redis pipeline
+	RedisSavePipelineBatch = 500
 )
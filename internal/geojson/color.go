@@ -0,0 +1,94 @@
+package geojson
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// ZoneColor returns the fill color and opacity of a zone by building area, on a log scale between minArea and maxArea
+func ZoneColor(buildingArea, minArea, maxArea float64) (string, float64) {
+	r, g, b, opacity := zoneRGB(buildingArea, minArea, maxArea)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b), opacity
+}
+
+// ZoneNRGBA is ZoneColor as an image color with the opacity as alpha
+func ZoneNRGBA(buildingArea, minArea, maxArea float64) color.NRGBA {
+	r, g, b, opacity := zoneRGB(buildingArea, minArea, maxArea)
+	return color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: uint8(math.Round(opacity * 255))}
+}
+
+// zoneRGB returns the color channels and opacity of the zone palette
+func zoneRGB(buildingArea, minArea, maxArea float64) (int, int, int, float64) {
+	// If no buildings, return light gray (#f5f5f5)
+	if buildingArea == 0 {
+		return 245, 245, 245, 0.2
+	}
+
+	// Avoid division by zero (#4fc3f7)
+	if maxArea == minArea {
+		return 79, 195, 247, 0.7
+	}
+
+	// Use logarithmic normalization for better distribution
+	logMin := math.Log(minArea + 1)
+	logMax := math.Log(maxArea + 1)
+	logCurrent := math.Log(buildingArea + 1)
+
+	normalized := (logCurrent - logMin) / (logMax - logMin)
+
+	// Clamp to 0-1 range
+	if normalized < 0 {
+		normalized = 0
+	}
+	if normalized > 1 {
+		normalized = 1
+	}
+
+	// Create smoother color gradient with more steps
+	// Very low: light blue (#e3f2fd)
+	// Low: light green (#c8e6c9)
+	// Medium-low: yellow (#fff9c4)
+	// Medium: orange (#ffcc80)
+	// Medium-high: red (#ff8a80)
+	// High: dark red (#d32f2f)
+
+	var r, g, b int
+
+	if normalized < 0.2 {
+		// Very low: light blue to light green
+		t := normalized / 0.2
+		r = int(227 + (200-227)*t) // 227 to 200
+		g = int(242 + (230-242)*t) // 242 to 230
+		b = int(253 + (201-253)*t) // 253 to 201
+	} else if normalized < 0.4 {
+		// Low: light green to yellow
+		t := (normalized - 0.2) / 0.2
+		r = int(200 + (255-200)*t) // 200 to 255
+		g = int(230 + (249-230)*t) // 230 to 249
+		b = int(201 + (196-201)*t) // 201 to 196
+	} else if normalized < 0.6 {
+		// Medium-low: yellow to orange
+		t := (normalized - 0.4) / 0.2
+		r = int(255)               // 255 stays
+		g = int(249 + (204-249)*t) // 249 to 204
+		b = int(196 + (128-196)*t) // 196 to 128
+	} else if normalized < 0.8 {
+		// Medium: orange to red
+		t := (normalized - 0.6) / 0.2
+		r = int(255)               // 255 stays
+		g = int(204 + (138-204)*t) // 204 to 138
+		b = int(128)               // 128 to 128
+	} else {
+		// High: red to dark red
+		t := (normalized - 0.8) / 0.2
+		r = int(255 + (211-255)*t) // 255 to 211
+		g = int(138 + (47-138)*t)  // 138 to 47
+		b = int(128 + (47-128)*t)  // 128 to 47
+	}
+
+	// More gradual opacity change (0.4 to 0.85)
+	opacity := 0.4 + normalized*0.45
+
+	return r, g, b, opacity
+}
@@ -0,0 +1,177 @@
+// Package geojson converts zones to GeoJSON features shared by file exports and the API
+package geojson
+
+import (
+	"math"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// AreaUnit is the unit used for all area properties
+var AreaUnit = util.AreaUnitM2
+
+// AreaProperty returns the property name for an area value in the configured unit
+func AreaProperty(name string) string {
+	return name + "_" + string(AreaUnit)
+}
+
+// ConvertAreas converts a map of areas in square meters to the configured unit
+func ConvertAreas(areas map[string]float64) map[string]float64 {
+	converted := make(map[string]float64, len(areas))
+	for key, area := range areas {
+		converted[key] = util.ConvertArea(area, AreaUnit)
+	}
+	return converted
+}
+
+// convertLevelBuckets converts level bucket stats to properties with areas in the configured unit
+func convertLevelBuckets(buckets map[string]model.BucketStat) map[string]map[string]interface{} {
+	converted := make(map[string]map[string]interface{}, len(buckets))
+	for name, stat := range buckets {
+		converted[name] = map[string]interface{}{
+			"count":              stat.Count,
+			AreaProperty("area"): util.ConvertArea(stat.TotalArea, AreaUnit),
+		}
+	}
+	return converted
+}
+
+// roundToKilometers converts meters to kilometers and rounds to 2 decimal places
+func roundToKilometers(meters float64) float64 {
+	km := meters / 1000.0
+	return math.Round(km*100) / 100
+}
+
+// BuildingAreaRange returns the min and max total building area of zones with buildings
+func BuildingAreaRange(zones []*model.Zone) (float64, float64) {
+	var minArea, maxArea float64
+	first := true
+	for _, zone := range zones {
+		if zone.Buildings.TotalArea <= 0 {
+			continue
+		}
+		if first || zone.Buildings.TotalArea < minArea {
+			minArea = zone.Buildings.TotalArea
+		}
+		if first || zone.Buildings.TotalArea > maxArea {
+			maxArea = zone.Buildings.TotalArea
+		}
+		first = false
+	}
+	return minArea, maxArea
+}
+
+// ZonesToFeatureCollection converts zones to a feature collection, colored relative to each other if withColor
+func ZonesToFeatureCollection(zones []*model.Zone, includeDetails bool, withColor bool) *geojson.FeatureCollection {
+	minArea, maxArea := BuildingAreaRange(zones)
+
+	fc := geojson.NewFeatureCollection()
+	for _, zone := range zones {
+		feature := ZoneFeature(zone, includeDetails)
+		if withColor {
+			StyleZoneFeature(feature, zone.Buildings.TotalArea, minArea, maxArea)
+		}
+		fc.Append(feature)
+	}
+	return fc
+}
+
+// StyleZoneFeature adds fill and stroke properties colored by building area, see ZoneColor
+func StyleZoneFeature(feature *geojson.Feature, buildingArea, minArea, maxArea float64) {
+	fillColor, fillOpacity := ZoneColor(buildingArea, minArea, maxArea)
+
+	feature.Properties["fill"] = fillColor
+	feature.Properties["fill-opacity"] = fillOpacity
+	feature.Properties["stroke"] = "#333333"
+	feature.Properties["stroke-width"] = 1
+	feature.Properties["stroke-opacity"] = 0.8
+}
+
+// ZoneFeature converts a zone to a polygon feature with its size and building statistics
+// includeDetails adds height, category and water statistics, otherwise only the building count is added
+func ZoneFeature(zone *model.Zone, includeDetails bool) *geojson.Feature {
+	feature := CornersFeature(zone.Corners())
+	feature.Properties["id"] = zone.ID
+
+	// Non-rectangular zones keep their stored outline, the size properties still describe the corners
+	if zone.Geometry != "" {
+		feature.Geometry = zone.BuildPolygon()
+	}
+	feature.Properties["name"] = zone.Name
+
+	if !includeDetails {
+		// Only basic building count for simple view
+		if zone.Buildings.TotalCount > 0 {
+			feature.Properties["total_buildings"] = zone.Buildings.TotalCount
+		}
+		return feature
+	}
+
+	// Basic building stats
+	feature.Properties["total_buildings"] = zone.Buildings.TotalCount
+	feature.Properties[AreaProperty("total_area")] = util.ConvertArea(zone.Buildings.TotalArea, AreaUnit)
+
+	// Height-based stats, custom level buckets replace the fixed ones when present
+	if len(zone.Buildings.LevelBuckets) > 0 {
+		feature.Properties["level_buckets"] = convertLevelBuckets(zone.Buildings.LevelBuckets)
+	} else {
+		feature.Properties["single_floor_count"] = zone.Buildings.SingleFloorCount
+		feature.Properties[AreaProperty("single_floor_area")] = util.ConvertArea(zone.Buildings.SingleFloorTotalArea, AreaUnit)
+		feature.Properties["low_rise_count"] = zone.Buildings.LowRiseCount
+		feature.Properties[AreaProperty("low_rise_area")] = util.ConvertArea(zone.Buildings.LowRiseTotalArea, AreaUnit)
+		feature.Properties["high_rise_count"] = zone.Buildings.HighRiseCount
+		feature.Properties[AreaProperty("high_rise_area")] = util.ConvertArea(zone.Buildings.HighRiseTotalArea, AreaUnit)
+		feature.Properties["skyscraper_count"] = zone.Buildings.SkyscraperCount
+		feature.Properties[AreaProperty("skyscraper_area")] = util.ConvertArea(zone.Buildings.SkyscraperTotalArea, AreaUnit)
+	}
+
+	// Building types (game categories)
+	if len(zone.Buildings.BuildingTypes) > 0 {
+		feature.Properties["building_types"] = zone.Buildings.BuildingTypes
+	}
+
+	// Building areas by type (game categories)
+	if len(zone.Buildings.BuildingAreas) > 0 {
+		feature.Properties[AreaProperty("building_areas")] = ConvertAreas(zone.Buildings.BuildingAreas)
+	}
+
+	// Water body stats if available
+	if zone.WaterBodies.TotalCount > 0 {
+		feature.Properties["water_bodies_count"] = zone.WaterBodies.TotalCount
+		feature.Properties[AreaProperty("water_bodies_area")] = util.ConvertArea(zone.WaterBodies.TotalArea, AreaUnit)
+		feature.Properties["river_count"] = zone.WaterBodies.RiverCount
+		feature.Properties["lake_count"] = zone.WaterBodies.LakeCount
+		feature.Properties["pond_count"] = zone.WaterBodies.PondCount
+	}
+
+	return feature
+}
+
+// CornersFeature creates a polygon feature from four [lon, lat] corners with width, height and area properties
+// The ring is counter-clockwise, like Zone.CornersRing
+func CornersFeature(topLeft, topRight, bottomRight, bottomLeft orb.Point) *geojson.Feature {
+	ring := orb.Ring{topLeft, bottomLeft, bottomRight, topRight, topLeft}
+	feature := geojson.NewFeature(orb.Polygon{ring})
+
+	// Calculate actual width and height in meters
+	topWidth := util.HaversineDistance(topLeft.Lat(), topLeft.Lon(), topRight.Lat(), topRight.Lon())
+	bottomWidth := util.HaversineDistance(bottomLeft.Lat(), bottomLeft.Lon(), bottomRight.Lat(), bottomRight.Lon())
+	leftHeight := util.HaversineDistance(topLeft.Lat(), topLeft.Lon(), bottomLeft.Lat(), bottomLeft.Lon())
+	rightHeight := util.HaversineDistance(topRight.Lat(), topRight.Lon(), bottomRight.Lat(), bottomRight.Lon())
+
+	// Calculate area (approximate for trapezoid)
+	avgHeight := (leftHeight + rightHeight) / 2
+	area := (topWidth + bottomWidth) * avgHeight / 2
+
+	feature.Properties["top_width_km"] = roundToKilometers(topWidth)
+	feature.Properties["bottom_width_km"] = roundToKilometers(bottomWidth)
+	feature.Properties["left_height_km"] = roundToKilometers(leftHeight)
+	feature.Properties["right_height_km"] = roundToKilometers(rightHeight)
+	feature.Properties[AreaProperty("area")] = util.ConvertArea(area, AreaUnit)
+
+	return feature
+}
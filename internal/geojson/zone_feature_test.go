@@ -0,0 +1,96 @@
+package geojson
+
+import (
+	"math"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+// withAreaUnit sets AreaUnit for the duration of a test
+func withAreaUnit(t *testing.T, unit util.AreaUnit) {
+	t.Helper()
+	previous := AreaUnit
+	AreaUnit = unit
+	t.Cleanup(func() { AreaUnit = previous })
+}
+
+func testBuildingZone() *model.Zone {
+	return &model.Zone{
+		ID:                "zone-1",
+		TopLeftLatLon:     []float64{40.01, -100},
+		TopRightLatLon:    []float64{40.01, -99.99},
+		BottomRightLatLon: []float64{40, -99.99},
+		BottomLeftLatLon:  []float64{40, -100},
+		Buildings: model.BuildingStats{
+			TotalCount:    2,
+			TotalArea:     25000,
+			BuildingTypes: map[string]int{"residential": 1, "retail": 1},
+			BuildingAreas: map[string]float64{"residential": 24988, "retail": 12},
+		},
+	}
+}
+
+func TestZoneFeatureAreaUnitHectares(t *testing.T) {
+	withAreaUnit(t, util.AreaUnitHectares)
+	feature := ZoneFeature(testBuildingZone(), true)
+
+	if got := feature.Properties["total_area_hectares"]; got != 2.5 {
+		t.Fatalf("total_area_hectares = %v, want 2.5", got)
+	}
+	if _, ok := feature.Properties["total_area_m2"]; ok {
+		t.Fatal("total_area_m2 is set in hectares mode")
+	}
+
+	areas := feature.Properties["building_areas_hectares"].(map[string]float64)
+	if math.Abs(areas["residential"]-2.4988) > 1e-12 {
+		t.Fatalf("residential area = %v hectares, want 2.4988", areas["residential"])
+	}
+}
+
+func TestZoneFeatureKeepsSmallAreasInLargeUnits(t *testing.T) {
+	withAreaUnit(t, util.AreaUnitKM2)
+	feature := ZoneFeature(testBuildingZone(), true)
+
+	// A 12 m² building is 0.000012 km²; rounding to 2 decimals used to turn it into 0
+	areas := feature.Properties["building_areas_km2"].(map[string]float64)
+	if got := util.AreaToSquareMeters(areas["retail"], util.AreaUnitKM2); math.Abs(got-12) > 1e-9 {
+		t.Fatalf("retail area round-trips to %v m², want 12", got)
+	}
+}
+
+func TestZoneFeatureWaterProperties(t *testing.T) {
+	withAreaUnit(t, util.AreaUnitHectares)
+	zone := testBuildingZone()
+
+	// No water bodies, no water properties
+	if _, ok := ZoneFeature(zone, true).Properties["water_bodies_count"]; ok {
+		t.Fatal("water_bodies_count is set for a zone without water")
+	}
+
+	zone.WaterBodies = model.WaterBodyStats{
+		RiverCount: 1, RiverTotalArea: 5000,
+		LakeCount: 2, LakeTotalArea: 30000,
+		PondCount: 3, PondTotalArea: 600,
+		TotalCount: 6, TotalArea: 35600,
+	}
+	properties := ZoneFeature(zone, true).Properties
+	want := map[string]any{
+		"water_bodies_count":         6,
+		"water_bodies_area_hectares": 3.56,
+		"river_count":                1,
+		"lake_count":                 2,
+		"pond_count":                 3,
+	}
+	for key, value := range want {
+		if properties[key] != value {
+			t.Errorf("%s = %v, want %v", key, properties[key], value)
+		}
+	}
+
+	// The simple view only has the building count
+	if _, ok := ZoneFeature(zone, false).Properties["water_bodies_count"]; ok {
+		t.Fatal("water_bodies_count is set without details")
+	}
+}
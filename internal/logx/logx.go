@@ -0,0 +1,96 @@
+// Package logx is a minimal leveled logger on top of log/slog
+// Messages are printf-style like the standard log package and are written to log.Writer(),
+// so the output configured with log.SetOutput (e.g. file + terminal) is kept
+package logx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Log levels, lowest to highest
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+)
+
+var (
+	level  = new(slog.LevelVar) // Info by default
+	logger = slog.New(slog.NewTextHandler(stdLogWriter{}, &slog.HandlerOptions{Level: level}))
+)
+
+// stdLogWriter forwards to the current output of the standard log package
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	return log.Writer().Write(p)
+}
+
+// SetLevel sets the minimum level of messages that are written
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// SetJSON switches the output to one JSON object per line
+// Must be called at startup, before logging from multiple goroutines
+func SetJSON(enabled bool) {
+	if enabled {
+		logger = slog.New(slog.NewJSONHandler(stdLogWriter{}, &slog.HandlerOptions{Level: level}))
+	} else {
+		logger = slog.New(slog.NewTextHandler(stdLogWriter{}, &slog.HandlerOptions{Level: level}))
+	}
+}
+
+// ParseLevel parses a level name: debug, info, warn (or warning) or error
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", name)
+}
+
+// Enabled reports whether messages of the given level are written
+// Use it to skip building expensive debug output
+func Enabled(l slog.Level) bool {
+	return l >= level.Level()
+}
+
+// Debug logs verbose diagnostics such as per-item progress
+func Debug(format string, args ...any) {
+	logf(LevelDebug, format, args...)
+}
+
+// Info logs normal operational messages
+func Info(format string, args ...any) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warn logs recoverable problems
+func Warn(format string, args ...any) {
+	logf(LevelWarn, format, args...)
+}
+
+// Error logs failures
+func Error(format string, args ...any) {
+	logf(LevelError, format, args...)
+}
+
+// logf formats and writes a message if its level is enabled
+func logf(l slog.Level, format string, args ...any) {
+	if !Enabled(l) {
+		return
+	}
+	logger.Log(context.Background(), l, fmt.Sprintf(format, args...))
+}
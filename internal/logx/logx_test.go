@@ -0,0 +1,63 @@
+package logx
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureOutput sets the level and sends the standard log output to a buffer until the test ends
+func captureOutput(t *testing.T, l slog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previousOutput, previousLevel := log.Writer(), level.Level()
+	log.SetOutput(&buf)
+	SetLevel(l)
+	t.Cleanup(func() {
+		log.SetOutput(previousOutput)
+		SetLevel(previousLevel)
+	})
+	return &buf
+}
+
+func TestDebugIsFilteredAtInfoLevel(t *testing.T) {
+	buf := captureOutput(t, LevelInfo)
+
+	Debug("debug message %d", 1)
+	Info("info message %d", 2)
+
+	out := buf.String()
+	if strings.Contains(out, "debug message") {
+		t.Fatalf("debug message written at info level: %q", out)
+	}
+	if !strings.Contains(out, "info message 2") {
+		t.Fatalf("info message missing: %q", out)
+	}
+	if Enabled(LevelDebug) {
+		t.Fatal("debug reported as enabled at info level")
+	}
+}
+
+func TestDebugIsWrittenAtDebugLevel(t *testing.T) {
+	buf := captureOutput(t, LevelDebug)
+
+	Debug("debug message %d", 1)
+
+	if !strings.Contains(buf.String(), "debug message 1") {
+		t.Fatalf("debug message missing at debug level: %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{"debug": LevelDebug, "": LevelInfo, "WARNING": LevelWarn, " error ": LevelError} {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Fatalf("ParseLevel(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+import "github.com/paulmach/orb"
+
+// CornersBound returns the bounding box of the zone corners in [lon, lat] order
+func (z *Zone) CornersBound() orb.Bound {
+	return z.CornersRing().Bound()
+}
+
+// ZonesBounds returns the minimum bounding rectangle of all zone corners in [lon, lat] order
+// An empty slice gives the zero bound
+func ZonesBounds(zones []*Zone) orb.Bound {
+	if len(zones) == 0 {
+		return orb.Bound{}
+	}
+
+	bound := zones[0].CornersBound()
+	for _, zone := range zones[1:] {
+		bound = bound.Union(zone.CornersBound())
+	}
+	return bound
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+// squareZone returns a zone with the given [lat, lon] bottom left corner and size in degrees
+func squareZone(lat, lon, size float64) *Zone {
+	return &Zone{
+		TopLeftLatLon:     []float64{lat + size, lon},
+		TopRightLatLon:    []float64{lat + size, lon + size},
+		BottomRightLatLon: []float64{lat, lon + size},
+		BottomLeftLatLon:  []float64{lat, lon},
+	}
+}
+
+func TestZonesBounds(t *testing.T) {
+	if bound := ZonesBounds(nil); bound != (orb.Bound{}) {
+		t.Fatalf("bound of no zones = %v, want the zero bound", bound)
+	}
+
+	zones := []*Zone{squareZone(40, -100, 1), squareZone(35, -90, 0.5), squareZone(42, -95, 2)}
+	want := orb.Bound{Min: orb.Point{-100, 35}, Max: orb.Point{-89.5, 44}}
+	if bound := ZonesBounds(zones); bound != want {
+		t.Fatalf("bound = %v, want %v", bound, want)
+	}
+}
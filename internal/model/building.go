@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/dhconnelly/rtreego"
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
 )
 
 // Building represents a building extracted from OSM data
@@ -19,6 +20,11 @@ type Building struct {
 	CentroidLon float64           // Longitude of the building centroid
 }
 
+// FootprintArea returns the area of the building outline on the ground in square meters
+func (b *Building) FootprintArea() float64 {
+	return geo.Area(b.Outline)
+}
+
 // BuildingSpatial represents a building with its spatial information for R-tree indexing
 type BuildingSpatial struct {
 	Building *Building // Reference to the building
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"fmt"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+)
+
+// BuildingHeightClass is a height band of a building by number of levels
+type BuildingHeightClass int
+
+const (
+	BuildingHeightSingleFloor BuildingHeightClass = iota
+	BuildingHeightLowRise
+	BuildingHeightHighRise
+	BuildingHeightSkyscraper
+)
+
+// String returns the name of the height class
+func (c BuildingHeightClass) String() string {
+	switch c {
+	case BuildingHeightSingleFloor:
+		return "single_floor"
+	case BuildingHeightLowRise:
+		return "low_rise"
+	case BuildingHeightHighRise:
+		return "high_rise"
+	case BuildingHeightSkyscraper:
+		return "skyscraper"
+	}
+	return fmt.Sprintf("BuildingHeightClass(%d)", int(c))
+}
+
+// ClassifyBuildingHeight returns the height class for a number of levels
+// Thresholds come from the building effects config, buildings without levels count as single floor
+func ClassifyBuildingHeight(levels int) BuildingHeightClass {
+	heightThresholds := mappers.GetHeightThresholds()
+
+	switch {
+	case levels >= heightThresholds.SkyscraperMinLevels:
+		return BuildingHeightSkyscraper
+	case levels >= heightThresholds.HighRiseMinLevels:
+		return BuildingHeightHighRise
+	case levels >= heightThresholds.LowRiseMinLevels:
+		return BuildingHeightLowRise
+	default:
+		return BuildingHeightSingleFloor
+	}
+}
+
+// AddToHeightClass records a building area in the stats of its height class
+func (s *BuildingStats) AddToHeightClass(class BuildingHeightClass, area float64) {
+	switch class {
+	case BuildingHeightSingleFloor:
+		s.SingleFloorCount++
+		s.SingleFloorTotalArea += area
+	case BuildingHeightLowRise:
+		s.LowRiseCount++
+		s.LowRiseTotalArea += area
+	case BuildingHeightHighRise:
+		s.HighRiseCount++
+		s.HighRiseTotalArea += area
+	case BuildingHeightSkyscraper:
+		s.SkyscraperCount++
+		s.SkyscraperTotalArea += area
+	}
+}
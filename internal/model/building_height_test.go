@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestClassifyBuildingHeightBoundaries(t *testing.T) {
+	useTestEffectsConfig(t) // Thresholds 2, 10 and 30 levels
+
+	cases := map[int]BuildingHeightClass{
+		0:  BuildingHeightSingleFloor, // No levels tag
+		1:  BuildingHeightSingleFloor,
+		2:  BuildingHeightLowRise,
+		9:  BuildingHeightLowRise,
+		10: BuildingHeightHighRise,
+		29: BuildingHeightHighRise,
+		30: BuildingHeightSkyscraper,
+	}
+	for levels, want := range cases {
+		if got := ClassifyBuildingHeight(levels); got != want {
+			t.Errorf("ClassifyBuildingHeight(%d) = %v, want %v", levels, got, want)
+		}
+	}
+}
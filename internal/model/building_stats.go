@@ -0,0 +1,66 @@
+package model
+
+// Add adds the counts, areas and per-type maps of other to the stats
+// Nil maps on either side are handled; maps are created only when there is something to add
+func (bs *BuildingStats) Add(other BuildingStats) {
+	bs.SingleFloorCount += other.SingleFloorCount
+	bs.SingleFloorTotalArea += other.SingleFloorTotalArea
+	bs.LowRiseCount += other.LowRiseCount
+	bs.LowRiseTotalArea += other.LowRiseTotalArea
+	bs.HighRiseCount += other.HighRiseCount
+	bs.HighRiseTotalArea += other.HighRiseTotalArea
+	bs.SkyscraperCount += other.SkyscraperCount
+	bs.SkyscraperTotalArea += other.SkyscraperTotalArea
+
+	bs.TotalCount += other.TotalCount
+	bs.TotalArea += other.TotalArea
+
+	bs.BuildingTypes = addCounts(bs.BuildingTypes, other.BuildingTypes)
+	bs.BuildingAreas = addAreas(bs.BuildingAreas, other.BuildingAreas)
+	bs.RawBuildingTypes = addCounts(bs.RawBuildingTypes, other.RawBuildingTypes)
+
+	if len(other.LevelBuckets) > 0 && bs.LevelBuckets == nil {
+		bs.LevelBuckets = make(map[string]BucketStat, len(other.LevelBuckets))
+	}
+	for name, stat := range other.LevelBuckets {
+		bucket := bs.LevelBuckets[name]
+		bucket.Count += stat.Count
+		bucket.TotalArea += stat.TotalArea
+		bs.LevelBuckets[name] = bucket
+	}
+}
+
+// MergeBuildingStats returns the sum of all given stats
+// The input stats are not modified
+func MergeBuildingStats(stats ...BuildingStats) BuildingStats {
+	merged := BuildingStats{
+		BuildingTypes: make(map[string]int),
+		BuildingAreas: make(map[string]float64),
+	}
+	for _, s := range stats {
+		merged.Add(s)
+	}
+	return merged
+}
+
+// addCounts adds the counts of src to dst, creating dst if needed
+func addCounts(dst, src map[string]int) map[string]int {
+	if len(src) > 0 && dst == nil {
+		dst = make(map[string]int, len(src))
+	}
+	for key, count := range src {
+		dst[key] += count
+	}
+	return dst
+}
+
+// addAreas adds the areas of src to dst, creating dst if needed
+func addAreas(dst, src map[string]float64) map[string]float64 {
+	if len(src) > 0 && dst == nil {
+		dst = make(map[string]float64, len(src))
+	}
+	for key, area := range src {
+		dst[key] += area
+	}
+	return dst
+}
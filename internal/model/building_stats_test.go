@@ -0,0 +1,73 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+// zoneStats returns consistent stats for one zone: the totals are the sums of the height classes
+func zoneStats(singleFloor, lowRise, highRise, skyscraper int, area float64) BuildingStats {
+	total := singleFloor + lowRise + highRise + skyscraper
+	return BuildingStats{
+		SingleFloorCount:     singleFloor,
+		SingleFloorTotalArea: float64(singleFloor) * area,
+		LowRiseCount:         lowRise,
+		LowRiseTotalArea:     float64(lowRise) * area,
+		HighRiseCount:        highRise,
+		HighRiseTotalArea:    float64(highRise) * area,
+		SkyscraperCount:      skyscraper,
+		SkyscraperTotalArea:  float64(skyscraper) * area,
+		TotalCount:           total,
+		TotalArea:            float64(total) * area,
+		BuildingTypes:        map[string]int{"residential": total},
+		BuildingAreas:        map[string]float64{"residential": float64(total) * area},
+		LevelBuckets:         map[string]BucketStat{"1-2": {Count: total, TotalArea: float64(total) * area}},
+	}
+}
+
+func TestMergeBuildingStatsTotalsEqualComponentSums(t *testing.T) {
+	zones := []BuildingStats{
+		zoneStats(3, 2, 1, 0, 100),
+		zoneStats(0, 5, 0, 1, 250),
+		{RawBuildingTypes: map[string]int{"yes": 4}}, // Only raw types, other maps nil
+	}
+
+	merged := MergeBuildingStats(zones...)
+
+	classCount := merged.SingleFloorCount + merged.LowRiseCount + merged.HighRiseCount + merged.SkyscraperCount
+	classArea := merged.SingleFloorTotalArea + merged.LowRiseTotalArea + merged.HighRiseTotalArea + merged.SkyscraperTotalArea
+	if merged.TotalCount != 12 || classCount != merged.TotalCount {
+		t.Fatalf("total count %d, height classes sum to %d, want 12", merged.TotalCount, classCount)
+	}
+	if merged.TotalArea != 2100 || math.Abs(classArea-merged.TotalArea) > 1e-9 {
+		t.Fatalf("total area %v, height classes sum to %v, want 2100", merged.TotalArea, classArea)
+	}
+
+	var countSum, areaSum, rawSum int
+	for _, zone := range zones {
+		countSum += zone.BuildingTypes["residential"]
+		areaSum += int(zone.BuildingAreas["residential"])
+		rawSum += zone.RawBuildingTypes["yes"]
+	}
+	if merged.BuildingTypes["residential"] != countSum || int(merged.BuildingAreas["residential"]) != areaSum {
+		t.Fatalf("type totals %d / %v, want %d / %d",
+			merged.BuildingTypes["residential"], merged.BuildingAreas["residential"], countSum, areaSum)
+	}
+	if merged.RawBuildingTypes["yes"] != rawSum {
+		t.Fatalf("raw type total %d, want %d", merged.RawBuildingTypes["yes"], rawSum)
+	}
+	if bucket := merged.LevelBuckets["1-2"]; bucket.Count != 12 || bucket.TotalArea != 2100 {
+		t.Fatalf("bucket = %+v, want 12 buildings over 2100", bucket)
+	}
+}
+
+func TestMergeBuildingStatsLeavesInputsUnchanged(t *testing.T) {
+	first := zoneStats(1, 0, 0, 0, 50)
+	second := zoneStats(2, 0, 0, 0, 50)
+
+	MergeBuildingStats(first, second)
+
+	if first.TotalCount != 1 || first.BuildingTypes["residential"] != 1 || first.LevelBuckets["1-2"].Count != 1 {
+		t.Fatalf("first input was modified: %+v", first)
+	}
+}
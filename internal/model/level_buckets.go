@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BucketStat holds building statistics of a single level bucket
+type BucketStat struct {
+	Count     int     `json:"count"`
+	TotalArea float64 `json:"total_area"`
+}
+
+// LevelBucket is a named range of building levels, e.g. "2-4" or "13+"
+type LevelBucket struct {
+	Name      string
+	MinLevels int
+	MaxLevels int  // Ignored when OpenEnded is set
+	OpenEnded bool // "N+" range without an upper bound
+}
+
+// Contains reports whether a building with the given number of levels falls into the bucket
+func (b LevelBucket) Contains(levels int) bool {
+	return levels >= b.MinLevels && (b.OpenEnded || levels <= b.MaxLevels)
+}
+
+// overlaps reports whether the level ranges of both buckets share a level
+func (b LevelBucket) overlaps(other LevelBucket) bool {
+	return b.Contains(other.MinLevels) || other.Contains(b.MinLevels)
+}
+
+// ParseLevelBuckets parses comma-separated level ranges like "1,2-4,5-12,13+"
+// Each range is named after its spec. Buildings without levels count as 1 level
+// Ranges must not overlap, so every building is recorded in at most one bucket
+func ParseLevelBuckets(spec string) ([]LevelBucket, error) {
+	var buckets []LevelBucket
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bucket, err := parseLevelBucket(part)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("no level ranges in %q", spec)
+	}
+
+	// Sorted by lower bound, overlapping ranges are neighbours
+	sorted := append([]LevelBucket(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinLevels < sorted[j].MinLevels })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].overlaps(sorted[i]) {
+			return nil, fmt.Errorf("level ranges %q and %q overlap", sorted[i-1].Name, sorted[i].Name)
+		}
+	}
+	return buckets, nil
+}
+
+// parseLevelBucket parses a single "N", "N-M" or "N+" range
+func parseLevelBucket(part string) (LevelBucket, error) {
+	bucket := LevelBucket{Name: part}
+
+	var err error
+	switch {
+	case strings.HasSuffix(part, "+"):
+		bucket.MinLevels, err = strconv.Atoi(strings.TrimSuffix(part, "+"))
+		bucket.OpenEnded = true
+	case strings.Contains(part, "-"):
+		minStr, maxStr, _ := strings.Cut(part, "-")
+		if bucket.MinLevels, err = strconv.Atoi(minStr); err == nil {
+			bucket.MaxLevels, err = strconv.Atoi(maxStr)
+		}
+	default:
+		bucket.MinLevels, err = strconv.Atoi(part)
+		bucket.MaxLevels = bucket.MinLevels
+	}
+
+	if err != nil {
+		return LevelBucket{}, fmt.Errorf("invalid level range %q: %w", part, err)
+	}
+	if bucket.MinLevels < 1 || (!bucket.OpenEnded && bucket.MaxLevels < bucket.MinLevels) {
+		return LevelBucket{}, fmt.Errorf("invalid level range %q", part)
+	}
+	return bucket, nil
+}
+
+// AddToLevelBuckets records a building in the first matching bucket
+// Buildings matching no bucket are not recorded
+func (s *BuildingStats) AddToLevelBuckets(buckets []LevelBucket, levels int, area float64) {
+	if levels < 1 {
+		levels = 1
+	}
+
+	for _, bucket := range buckets {
+		if !bucket.Contains(levels) {
+			continue
+		}
+
+		if s.LevelBuckets == nil {
+			s.LevelBuckets = make(map[string]BucketStat, len(buckets))
+		}
+		stat := s.LevelBuckets[bucket.Name]
+		stat.Count++
+		stat.TotalArea += area
+		s.LevelBuckets[bucket.Name] = stat
+		return
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestParseLevelBuckets(t *testing.T) {
+	buckets, err := ParseLevelBuckets("1, 2-4,5-12,13+")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for levels, want := range map[int]string{1: "1", 3: "2-4", 12: "5-12", 13: "13+", 80: "13+"} {
+		var stats BuildingStats
+		stats.AddToLevelBuckets(buckets, levels, 100)
+		if stats.LevelBuckets[want].Count != 1 {
+			t.Errorf("%d levels recorded in %v, want bucket %q", levels, stats.LevelBuckets, want)
+		}
+	}
+}
+
+func TestParseLevelBucketsRejectsInvalidRanges(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"0",
+		"3-0",
+		"4-2",
+		"a-3",
+		"1-4,3-6",
+		"2,1-3",
+		"5+,10-12",
+		"5+,8+",
+	} {
+		if _, err := ParseLevelBuckets(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
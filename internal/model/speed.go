@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Speed is a movement speed in meters per second
+type Speed float32
+
+// SpeedFromMs creates a speed from meters per second
+func SpeedFromMs(metersPerSecond float64) Speed {
+	return Speed(metersPerSecond)
+}
+
+// SpeedFromKmh creates a speed from kilometers per hour
+func SpeedFromKmh(kmh float64) Speed {
+	return Speed(kmh * 1000 / 3600)
+}
+
+// Ms returns the speed in meters per second
+func (s Speed) Ms() float64 {
+	return float64(s)
+}
+
+// Kmh returns the speed in kilometers per hour
+func (s Speed) Kmh() float64 {
+	return float64(s) * 3600 / 1000
+}
+
+// DistanceOver returns the distance in meters covered at this speed during d
+func (s Speed) DistanceOver(d time.Duration) float64 {
+	return float64(s) * d.Seconds()
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpeedKmhToMs(t *testing.T) {
+	speed := SpeedFromKmh(36)
+	if speed != SpeedFromMs(10) || speed.Ms() != 10 {
+		t.Fatalf("36 km/h = %v m/s, want 10", speed.Ms())
+	}
+	if kmh := SpeedFromMs(10).Kmh(); kmh != 36 {
+		t.Fatalf("10 m/s = %v km/h, want 36", kmh)
+	}
+}
+
+func TestSpeedDistanceOver(t *testing.T) {
+	cases := []struct {
+		speed    Speed
+		duration time.Duration
+		want     float64
+	}{
+		{SpeedFromMs(10), 5 * time.Second, 50},
+		{SpeedFromKmh(36), time.Minute, 600},
+		{SpeedFromMs(1.5), 500 * time.Millisecond, 0.75},
+		{SpeedFromMs(10), 0, 0},
+	}
+	for _, c := range cases {
+		if got := c.speed.DistanceOver(c.duration); math.Abs(got-c.want) > 1e-9 {
+			t.Fatalf("%v m/s over %v = %v m, want %v", c.speed.Ms(), c.duration, got, c.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"metalink/internal/util"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,15 +12,26 @@ import (
 type TargetState int
 
 const (
-	TargetStateWalking TargetState = iota
-	TargetStateStopped
+	TargetStateWalking  TargetState = iota // Moves along the route once and stops at the end
+	TargetStateStopped                     // Doesn't move
+	TargetStateLooping                     // Wraps back to the first route point at the end
+	TargetStatePingPong                    // Reverses direction at each end of the route
 )
 
+// RouteNotStarted is the NextPointIndex of a target that hasn't been placed on its route yet
+// Movement starts such targets at the first route point
+const RouteNotStarted = -1
+
+// IsMoving reports whether targets in this state move along their route
+func (s TargetState) IsMoving() bool {
+	return s == TargetStateWalking || s == TargetStateLooping || s == TargetStatePingPong
+}
+
 // TargetPG is the model for PostgreSQL storage
 type TargetPG struct {
 	ID             string      `gorm:"primaryKey"`
 	Name           string      `gorm:"size:255;not null"`
-	Speed          float32     `gorm:"not null"`
+	Speed          float32     `gorm:"not null"` // Meters per second
 	TargetLat      float32     `gorm:"not null"`
 	TargetLng      float32     `gorm:"not null"`
 	Route          string      `gorm:"type:text"`
@@ -26,6 +39,8 @@ type TargetPG struct {
 	NextPointIndex int         `gorm:""`
 	CurrentLat     float32     `gorm:""`
 	CurrentLng     float32     `gorm:""`
+	CurrentBearing float32     `gorm:""`
+	MovingBackward bool        `gorm:"not null;default:false"`
 
 	UpdatedAt time.Time      `gorm:"column:updated_at"`
 	CreatedAt time.Time      `gorm:"column:created_at"`
@@ -40,13 +55,15 @@ func (TargetPG) TableName() string {
 // TargetRedis is the model for Redis storage
 type TargetRedis struct {
 	ID             string      `json:"id"`
-	Speed          float32     `json:"speed"`
+	Speed          float32     `json:"speed"` // Meters per second
 	TargetLat      float32     `json:"target_lat"`
 	TargetLng      float32     `json:"target_lng"`
 	State          TargetState `json:"state"`
 	NextPointIndex int         `json:"next_point_index"`
 	CurrentLat     float32     `json:"current_lat"`
 	CurrentLng     float32     `json:"current_lng"`
+	CurrentBearing float32     `json:"current_bearing"`
+	MovingBackward bool        `json:"moving_backward"`
 	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
@@ -54,7 +71,7 @@ type TargetRedis struct {
 type Target struct {
 	ID             string
 	Name           string
-	Speed          float32
+	Speed          Speed
 	TargetLat      float32
 	TargetLng      float32
 	Route          string
@@ -62,25 +79,66 @@ type Target struct {
 	NextPointIndex int
 	CurrentLat     float32
 	CurrentLng     float32
+	CurrentBearing float32 // Heading in degrees, 0 = north, 90 = east
+	MovingBackward bool    // Direction along the route for TargetStatePingPong
 
 	UpdatedAt time.Time
 	CreatedAt time.Time
 	DeletedAt gorm.DeletedAt
 
 	RoutePoints [][2]float64 // For runtime calculations only
+	routeOnce   sync.Once    // Guards lazy decoding of RoutePoints
+	LastZoneIDs []string     // Sorted zone IDs from the previous tick, in memory only (nil = not known yet)
+}
+
+// EnsureRoutePoints decodes the encoded route into RoutePoints once and returns them
+// Already set RoutePoints are kept as is
+func (t *Target) EnsureRoutePoints() [][2]float64 {
+	t.routeOnce.Do(func() {
+		if t.RoutePoints == nil {
+			t.RoutePoints = util.DecodePolyline(t.Route)
+		}
+	})
+	return t.RoutePoints
+}
+
+// Clone returns a copy of the target for readers outside the movement loop
+// Route points and zone IDs are shared, they are replaced rather than modified in place
+func (t *Target) Clone() *Target {
+	return &Target{
+		ID:             t.ID,
+		Name:           t.Name,
+		Speed:          t.Speed,
+		TargetLat:      t.TargetLat,
+		TargetLng:      t.TargetLng,
+		Route:          t.Route,
+		State:          t.State,
+		NextPointIndex: t.NextPointIndex,
+		CurrentLat:     t.CurrentLat,
+		CurrentLng:     t.CurrentLng,
+		CurrentBearing: t.CurrentBearing,
+		MovingBackward: t.MovingBackward,
+		UpdatedAt:      t.UpdatedAt,
+		CreatedAt:      t.CreatedAt,
+		DeletedAt:      t.DeletedAt,
+		RoutePoints:    t.RoutePoints,
+		LastZoneIDs:    t.LastZoneIDs,
+	}
 }
 
 // ToRedis converts a Target to TargetRedis
 func (t *Target) ToRedis() *TargetRedis {
 	return &TargetRedis{
 		ID:             t.ID,
-		Speed:          t.Speed,
+		Speed:          float32(t.Speed),
 		TargetLat:      t.TargetLat,
 		TargetLng:      t.TargetLng,
 		State:          t.State,
 		NextPointIndex: t.NextPointIndex,
 		CurrentLat:     t.CurrentLat,
 		CurrentLng:     t.CurrentLng,
+		CurrentBearing: t.CurrentBearing,
+		MovingBackward: t.MovingBackward,
 		UpdatedAt:      t.UpdatedAt,
 	}
 }
@@ -90,7 +148,7 @@ func (t *Target) ToPG() *TargetPG {
 	return &TargetPG{
 		ID:             t.ID,
 		Name:           t.Name,
-		Speed:          t.Speed,
+		Speed:          float32(t.Speed),
 		TargetLat:      t.TargetLat,
 		TargetLng:      t.TargetLng,
 		Route:          t.Route,
@@ -98,6 +156,8 @@ func (t *Target) ToPG() *TargetPG {
 		NextPointIndex: t.NextPointIndex,
 		CurrentLat:     t.CurrentLat,
 		CurrentLng:     t.CurrentLng,
+		CurrentBearing: t.CurrentBearing,
+		MovingBackward: t.MovingBackward,
 		UpdatedAt:      t.UpdatedAt,
 		CreatedAt:      t.CreatedAt,
 		DeletedAt:      t.DeletedAt,
@@ -109,7 +169,7 @@ func FromPG(pg *TargetPG) *Target {
 	return &Target{
 		ID:             pg.ID,
 		Name:           pg.Name,
-		Speed:          pg.Speed,
+		Speed:          Speed(pg.Speed),
 		TargetLat:      pg.TargetLat,
 		TargetLng:      pg.TargetLng,
 		Route:          pg.Route,
@@ -117,6 +177,8 @@ func FromPG(pg *TargetPG) *Target {
 		NextPointIndex: pg.NextPointIndex,
 		CurrentLat:     pg.CurrentLat,
 		CurrentLng:     pg.CurrentLng,
+		CurrentBearing: pg.CurrentBearing,
+		MovingBackward: pg.MovingBackward,
 		UpdatedAt:      pg.UpdatedAt,
 		CreatedAt:      pg.CreatedAt,
 		DeletedAt:      pg.DeletedAt,
@@ -127,13 +189,15 @@ func FromPG(pg *TargetPG) *Target {
 func FromRedis(r *TargetRedis) *Target {
 	return &Target{
 		ID:             r.ID,
-		Speed:          r.Speed,
+		Speed:          Speed(r.Speed),
 		TargetLat:      r.TargetLat,
 		TargetLng:      r.TargetLng,
 		State:          r.State,
 		NextPointIndex: r.NextPointIndex,
 		CurrentLat:     r.CurrentLat,
 		CurrentLng:     r.CurrentLng,
+		CurrentBearing: r.CurrentBearing,
+		MovingBackward: r.MovingBackward,
 		UpdatedAt:      r.UpdatedAt,
 	}
 }
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"metalink/internal/util"
+)
+
+// testRoutePolyline is an encoded route of 200 points
+var testRoutePolyline = func() string {
+	points := make([][2]float64, 200)
+	for i := range points {
+		points[i] = [2]float64{40 + float64(i)*0.0001, -100 + float64(i%7)*0.0001}
+	}
+	return util.EncodePolyline(points)
+}()
+
+func TestEnsureRoutePointsDecodesOnce(t *testing.T) {
+	target := &Target{Route: testRoutePolyline}
+	first := target.EnsureRoutePoints()
+	if len(first) != 200 {
+		t.Fatalf("%d route points, want 200", len(first))
+	}
+
+	// Decoding allocates the points; later calls return the cached slice without decoding
+	if allocs := testing.AllocsPerRun(100, func() { target.EnsureRoutePoints() }); allocs != 0 {
+		t.Fatalf("%v allocations per call after warm-up, want 0", allocs)
+	}
+	if again := target.EnsureRoutePoints(); &again[0] != &first[0] {
+		t.Fatal("route points were decoded again")
+	}
+}
+
+func BenchmarkEnsureRoutePoints(b *testing.B) {
+	target := &Target{Route: testRoutePolyline}
+	target.EnsureRoutePoints()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		target.EnsureRoutePoints()
+	}
+}
+
+// BenchmarkDecodePolyline is the cost EnsureRoutePoints saves on every tick after the first
+func BenchmarkDecodePolyline(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		util.DecodePolyline(testRoutePolyline)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"math"
 	"metalink/cmd/osm-zone-parser/mappers"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
 	"gorm.io/gorm"
 )
 
@@ -27,6 +31,69 @@ const (
 	// ... other target param types
 )
 
+// targetParamTypeNames maps param types to their JSON names
+var targetParamTypeNames = map[TargetParamType]string{
+	TargetParamTypeHealth:             "health",
+	TargetParamTypeStamina:            "stamina",
+	TargetParamTypeStrength:           "strength",
+	TargetParamTypeSleepQuality:       "sleep_quality",
+	TargetParamTypeFoodSearch:         "food_search",
+	TargetParamTypeWaterSearch:        "water_search",
+	TargetParamTypeMedicineSearch:     "medicine_search",
+	TargetParamTypeAirQuality:         "air_quality",
+	TargetParamTypeStaminaConsumption: "stamina_consumption",
+}
+
+// String returns the snake_case name of the param type
+func (t TargetParamType) String() string {
+	if name, ok := targetParamTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("param_type_%d", int(t))
+}
+
+// ParseTargetParamType parses a param type from its name
+func ParseTargetParamType(name string) (TargetParamType, error) {
+	for paramType, paramName := range targetParamTypeNames {
+		if paramName == name {
+			return paramType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown target param type %q", name)
+}
+
+// MarshalJSON encodes the param type as its name
+func (t TargetParamType) MarshalJSON() ([]byte, error) {
+	name, ok := targetParamTypeNames[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown target param type %d", int(t))
+	}
+	return json.Marshal(name)
+}
+
+// UnmarshalJSON decodes the param type from its name or from the legacy integer value
+func (t *TargetParamType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		paramType, err := ParseTargetParamType(name)
+		if err != nil {
+			return err
+		}
+		*t = paramType
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("cannot decode target param type from %s", string(data))
+	}
+	if _, ok := targetParamTypeNames[TargetParamType(value)]; !ok {
+		return fmt.Errorf("unknown target param type %d", value)
+	}
+	*t = TargetParamType(value)
+	return nil
+}
+
 // Float64Slice is a custom type for JSONB serialization of []float64
 type Float64Slice []float64
 
@@ -67,6 +134,12 @@ type BuildingStats struct {
 	TotalArea     float64            `json:"total_area"`
 	BuildingTypes map[string]int     `json:"building_types"` // Count by building type
 	BuildingAreas map[string]float64 `json:"building_areas"` // Total area by building type
+
+	// Count by raw OSM building type (only filled when raw types are kept by the parser)
+	RawBuildingTypes map[string]int `json:"raw_building_types,omitempty"`
+
+	// Stats by custom level range name (only filled when the parser is given level buckets)
+	LevelBuckets map[string]BucketStat `json:"level_buckets,omitempty"`
 }
 
 // Value implements the driver.Valuer interface for database serialization
@@ -112,12 +185,62 @@ func (wbs *WaterBodyStats) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, wbs)
 }
 
+// POIStats holds the counts of points of interest (amenity, shop and leisure nodes) around a zone
+type POIStats struct {
+	TotalCount int            `json:"total_count"`
+	Categories map[string]int `json:"categories,omitempty"` // Count by game category
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (ps POIStats) Value() (driver.Value, error) {
+	return json.Marshal(ps)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+// NULL values (zones saved before POIs were processed) result in empty stats
+func (ps *POIStats) Scan(value interface{}) error {
+	if value == nil {
+		*ps = POIStats{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot convert %T to POIStats", value)
+	}
+	return json.Unmarshal(bytes, ps)
+}
+
 // ZoneEffect represents an effect that a zone has on targets inside it
-// These effects are calculated dynamically and not stored in DB
+// These effects are calculated from building areas and cached in DB as a snapshot
 // Positive values are buffs, negative values are debuffs
 type ZoneEffect struct {
-	ResourceType TargetParamType
-	Value        float32 // Positive for buff, negative for debuff
+	ResourceType TargetParamType `json:"resource_type"`
+	Value        float32         `json:"value"` // Positive for buff, negative for debuff
+}
+
+// ZoneEffects is a custom type for JSONB serialization of precomputed zone effects
+type ZoneEffects []ZoneEffect
+
+// Value implements the driver.Valuer interface for database serialization
+func (ze ZoneEffects) Value() (driver.Value, error) {
+	if ze == nil {
+		return nil, nil
+	}
+	return json.Marshal(ze)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+// NULL values (zones saved before the effects cache existed) result in empty effects
+func (ze *ZoneEffects) Scan(value interface{}) error {
+	if value == nil {
+		*ze = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot convert %T to ZoneEffects", value)
+	}
+	return json.Unmarshal(bytes, ze)
 }
 
 // ZonePG model for PostgreSQL storage
@@ -128,9 +251,12 @@ type ZonePG struct {
 	TopRightLatLon    Float64Slice `gorm:"type:jsonb;not null"`
 	BottomLeftLatLon  Float64Slice `gorm:"type:jsonb;not null"`
 	BottomRightLatLon Float64Slice `gorm:"type:jsonb;not null"`
+	Geometry          string       `gorm:"type:text"` // Optional GeoJSON polygon for non-rectangular zones
 
 	Buildings   BuildingStats  `gorm:"type:jsonb"`
 	WaterBodies WaterBodyStats `gorm:"type:jsonb"`
+	POIStats    POIStats       `gorm:"type:jsonb;column:poi_stats"`
+	ZoneEffects ZoneEffects    `gorm:"type:jsonb;column:zone_effects"` // Precomputed effects cache (may be NULL)
 
 	UpdatedAt time.Time      `gorm:"column:updated_at"`
 	CreatedAt time.Time      `gorm:"column:created_at"`
@@ -143,6 +269,8 @@ func (ZonePG) TableName() string {
 }
 
 // Zone in-memory model
+// Corner fields are [lat, lon] and must form a simple quad in the order top-left, top-right,
+// bottom-right, bottom-left (see Validate); Corners and CornersRing give [lon, lat] points
 type Zone struct {
 	ID                string
 	Name              string
@@ -150,11 +278,13 @@ type Zone struct {
 	TopRightLatLon    []float64
 	BottomLeftLatLon  []float64
 	BottomRightLatLon []float64
+	Geometry          string // Optional GeoJSON polygon, corners are used when empty
 
 	Buildings   BuildingStats
 	WaterBodies WaterBodyStats
+	POIStats    POIStats
 
-	// Calculated effects (not stored in DB)
+	// Calculated effects (cached in DB as zone_effects)
 	Effects []ZoneEffect
 
 	UpdatedAt time.Time
@@ -167,10 +297,19 @@ type Zone struct {
 
 	// Processing flags (not stored in DB)
 	RecalculateNeeded bool // Flag to mark zones that need recalculation
+
+	// Incremental effect accumulator (not stored in DB)
+	effectAccumulator map[TargetParamType]float64
 }
 
 // ZoneFromPG creates a Zone from ZonePG
 func ZoneFromPG(pg *ZonePG) *Zone {
+	// Use the precomputed effects snapshot if present, otherwise they'll be calculated on demand
+	effects := []ZoneEffect{}
+	if len(pg.ZoneEffects) > 0 {
+		effects = pg.ZoneEffects
+	}
+
 	return &Zone{
 		ID:                pg.ID,
 		Name:              pg.Name,
@@ -178,91 +317,221 @@ func ZoneFromPG(pg *ZonePG) *Zone {
 		TopRightLatLon:    pg.TopRightLatLon,
 		BottomLeftLatLon:  pg.BottomLeftLatLon,
 		BottomRightLatLon: pg.BottomRightLatLon,
+		Geometry:          pg.Geometry,
 		Buildings:         pg.Buildings,
 		WaterBodies:       pg.WaterBodies,
+		POIStats:          pg.POIStats,
 		UpdatedAt:         pg.UpdatedAt,
 		CreatedAt:         pg.CreatedAt,
 		DeletedAt:         pg.DeletedAt,
-		Effects:           []ZoneEffect{}, // Initialize empty effects, they'll be calculated on demand
+		Effects:           effects,
 	}
 }
 
+// invalidGeometryFallbacks counts BuildPolygon calls that fell back to the corners on an invalid geometry
+var invalidGeometryFallbacks atomic.Int64
+
+// InvalidGeometryFallbacks returns how many times BuildPolygon fell back to the corners
+// because the stored geometry of a zone was invalid
+func InvalidGeometryFallbacks() int64 {
+	return invalidGeometryFallbacks.Load()
+}
+
+// BuildPolygon returns the zone shape in [lon, lat] order
+// Uses the stored GeoJSON geometry when present and valid, falling back to the four corners
+// Fallbacks on an invalid geometry are counted, see InvalidGeometryFallbacks
+func (z *Zone) BuildPolygon() orb.Polygon {
+	polygon, err := z.GeometryPolygon()
+	if err != nil {
+		invalidGeometryFallbacks.Add(1)
+	}
+	if polygon == nil {
+		return orb.Polygon{z.CornersRing()}
+	}
+	return polygon
+}
+
+// GeometryPolygon returns the stored GeoJSON geometry of the zone in [lon, lat] order
+// Returns nil without an error when the zone has no stored geometry
+func (z *Zone) GeometryPolygon() (orb.Polygon, error) {
+	if z.Geometry == "" {
+		return nil, nil
+	}
+	polygon, err := parseGeometryPolygon(z.Geometry)
+	if err != nil {
+		return nil, fmt.Errorf("zone %s: %w", z.ID, err)
+	}
+	return polygon, nil
+}
+
+// parseGeometryPolygon parses a GeoJSON Polygon geometry (or a Feature containing one)
+func parseGeometryPolygon(data string) (orb.Polygon, error) {
+	var geometry orb.Geometry
+	if g, err := geojson.UnmarshalGeometry([]byte(data)); err == nil {
+		geometry = g.Geometry()
+	} else if f, err := geojson.UnmarshalFeature([]byte(data)); err == nil {
+		geometry = f.Geometry
+	} else {
+		return nil, fmt.Errorf("invalid GeoJSON geometry: %w", err)
+	}
+
+	polygon, ok := geometry.(orb.Polygon)
+	if !ok || len(polygon) == 0 || len(polygon[0]) < 4 {
+		return nil, fmt.Errorf("geometry is not a valid polygon")
+	}
+	return polygon, nil
+}
+
 // CalculateEffects calculates zone effects based on building types and their areas
+// Contributions are accumulated in float64 in a fixed (sorted) order so the result
+// is deterministic regardless of map iteration order and repeated calls give the same effects
+// Writes the zone, so it must not be called on a zone that other goroutines may be reading
 func (z *Zone) CalculateEffects() {
-	z.Effects = []ZoneEffect{}
+	z.effectAccumulator = nil
+	z.Effects = effectsFromAccumulator(z.accumulateBuildingAreas())
+}
 
-	// Initialize effect accumulator map
-	effectAccumulator := make(map[TargetParamType]float32)
+// AddBuildingEffects adds the effects of a building area to the zone's incremental accumulator
+// Must be called before the area is added to Buildings.BuildingAreas, since areas already
+// present are folded into the accumulator on first use
+// Does nothing for non-linear area curves, where effects of area parts don't add up;
+// FinalizeEffects then reports false and effects must be calculated from the totals
+func (z *Zone) AddBuildingEffects(buildingType string, buildingArea float64) {
+	if !mappers.GetAreaCurve().IsLinear() {
+		return
+	}
+	if z.effectAccumulator == nil {
+		z.effectAccumulator = z.accumulateBuildingAreas()
+	}
+	z.accumulateEffects(z.effectAccumulator, buildingType, buildingArea)
+}
 
-	// Process building effects based on building types and areas
-	for buildingType, buildingArea := range z.Buildings.BuildingAreas {
-		if buildingArea <= 0 {
-			continue
-		}
+// FinalizeEffects sets Effects from the incremental accumulator
+// Returns false if no effects were accumulated incrementally
+func (z *Zone) FinalizeEffects() bool {
+	if z.effectAccumulator == nil {
+		return false
+	}
+	z.Effects = effectsFromAccumulator(z.effectAccumulator)
+	return true
+}
 
-		// Get effects configuration for this building type
-		effects := mappers.GetBuildingEffects(buildingType)
-		if effects == nil {
-			continue
-		}
+// accumulateBuildingAreas accumulates effects of all building areas in sorted type order
+// Points of interest count as their building area equivalent
+func (z *Zone) accumulateBuildingAreas() map[TargetParamType]float64 {
+	effectAccumulator := make(map[TargetParamType]float64)
+	z.ForEachCategoryEffect(func(_ string, paramType TargetParamType, value float64) {
+		effectAccumulator[paramType] += value
+	})
+	return effectAccumulator
+}
 
-		// Calculate area coefficient (effect strength based on area)
-		areaCoefficient := z.calculateAreaCoefficient(buildingArea)
+// ForEachCategoryEffect calls fn with every non-zero effect of every building category
+// Categories are visited in sorted order instead of random map order, so summing the values
+// in call order gives exactly the effects of CalculateEffects
+func (z *Zone) ForEachCategoryEffect(fn func(category string, paramType TargetParamType, value float64)) {
+	effectAreas := z.effectAreas()
 
-		// Apply each effect type (preserving original sign - positive for buff, negative for debuff)
-		if effects.SleepQuality != 0 {
-			effectValue := float32(effects.SleepQuality) * areaCoefficient
-			effectAccumulator[TargetParamTypeSleepQuality] += effectValue
-		}
+	buildingTypes := make([]string, 0, len(effectAreas))
+	for buildingType := range effectAreas {
+		buildingTypes = append(buildingTypes, buildingType)
+	}
+	sort.Strings(buildingTypes)
 
-		if effects.FoodSearch != 0 {
-			effectValue := float32(effects.FoodSearch) * areaCoefficient
-			effectAccumulator[TargetParamTypeFoodSearch] += effectValue
-		}
+	for _, buildingType := range buildingTypes {
+		z.forEachBuildingEffect(buildingType, effectAreas[buildingType], func(paramType TargetParamType, value float64) {
+			fn(buildingType, paramType, value)
+		})
+	}
+}
 
-		if effects.WaterSearch != 0 {
-			effectValue := float32(effects.WaterSearch) * areaCoefficient
-			effectAccumulator[TargetParamTypeWaterSearch] += effectValue
-		}
+// accumulateEffects adds the effects of a single building type and area to the accumulator
+func (z *Zone) accumulateEffects(effectAccumulator map[TargetParamType]float64, buildingType string, buildingArea float64) {
+	z.forEachBuildingEffect(buildingType, buildingArea, func(paramType TargetParamType, value float64) {
+		effectAccumulator[paramType] += value
+	})
+}
 
-		if effects.MedicineSearch != 0 {
-			effectValue := float32(effects.MedicineSearch) * areaCoefficient
-			effectAccumulator[TargetParamTypeMedicineSearch] += effectValue
-		}
+// forEachBuildingEffect calls fn with every non-zero effect of a building type for the given area
+func (z *Zone) forEachBuildingEffect(buildingType string, buildingArea float64, fn func(paramType TargetParamType, value float64)) {
+	if buildingArea <= 0 {
+		return
+	}
 
-		if effects.AirQuality != 0 {
-			effectValue := float32(effects.AirQuality) * areaCoefficient
-			effectAccumulator[TargetParamTypeAirQuality] += effectValue
-		}
+	// Get effects configuration for this building type
+	effects := mappers.GetBuildingEffects(buildingType)
+	if effects == nil {
+		return
+	}
 
-		if effects.StaminaConsumption != 0 {
-			effectValue := float32(effects.StaminaConsumption) * areaCoefficient
-			effectAccumulator[TargetParamTypeStaminaConsumption] += effectValue
-		}
+	// Calculate area coefficient (effect strength based on area)
+	areaCoefficient := float64(calculateAreaCoefficient(buildingArea, mappers.GetAreaCurve()))
+
+	// Apply each effect type (preserving original sign - positive for buff, negative for debuff)
+	if effects.SleepQuality != 0 {
+		fn(TargetParamTypeSleepQuality, float64(effects.SleepQuality)*areaCoefficient)
+	}
+
+	if effects.FoodSearch != 0 {
+		fn(TargetParamTypeFoodSearch, float64(effects.FoodSearch)*areaCoefficient)
+	}
+
+	if effects.WaterSearch != 0 {
+		fn(TargetParamTypeWaterSearch, float64(effects.WaterSearch)*areaCoefficient)
 	}
 
-	// Convert accumulated effects to ZoneEffect array
-	for paramType, value := range effectAccumulator {
+	if effects.MedicineSearch != 0 {
+		fn(TargetParamTypeMedicineSearch, float64(effects.MedicineSearch)*areaCoefficient)
+	}
+
+	if effects.AirQuality != 0 {
+		fn(TargetParamTypeAirQuality, float64(effects.AirQuality)*areaCoefficient)
+	}
+
+	if effects.StaminaConsumption != 0 {
+		fn(TargetParamTypeStaminaConsumption, float64(effects.StaminaConsumption)*areaCoefficient)
+	}
+}
+
+// effectsFromAccumulator converts accumulated effects to a ZoneEffect array ordered by param type
+func effectsFromAccumulator(effectAccumulator map[TargetParamType]float64) []ZoneEffect {
+	paramTypes := make([]TargetParamType, 0, len(effectAccumulator))
+	for paramType := range effectAccumulator {
+		paramTypes = append(paramTypes, paramType)
+	}
+	sort.Slice(paramTypes, func(i, j int) bool { return paramTypes[i] < paramTypes[j] })
+
+	effects := []ZoneEffect{}
+	for _, paramType := range paramTypes {
+		value := float32(effectAccumulator[paramType]) // Downcast only once, after summation
 		if value == 0 {
 			continue
 		}
 
-		effect := ZoneEffect{
+		effects = append(effects, ZoneEffect{
 			ResourceType: paramType,
 			Value:        value, // Keep the original sign (positive for buff, negative for debuff)
-		}
-
-		z.Effects = append(z.Effects, effect)
+		})
 	}
+
+	return effects
 }
 
-// calculateAreaCoefficient calculates coefficient based on building area
-func (z *Zone) calculateAreaCoefficient(buildingArea float64) float32 {
+// calculateAreaCoefficient calculates coefficient based on building area using the given curve
+func calculateAreaCoefficient(buildingArea float64, curve mappers.AreaCurve) float32 {
 	if buildingArea <= 0 {
 		return 0
 	}
 
-	// Simple area-based scaling
-	// Convert area to coefficient (1000 sq meters = 1.0 coefficient)
-	return float32(buildingArea) / 1000.0
+	switch curve.Type {
+	case mappers.AreaCurveLogarithmic:
+		// Keeps growing with area, but each doubling adds less
+		return float32(math.Log1p(buildingArea / curve.Scale))
+	case mappers.AreaCurveSaturating:
+		// Approaches curve.Max for very large areas
+		return float32(curve.Max * buildingArea / (buildingArea + curve.HalfArea))
+	default:
+		// Simple area-based scaling (1000 sq meters = 1.0 coefficient by default)
+		return float32(buildingArea / curve.Scale)
+	}
 }
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/planar"
+)
+
+// Centroid returns the area centroid of the zone shape as a [lon, lat] point
+// Uses the cached polygon when set
+func (z *Zone) Centroid() orb.Point {
+	centroid, _ := planar.CentroidArea(z.shape())
+	return centroid
+}
+
+// MinPolygonArea is the area in square meters below which a zone polygon is degenerate (collapsed to a line or point)
+const MinPolygonArea = 1.0
+
+// PolygonArea returns the geodesic area of the zone shape in square meters (0 for an empty shape)
+// Uses the cached polygon when set
+func (z *Zone) PolygonArea() float64 {
+	polygon := z.shape()
+	if len(polygon) == 0 {
+		return 0
+	}
+	return math.Abs(geo.Area(polygon))
+}
+
+// IsDegenerate reports whether the zone shape has (almost) no area
+func (z *Zone) IsDegenerate() bool {
+	return z.PolygonArea() < MinPolygonArea
+}
+
+// NormalizedDistance returns how far a [lon, lat] point is from the zone centroid relative to
+// the farthest vertex of the zone: 0 at the centroid, 1 at the farthest corner (more outside the zone)
+// Degenerate zones have no meaningful centroid and always return 0
+func (z *Zone) NormalizedDistance(point orb.Point) float64 {
+	polygon := z.shape()
+	if len(polygon) == 0 || z.IsDegenerate() {
+		return 0
+	}
+
+	centroid, _ := planar.CentroidArea(polygon)
+	var maxDistance float64
+	for _, vertex := range polygon[0] {
+		maxDistance = max(maxDistance, geo.Distance(centroid, vertex))
+	}
+	if maxDistance == 0 {
+		return 0
+	}
+
+	return geo.Distance(centroid, point) / maxDistance
+}
+
+// shape returns the cached polygon or builds it
+func (z *Zone) shape() orb.Polygon {
+	if z.Polygon != nil {
+		return *z.Polygon
+	}
+	return z.BuildPolygon()
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestNormalizedDistance(t *testing.T) {
+	zone := squareZone(40, -100, 0.01)
+	centroid := zone.Centroid()
+
+	if d := zone.NormalizedDistance(centroid); d > 1e-9 {
+		t.Fatalf("distance at the centroid is %v, want 0", d)
+	}
+	// The farthest corner is at distance 1; the corners of a small square are almost equidistant
+	if d := zone.NormalizedDistance(orb.Point{-100, 40}); math.Abs(d-1) > 0.01 {
+		t.Fatalf("distance at a corner is %v, want ~1", d)
+	}
+	// Meters per degree of longitude shrink with cos(lat), so the bottom edge midpoint is nearer than sqrt(2)/2
+	cosLat := math.Cos(40.005 * math.Pi / 180)
+	want := 1 / math.Sqrt(1+cosLat*cosLat)
+	if d := zone.NormalizedDistance(orb.Point{centroid[0], 40}); math.Abs(d-want) > 0.01 {
+		t.Fatalf("distance at the bottom edge midpoint is %v, want ~%.2f", d, want)
+	}
+}
+
+func TestCentroidOfLShapeIsAreaWeighted(t *testing.T) {
+	// A 2x1 block with a 1x1 block on its left half, in 0.01 degree units
+	const unit = 0.01
+	lShape := orb.Polygon{orb.Ring{
+		{-100, 40}, {-100 + 2*unit, 40}, {-100 + 2*unit, 40 + unit},
+		{-100 + unit, 40 + unit}, {-100 + unit, 40 + 2*unit}, {-100, 40 + 2*unit}, {-100, 40},
+	}}
+	zone := squareZone(40, -100, 2*unit)
+	zone.Polygon = &lShape
+
+	// Weighted by area: (2*(1, 0.5) + 1*(0.5, 1.5)) / 3, not the bounds center (1, 1)
+	want := orb.Point{-100 + unit*5/6, 40 + unit*5/6}
+	centroid := zone.Centroid()
+	if math.Abs(centroid[0]-want[0]) > 1e-9 || math.Abs(centroid[1]-want[1]) > 1e-9 {
+		t.Fatalf("centroid = %v, want %v", centroid, want)
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "github.com/paulmach/orb"
+
+// Corners returns the zone corners as [lon, lat] points
+// Zone corner fields are [lat, lon]; use this instead of flipping indices by hand
+func (z *Zone) Corners() (tl, tr, br, bl orb.Point) {
+	return latLonPoint(z.TopLeftLatLon), latLonPoint(z.TopRightLatLon),
+		latLonPoint(z.BottomRightLatLon), latLonPoint(z.BottomLeftLatLon)
+}
+
+// CornersRing returns the closed ring of the zone corners in [lon, lat] order
+// The ring is counter-clockwise (top-left, bottom-left, bottom-right, top-right) as GeoJSON expects
+// for exterior rings
+func (z *Zone) CornersRing() orb.Ring {
+	tl, tr, br, bl := z.Corners()
+	return orb.Ring{tl, bl, br, tr, tl}
+}
+
+// latLonPoint converts a [lat, lon] corner to an orb point
+func latLonPoint(corner []float64) orb.Point {
+	return orb.Point{corner[1], corner[0]}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestCornersAreLonLat(t *testing.T) {
+	tl, tr, br, bl := squareZone(40, -100, 0.01).Corners()
+	want := []orb.Point{{-100, 40.01}, {-99.99, 40.01}, {-99.99, 40}, {-100, 40}}
+	for i, corner := range []orb.Point{tl, tr, br, bl} {
+		if corner != want[i] {
+			t.Fatalf("corner %d = %v, want %v", i, corner, want[i])
+		}
+	}
+}
+
+func TestCornersRingIsClosedAndCounterClockwise(t *testing.T) {
+	ring := squareZone(40, -100, 0.01).CornersRing()
+
+	if len(ring) != 5 || !ring.Closed() {
+		t.Fatalf("ring %v is not a closed ring of four corners", ring)
+	}
+	if ring.Orientation() != orb.CCW {
+		t.Fatalf("ring orientation = %v, want counter-clockwise", ring.Orientation())
+	}
+}
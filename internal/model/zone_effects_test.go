@@ -0,0 +1,174 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"metalink/cmd/osm-zone-parser/mappers"
+)
+
+var effectsConfigOnce sync.Once
+
+// useTestEffectsConfig makes the bundled effects config the one used by effect calculations
+// The config is cached for the whole test binary, so all tests share it
+func useTestEffectsConfig(t testing.TB) {
+	t.Helper()
+	effectsConfigOnce.Do(func() {
+		config, err := mappers.LoadBuildingEffectsConfig("../../usa_buildings_data/building_cat_kf_config.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mappers.SetBuildingEffectsConfig(config)
+		if err := mappers.InitBuildingEffectsConfig(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// zoneEffect returns the value of a resource in the zone effects, false if the zone has none
+func zoneEffect(zone *Zone, paramType TargetParamType) (float32, bool) {
+	for _, effect := range zone.Effects {
+		if effect.ResourceType == paramType {
+			return effect.Value, true
+		}
+	}
+	return 0, false
+}
+
+func TestNegativeEffectsKeepTheirSign(t *testing.T) {
+	useTestEffectsConfig(t)
+
+	// The bundled config gives farms "air_quality": -10 and hospitals +5 per 1000 m²
+	farm := &Zone{ID: "farm"}
+	farm.Buildings.BuildingAreas = map[string]float64{"agricultural_farming": 2000}
+	farm.CalculateEffects()
+	if value, ok := zoneEffect(farm, TargetParamTypeAirQuality); !ok || value != -20 {
+		t.Fatalf("farm air quality %v (present %v), want -20", value, ok)
+	}
+
+	mixed := &Zone{ID: "mixed"}
+	mixed.Buildings.BuildingAreas = map[string]float64{"agricultural_farming": 2000, "healthcare_medical": 1000}
+	mixed.CalculateEffects()
+	if value, _ := zoneEffect(mixed, TargetParamTypeAirQuality); value != -15 {
+		t.Fatalf("mixed zone air quality %v, want -15", value)
+	}
+}
+
+// testBuildings are (type, area) pairs in input order, with repeated types
+var testBuildings = []struct {
+	buildingType string
+	area         float64
+}{
+	{"residential", 120}, {"food_services", 300}, {"residential", 95.5}, {"agricultural_farming", 2000},
+	{"healthcare_medical", 4200}, {"food_services", 80}, {"industrial_manufacturing", 1500}, {"residential", 210},
+}
+
+// assertSameEffects fails if the effects differ in resources or by more than float rounding
+func assertSameEffects(t *testing.T, got, want []ZoneEffect) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("effects %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].ResourceType != want[i].ResourceType || math.Abs(float64(got[i].Value-want[i].Value)) > 1e-4 {
+			t.Fatalf("effects %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIncrementalEffectsMatchBatch(t *testing.T) {
+	useTestEffectsConfig(t)
+
+	// The first building is already in the stats and gets folded in on the first add
+	incremental := &Zone{ID: "incremental"}
+	incremental.Buildings.BuildingAreas = map[string]float64{testBuildings[0].buildingType: testBuildings[0].area}
+	for _, building := range testBuildings[1:] {
+		incremental.AddBuildingEffects(building.buildingType, building.area)
+		incremental.Buildings.BuildingAreas[building.buildingType] += building.area
+	}
+	if !incremental.FinalizeEffects() {
+		t.Fatal("no effects were accumulated incrementally")
+	}
+
+	batch := &Zone{ID: "batch"}
+	batch.Buildings.BuildingAreas = make(map[string]float64)
+	for _, building := range testBuildings {
+		batch.Buildings.BuildingAreas[building.buildingType] += building.area
+	}
+	batch.CalculateEffects()
+
+	if len(batch.Effects) == 0 {
+		t.Fatal("the buildings have no effects")
+	}
+	assertSameEffects(t, incremental.Effects, batch.Effects)
+}
+
+func TestCalculateAreaCoefficient(t *testing.T) {
+	linear := mappers.AreaCurve{Type: mappers.AreaCurveLinear, Scale: 1000}
+	logarithmic := mappers.AreaCurve{Type: mappers.AreaCurveLogarithmic, Scale: 1000}
+	saturating := mappers.AreaCurve{Type: mappers.AreaCurveSaturating, Max: 3, HalfArea: 5000}
+
+	for _, tc := range []struct {
+		curve mappers.AreaCurve
+		area  float64
+		want  float64
+	}{
+		{linear, 500, 0.5},
+		{linear, 5000, 5},
+		{linear, 500000, 500},
+		{logarithmic, 500, math.Log(1.5)},
+		{logarithmic, 5000, math.Log(6)},
+		{logarithmic, 500000, math.Log(501)},
+		{saturating, 500, 3 * 500.0 / 5500},
+		{saturating, 5000, 1.5},
+		{saturating, 500000, 3 * 500000.0 / 505000},
+		{linear, 0, 0},
+		{saturating, -10, 0},
+	} {
+		got := float64(calculateAreaCoefficient(tc.area, tc.curve))
+		if math.Abs(got-tc.want) > 1e-5*math.Max(1, tc.want) {
+			t.Errorf("%s curve at %v m²: coefficient %v, want %v", tc.curve.Type, tc.area, got, tc.want)
+		}
+	}
+
+	// The saturating curve never reaches its max
+	if big := calculateAreaCoefficient(1e9, saturating); big >= 3 {
+		t.Errorf("saturating coefficient %v at 1e9 m², want below 3", big)
+	}
+}
+
+func TestCalculateEffectsIsDeterministic(t *testing.T) {
+	useTestEffectsConfig(t)
+
+	// Many categories with uneven areas, so a different summation order would change the float result
+	categories := []string{
+		"residential", "commercial_retail", "industrial_manufacturing", "agricultural_farming", "religious_worship",
+		"educational", "healthcare_medical", "government_civic", "hospitality_accommodation", "food_services",
+	}
+	areas := make(map[string]float64, len(categories))
+	for i, category := range categories {
+		areas[category] = 1234.567*float64(i+1) + 0.1/float64(i+1)
+	}
+
+	var first []ZoneEffect
+	for run := 0; run < 20; run++ {
+		// Insert in a different order each run; map iteration is randomized as well
+		zone := &Zone{ID: "deterministic"}
+		zone.Buildings.BuildingAreas = make(map[string]float64, len(areas))
+		for i := range categories {
+			category := categories[(i+run)%len(categories)]
+			zone.Buildings.BuildingAreas[category] = areas[category]
+		}
+		zone.CalculateEffects()
+
+		if run == 0 {
+			first = zone.Effects
+			continue
+		}
+		if !reflect.DeepEqual(zone.Effects, first) {
+			t.Fatalf("run %d effects %v, want the first run's %v", run, zone.Effects, first)
+		}
+	}
+}
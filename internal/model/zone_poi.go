@@ -0,0 +1,36 @@
+package model
+
+import mappers "metalink/cmd/osm-zone-parser/mappers"
+
+// AddPOI counts a point of interest of the given game category in the zone
+// A POI has no area of its own, its effects are those of the configured POI effect area of its category
+func (z *Zone) AddPOI(category string) {
+	// Like AddBuildingEffects, POIs already counted are folded into the accumulator on first use
+	if z.effectAccumulator != nil {
+		z.accumulateEffects(z.effectAccumulator, category, mappers.GetPOIEffectArea())
+	}
+
+	if z.POIStats.Categories == nil {
+		z.POIStats.Categories = make(map[string]int)
+	}
+	z.POIStats.Categories[category]++
+	z.POIStats.TotalCount++
+}
+
+// effectAreas returns the area by game category that effects are calculated from:
+// building areas plus the area equivalent of counted points of interest
+func (z *Zone) effectAreas() map[string]float64 {
+	if len(z.POIStats.Categories) == 0 {
+		return z.Buildings.BuildingAreas
+	}
+
+	poiArea := mappers.GetPOIEffectArea()
+	areas := make(map[string]float64, len(z.Buildings.BuildingAreas)+len(z.POIStats.Categories))
+	for category, area := range z.Buildings.BuildingAreas {
+		areas[category] = area
+	}
+	for category, count := range z.POIStats.Categories {
+		areas[category] += float64(count) * poiArea
+	}
+	return areas
+}
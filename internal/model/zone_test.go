@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestBuildPolygonCountsInvalidGeometryFallbacks(t *testing.T) {
+	zone := &Zone{
+		ID:                "z",
+		TopLeftLatLon:     []float64{40.01, -100},
+		TopRightLatLon:    []float64{40.01, -99.99},
+		BottomRightLatLon: []float64{40, -99.99},
+		BottomLeftLatLon:  []float64{40, -100},
+	}
+	before := InvalidGeometryFallbacks()
+
+	// No stored geometry: the corners are used without counting a fallback
+	if polygon := zone.BuildPolygon(); len(polygon[0]) != 5 || InvalidGeometryFallbacks() != before {
+		t.Fatalf("corner polygon %v, fallbacks %d; want 5 points and no fallback", polygon, InvalidGeometryFallbacks()-before)
+	}
+
+	zone.Geometry = `{"type":"Polygon","coordinates":[[[-100,40],[-99.99,40],[-100,40]]]}`
+	if _, err := zone.GeometryPolygon(); err == nil {
+		t.Fatal("expected an error for a polygon with 3 points")
+	}
+	if polygon := zone.BuildPolygon(); len(polygon[0]) != 5 || InvalidGeometryFallbacks() != before+1 {
+		t.Fatalf("fallback polygon %v, fallbacks %d; want the corners and 1 fallback", polygon, InvalidGeometryFallbacks()-before)
+	}
+
+	zone.Geometry = `{"type":"Polygon","coordinates":[[[-100,40],[-99.99,40],[-99.99,40.01],[-99.995,40.015],[-100,40.01],[-100,40]]]}`
+	if polygon := zone.BuildPolygon(); len(polygon[0]) != 6 || InvalidGeometryFallbacks() != before+1 {
+		t.Fatalf("geometry polygon %v, fallbacks %d; want the stored 6 points", polygon, InvalidGeometryFallbacks()-before)
+	}
+}
+
+func TestTargetParamTypeRoundTrip(t *testing.T) {
+	for paramType := TargetParamTypeHealth; paramType <= TargetParamTypeStaminaConsumption; paramType++ {
+		name := paramType.String()
+		if parsed, err := ParseTargetParamType(name); err != nil || parsed != paramType {
+			t.Fatalf("ParseTargetParamType(%q) = %v, %v; want %d", name, parsed, err, paramType)
+		}
+
+		data, err := json.Marshal(paramType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `"`+name+`"` {
+			t.Fatalf("%d encodes as %s, want %q", paramType, data, name)
+		}
+		var decoded TargetParamType
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != paramType {
+			t.Fatalf("%s decodes as %v, %v; want %d", data, decoded, err, paramType)
+		}
+
+		// Zones saved before the names still hold the integer values
+		var legacy TargetParamType
+		if err := json.Unmarshal([]byte(fmt.Sprint(int(paramType))), &legacy); err != nil || legacy != paramType {
+			t.Fatalf("legacy %d decodes as %v, %v", int(paramType), legacy, err)
+		}
+	}
+
+	if _, err := ParseTargetParamType("mana"); err == nil {
+		t.Fatal("expected an error for an unknown name")
+	}
+	var decoded TargetParamType
+	for _, data := range []string{`"mana"`, `99`, `true`} {
+		if err := json.Unmarshal([]byte(data), &decoded); err == nil {
+			t.Errorf("decoding %s: expected an error", data)
+		}
+	}
+	if _, err := json.Marshal(TargetParamType(99)); err == nil {
+		t.Fatal("expected an error encoding an unknown param type")
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks that the zone corners have valid coordinates and form a simple convex quad
+// when taken in the order top-left, top-right, bottom-right, bottom-left
+func (z *Zone) Validate() error {
+	corners := []struct {
+		name  string
+		value []float64
+	}{
+		{"top-left", z.TopLeftLatLon},
+		{"top-right", z.TopRightLatLon},
+		{"bottom-right", z.BottomRightLatLon},
+		{"bottom-left", z.BottomLeftLatLon},
+	}
+
+	// Corners as [lon, lat] in ring order
+	points := make([][2]float64, len(corners))
+	for i, corner := range corners {
+		if len(corner.value) != 2 {
+			return fmt.Errorf("zone %s: %s corner must have 2 coordinates, got %d", z.ID, corner.name, len(corner.value))
+		}
+		lat, lon := corner.value[0], corner.value[1]
+		if math.IsNaN(lat) || math.IsNaN(lon) {
+			return fmt.Errorf("zone %s: %s corner [%v, %v] is not a number", z.ID, corner.name, lat, lon)
+		}
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return fmt.Errorf("zone %s: %s corner [%v, %v] is out of range", z.ID, corner.name, lat, lon)
+		}
+		points[i] = [2]float64{lon, lat}
+	}
+
+	// A simple convex quad turns the same way at every corner; a bowtie (swapped corners)
+	// or a degenerate quad doesn't
+	var sign float64
+	for i := range points {
+		turn := cross(points[i], points[(i+1)%4], points[(i+2)%4])
+		if turn == 0 {
+			return fmt.Errorf("zone %s: corners are collinear or duplicated", z.ID)
+		}
+		if sign == 0 {
+			sign = turn
+		} else if (turn > 0) != (sign > 0) {
+			return fmt.Errorf("zone %s: corners don't form a simple convex quad (check corner order)", z.ID)
+		}
+	}
+
+	return nil
+}
+
+// cross returns the z component of (b - a) x (c - b), positive for a left turn at b
+func cross(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-b[1]) - (b[1]-a[1])*(c[0]-b[0])
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZoneValidate(t *testing.T) {
+	if err := squareZone(40, -100, 0.01).Validate(); err != nil {
+		t.Fatalf("valid zone: %v", err)
+	}
+
+	nan := math.NaN()
+	for name, modify := range map[string]func(z *Zone){
+		"NaN latitude":       func(z *Zone) { z.TopLeftLatLon[0] = nan },
+		"NaN longitude":      func(z *Zone) { z.BottomRightLatLon[1] = nan },
+		"out of range":       func(z *Zone) { z.TopRightLatLon[0] = 91 },
+		"missing coordinate": func(z *Zone) { z.BottomLeftLatLon = []float64{40} },
+		"bowtie":             func(z *Zone) { z.TopLeftLatLon, z.TopRightLatLon = z.TopRightLatLon, z.TopLeftLatLon },
+		"duplicated corner":  func(z *Zone) { z.TopRightLatLon = z.TopLeftLatLon },
+	} {
+		zone := squareZone(40, -100, 0.01)
+		modify(zone)
+		if err := zone.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
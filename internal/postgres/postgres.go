@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"metalink/internal/model"
 	"time"
@@ -16,7 +19,7 @@ var DB *gorm.DB
 var sqlDB *sql.DB
 
 // Init initializes the database connection and sets the global DB variable
-func Init(url string) *gorm.DB {
+func Init(url string) (*gorm.DB, error) {
 	// Configure GORM logger with higher slow SQL threshold
 	gormLogger := logger.New(
 		log.New(log.Writer(), "\r\n", log.LstdFlags), // io writer
@@ -30,14 +33,15 @@ func Init(url string) *gorm.DB {
 	})
 
 	if err != nil {
-		log.Fatalln(err)
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
 
 	// Get underlying SQL database
-	sqlDB, err = db.DB()
+	conn, err := db.DB()
 	if err != nil {
-		log.Fatalln("Failed to get SQL DB:", err)
+		return nil, fmt.Errorf("failed to get SQL DB: %w", err)
 	}
+	sqlDB = conn
 
 	// Configure connection pool settings
 	sqlDB.SetMaxIdleConns(10)
@@ -47,13 +51,13 @@ func Init(url string) *gorm.DB {
 	// AutoMigrate models
 	err = db.AutoMigrate(&model.TargetPG{})
 	if err != nil {
-		log.Fatalln("Failed to migrate Target model:", err)
+		return nil, fmt.Errorf("failed to migrate Target model: %w", err)
 	}
 
 	// Set global DB variable
 	DB = db
 
-	return db
+	return db, nil
 }
 
 // GetDB returns the global database connection
@@ -61,6 +65,14 @@ func GetDB() *gorm.DB {
 	return DB
 }
 
+// Ping checks that PostgreSQL is reachable
+func Ping(ctx context.Context) error {
+	if sqlDB == nil {
+		return errors.New("postgres is not initialized")
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // Close closes the database connection
 func Close() error {
 	if sqlDB != nil {
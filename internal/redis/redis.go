@@ -2,6 +2,8 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -12,10 +14,10 @@ import (
 var redisClient *redis.Client
 
 // Init initializes the Redis connection and sets the global RedisClient variable
-func Init(redisURL string) *redis.Client {
+func Init(redisURL string) (*redis.Client, error) {
 	opts, err := redis.ParseURL(redisURL)
 	if err != nil {
-		log.Fatalf("Failed to parse Redis URL: %v", err)
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
 	}
 
 	client := redis.NewClient(opts)
@@ -26,13 +28,14 @@ func Init(redisURL string) *redis.Client {
 
 	_, err = client.Ping(ctx).Result()
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	log.Println("Successfully connected to Redis")
 	redisClient = client
 
-	return client
+	return client, nil
 }
 
 // GetClient returns the global Redis client connection
@@ -40,6 +43,14 @@ func GetClient() *redis.Client {
 	return redisClient
 }
 
+// Ping checks that Redis is reachable
+func Ping(ctx context.Context) error {
+	if redisClient == nil {
+		return errors.New("redis is not initialized")
+	}
+	return redisClient.Ping(ctx).Err()
+}
+
 // Close closes the Redis client connection
 func Close() error {
 	if redisClient != nil {
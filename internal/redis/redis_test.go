@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"metalink/internal/redis/redistest"
+)
+
+// closedPortAddr returns a local address nothing listens on
+func closedPortAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestInitReturnsErrorForBadURL(t *testing.T) {
+	client, err := Init("not-a-redis-url")
+	if err == nil || client != nil {
+		t.Fatalf("Init = %v, %v, want an error", client, err)
+	}
+	if !strings.Contains(err.Error(), "failed to parse Redis URL") {
+		t.Fatalf("err = %v, want a parse error", err)
+	}
+}
+
+func TestInitReturnsErrorForUnreachableServer(t *testing.T) {
+	client, err := Init("redis://" + closedPortAddr(t))
+	if err == nil || client != nil {
+		t.Fatalf("Init = %v, %v, want an error", client, err)
+	}
+	if !strings.Contains(err.Error(), "failed to connect to Redis") {
+		t.Fatalf("err = %v, want a connection error", err)
+	}
+}
+
+func TestInitSetsGlobalClient(t *testing.T) {
+	server, err := redistest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := Init(server.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if GetClient() != client {
+		t.Fatal("Init did not set the global client")
+	}
+}
@@ -0,0 +1,308 @@
+// Package redistest provides an in-process Redis server for tests
+// It speaks RESP2 and implements only the string, keyspace and publish commands the services use
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a minimal in-memory Redis server listening on a local TCP port
+type Server struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	data     map[string]string
+	commands map[string]int // Command name -> number of calls
+	failing  map[string]bool
+	cursors  []string            // SCAN cursor - 1 -> last key returned
+	messages map[string][]string // Channel -> published messages, there are no subscribers
+}
+
+// NewServer starts a server on a random local port
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		data:     make(map[string]string),
+		commands: make(map[string]int),
+		failing:  make(map[string]bool),
+		messages: make(map[string][]string),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// URL returns the redis:// URL of the server
+func (s *Server) URL() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+// Close stops accepting connections
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// Set stores a key directly, bypassing the protocol
+func (s *Server) Set(key, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data[key] = value
+}
+
+// Get returns a stored key
+func (s *Server) Get(key string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, ok := s.data[key]
+	return value, ok
+}
+
+// Len returns the number of stored keys
+func (s *Server) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.data)
+}
+
+// Calls returns how many times a command (e.g. "DEL") was received
+func (s *Server) Calls(command string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.commands[strings.ToUpper(command)]
+}
+
+// Published returns the messages published to a channel, oldest first
+func (s *Server) Published(channel string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.messages[channel]...)
+}
+
+// FailCommand makes the server answer the command with an error until called with fail=false
+func (s *Server) FailCommand(command string, fail bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failing[strings.ToUpper(command)] = fail
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers the commands of one connection
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.execute(writer, args)
+
+		// Flush once the pipelined commands are all answered
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected command line %q", line)
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("unexpected argument header %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// readLine reads a CRLF terminated line without the terminator
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+// execute runs one command and writes its reply
+func (s *Server) execute(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		writeError(w, "ERR empty command")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	command := strings.ToUpper(args[0])
+	s.commands[command]++
+	if s.failing[command] {
+		writeError(w, "ERR injected failure")
+		return
+	}
+
+	switch command {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "CLIENT", "SELECT":
+		fmt.Fprint(w, "+OK\r\n")
+	case "SET":
+		if len(args) < 3 {
+			writeError(w, "ERR wrong number of arguments for 'set' command")
+			return
+		}
+		s.data[args[1]] = args[2]
+		fmt.Fprint(w, "+OK\r\n")
+	case "GET":
+		if len(args) != 2 {
+			writeError(w, "ERR wrong number of arguments for 'get' command")
+			return
+		}
+		value, ok := s.data[args[1]]
+		if !ok {
+			fmt.Fprint(w, "$-1\r\n")
+			return
+		}
+		writeBulk(w, value)
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", deleted)
+	case "DBSIZE":
+		fmt.Fprintf(w, ":%d\r\n", len(s.data))
+	case "SCAN":
+		s.scan(w, args[1:])
+	case "PUBLISH":
+		if len(args) != 3 {
+			writeError(w, "ERR wrong number of arguments for 'publish' command")
+			return
+		}
+		s.messages[args[1]] = append(s.messages[args[1]], args[2])
+		fmt.Fprint(w, ":0\r\n")
+	default:
+		// Includes HELLO, so clients fall back to RESP2
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// scan implements SCAN cursor [MATCH pattern] [COUNT count] over the sorted keys
+// A cursor resumes after the last key it returned, so like Redis keys deleted during a scan don't make it
+// skip the others
+func (s *Server) scan(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		writeError(w, "ERR wrong number of arguments for 'scan' command")
+		return
+	}
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil || cursor < 0 || cursor > len(s.cursors) {
+		writeError(w, "ERR invalid cursor")
+		return
+	}
+
+	pattern, count := "*", 10
+	for i := 1; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count <= 0 {
+				writeError(w, "ERR invalid count")
+				return
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		if cursor == 0 || key > s.cursors[cursor-1] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	page := keys[:min(count, len(keys))]
+	var matched []string
+	for _, key := range page {
+		if ok, _ := path.Match(pattern, key); ok {
+			matched = append(matched, key)
+		}
+	}
+
+	next := 0
+	if len(page) < len(keys) {
+		s.cursors = append(s.cursors, page[len(page)-1])
+		next = len(s.cursors)
+	}
+	fmt.Fprintf(w, "*2\r\n")
+	writeBulk(w, strconv.Itoa(next))
+	fmt.Fprintf(w, "*%d\r\n", len(matched))
+	for _, key := range matched {
+		writeBulk(w, key)
+	}
+}
+
+// writeBulk writes a bulk string reply
+func writeBulk(w *bufio.Writer, value string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+}
+
+// writeError writes an error reply
+func writeError(w *bufio.Writer, message string) {
+	fmt.Fprintf(w, "-%s\r\n", message)
+}
@@ -80,15 +80,15 @@ func (s *MemoryStorage[K, V]) GetAllValues() []V {
 	return result
 }
 
-// GetDirty returns all dirty objects without clearing flags
-func (s *MemoryStorage[K, V]) GetDirty() []V {
+// GetDirty returns all dirty objects by key without clearing flags
+func (s *MemoryStorage[K, V]) GetDirty() map[K]V {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	result := make([]V, 0, len(s.dirty))
+	result := make(map[K]V, len(s.dirty))
 	for k := range s.dirty {
 		if v, exists := s.data[k]; exists {
-			result = append(result, v)
+			result[k] = v
 		}
 	}
 	return result
@@ -104,6 +104,16 @@ func (s *MemoryStorage[K, V]) ClearDirty(keys []K) {
 	}
 }
 
+// MarkDirty sets dirty flags for provided keys, e.g. to retry a failed save
+func (s *MemoryStorage[K, V]) MarkDirty(keys []K) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, k := range keys {
+		s.dirty[k] = true
+	}
+}
+
 // ForEach executes a function for each object
 func (s *MemoryStorage[K, V]) ForEach(fn func(key K, value V) bool) {
 	// Copy data under lock for subsequent processing
@@ -160,9 +160,9 @@ func (s *ShardedMemoryStorage[K, V]) GetAllValues() []V {
 	return result
 }
 
-// GetDirty returns all dirty objects from all shards without clearing flags
-func (s *ShardedMemoryStorage[K, V]) GetDirty() []V {
-	// Estimate the number of dirty objects to pre-allocate the slice
+// GetDirty returns all dirty objects by key from all shards without clearing flags
+func (s *ShardedMemoryStorage[K, V]) GetDirty() map[K]V {
+	// Estimate the number of dirty objects to pre-allocate the map
 	totalDirty := 0
 	for _, shard := range s.shards {
 		shard.mutex.RLock()
@@ -170,14 +170,14 @@ func (s *ShardedMemoryStorage[K, V]) GetDirty() []V {
 		shard.mutex.RUnlock()
 	}
 
-	result := make([]V, 0, totalDirty)
+	result := make(map[K]V, totalDirty)
 
 	for _, shard := range s.shards {
 		shard.mutex.RLock()
 
 		for k := range shard.dirty {
 			if v, exists := shard.data[k]; exists {
-				result = append(result, v)
+				result[k] = v
 			}
 		}
 
@@ -189,6 +189,16 @@ func (s *ShardedMemoryStorage[K, V]) GetDirty() []V {
 
 // ClearDirty clears dirty flags for provided keys
 func (s *ShardedMemoryStorage[K, V]) ClearDirty(keys []K) {
+	s.setDirty(keys, false)
+}
+
+// MarkDirty sets dirty flags for provided keys, e.g. to retry a failed save
+func (s *ShardedMemoryStorage[K, V]) MarkDirty(keys []K) {
+	s.setDirty(keys, true)
+}
+
+// setDirty sets or clears dirty flags for provided keys, locking each shard once
+func (s *ShardedMemoryStorage[K, V]) setDirty(keys []K, dirty bool) {
 	// Group keys by shard
 	shardToKeys := make(map[int][]K)
 
@@ -197,13 +207,17 @@ func (s *ShardedMemoryStorage[K, V]) ClearDirty(keys []K) {
 		shardToKeys[shardIndex] = append(shardToKeys[shardIndex], key)
 	}
 
-	// Clear dirty flags in each shard
+	// Update dirty flags in each shard
 	for shardIndex, keysForShard := range shardToKeys {
 		shard := s.shards[shardIndex]
 		shard.mutex.Lock()
 
 		for _, key := range keysForShard {
-			delete(shard.dirty, key)
+			if dirty {
+				shard.dirty[key] = true
+			} else {
+				delete(shard.dirty, key)
+			}
 		}
 
 		shard.mutex.Unlock()
@@ -230,14 +244,24 @@ func (s *ShardedMemoryStorage[K, V]) ForEach(fn func(key K, value V) bool) {
 }
 
 // Count returns total number of objects
+// Shards are locked one at a time, so the result is not an atomic snapshot under concurrent writes
 func (s *ShardedMemoryStorage[K, V]) Count() int {
 	count := 0
-	for _, shard := range s.shards {
+	for _, shardCount := range s.ShardStats() {
+		count += shardCount
+	}
+	return count
+}
+
+// ShardStats returns the number of objects in each shard, locking one shard at a time
+func (s *ShardedMemoryStorage[K, V]) ShardStats() []int {
+	stats := make([]int, len(s.shards))
+	for i, shard := range s.shards {
 		shard.mutex.RLock()
-		count += len(shard.data)
+		stats[i] = len(shard.data)
 		shard.mutex.RUnlock()
 	}
-	return count
+	return stats
 }
 
 // ForEachParallel processes objects in parallel
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestShardedStorageConcurrentSetMarksDirty(t *testing.T) {
+	s := NewShardedMemoryStorage[string, int](16, nil)
+
+	const writers, perWriter = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				s.Set(fmt.Sprintf("w%d-%d", w, i), i)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	dirty := s.GetDirty()
+	if len(dirty) != writers*perWriter {
+		t.Fatalf("got %d dirty entries, want %d", len(dirty), writers*perWriter)
+	}
+	if dirty["w3-42"] != 42 {
+		t.Fatalf("dirty value of w3-42 = %d, want 42", dirty["w3-42"])
+	}
+	if s.Count() != writers*perWriter {
+		t.Fatalf("count = %d, want %d", s.Count(), writers*perWriter)
+	}
+}
+
+func TestShardedStorageClearDirtyDuringWrites(t *testing.T) {
+	s := NewShardedMemoryStorage[string, int](4, nil)
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("old-%03d", i), i)
+	}
+	saved := sortedKeys(s.GetDirty())
+
+	// Entries written while a save runs stay dirty after the saved keys are cleared
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.Set(fmt.Sprintf("new-%03d", i), i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		s.ClearDirty(saved)
+	}()
+	wg.Wait()
+
+	dirty := s.GetDirty()
+	if len(dirty) != 100 {
+		t.Fatalf("got %d dirty entries, want the 100 new ones", len(dirty))
+	}
+	for key := range dirty {
+		if key[:4] != "new-" {
+			t.Fatalf("cleared key %s is still dirty", key)
+		}
+	}
+}
+
+func TestShardedStorageMarkDirty(t *testing.T) {
+	s := NewShardedMemoryStorage[string, int](4, nil)
+	s.Set("a", 1)
+	s.Set("b", 2)
+	s.ClearDirty([]string{"a", "b"})
+	if len(s.GetDirty()) != 0 {
+		t.Fatalf("dirty set not empty after ClearDirty")
+	}
+
+	s.MarkDirty([]string{"a", "missing"})
+	if got := sortedKeys(s.GetDirty()); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("dirty keys = %v, want [a]", got)
+	}
+}
+
+func TestShardedStorageSpreadsKeysEvenly(t *testing.T) {
+	s := NewShardedMemoryStorage[string, int](32, nil)
+	for i := 0; i < 100000; i++ {
+		s.Set(fmt.Sprintf("target-%06d", i), i)
+	}
+
+	stats := s.ShardStats()
+	if len(stats) != 32 {
+		t.Fatalf("got %d shards, want 32", len(stats))
+	}
+	total, minCount, maxCount := 0, stats[0], stats[0]
+	for _, count := range stats {
+		total += count
+		minCount = min(minCount, count)
+		maxCount = max(maxCount, count)
+	}
+	if total != 100000 || s.Count() != total {
+		t.Fatalf("shards hold %d keys, Count = %d, want 100000", total, s.Count())
+	}
+	if minCount == 0 || float64(maxCount)/float64(minCount) >= 2 {
+		t.Fatalf("shard sizes range from %d to %d, want a ratio below 2", minCount, maxCount)
+	}
+}
@@ -7,8 +7,9 @@ type Storage[K comparable, V any] interface {
 	Delete(key K) bool
 	GetAll() map[K]V
 	GetAllValues() []V
-	GetDirty() []V
+	GetDirty() map[K]V
 	ClearDirty(keys []K)
+	MarkDirty(keys []K)
 	ForEach(fn func(key K, value V) bool)
 	Count() int
 }
@@ -0,0 +1,79 @@
+package target
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"metalink/internal/model"
+	"metalink/internal/service/zone"
+	"metalink/internal/util"
+)
+
+// windowAround returns a daily window from the current time of day plus from to plus to
+func windowAround(from, to time.Duration) *util.TimeWindow {
+	now := time.Now().UTC()
+	offset := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	day := 24 * time.Hour
+	return &util.TimeWindow{Start: (offset + from + day) % day, End: (offset + to + day) % day}
+}
+
+func TestProcessTargetsOutsideActiveWindow(t *testing.T) {
+	// A zone around a route of its own, so other tests' routes stay outside any zone
+	route := [][2]float64{{60, 0.000}, {60, 0.001}, {60, 0.002}}
+	zone.GetZoneService().LoadZones([]*model.Zone{{
+		ID:                "window-zone",
+		TopLeftLatLon:     []float64{60.01, -0.01},
+		TopRightLatLon:    []float64{60.01, 0.01},
+		BottomRightLatLon: []float64{59.99, 0.01},
+		BottomLeftLatLon:  []float64{59.99, -0.01},
+		Effects:           []model.ZoneEffect{{ResourceType: model.TargetParamTypeFoodSearch, Value: 10}},
+	}})
+
+	targets := make([]*model.Target, 3)
+	for i := range targets {
+		targets[i] = &model.Target{
+			ID:             fmt.Sprintf("window-%d", i),
+			Speed:          model.SpeedFromMs(5),
+			Route:          util.EncodePolyline(route),
+			State:          model.TargetStateLooping,
+			NextPointIndex: model.RouteNotStarted,
+		}
+	}
+	s := newTestTargetService(targets...)
+	s.TrackZoneOccupancy = true
+	s.ActiveWindow = windowAround(time.Hour, 2*time.Hour)
+
+	for i := 0; i < 3; i++ {
+		s.ProcessTargets()
+	}
+	for _, target := range s.storage.GetAllValues() {
+		if target.CurrentLat != 0 || target.CurrentLng != 0 || !target.UpdatedAt.IsZero() {
+			t.Fatalf("%s moved to %v,%v outside the active window", target.ID, target.CurrentLat, target.CurrentLng)
+		}
+		if target.LastZoneIDs != nil {
+			t.Fatalf("%s got zones %v outside the active window", target.ID, target.LastZoneIDs)
+		}
+	}
+	if n := zone.GetZoneService().ZoneOccupancy("window-zone"); n != 0 {
+		t.Fatalf("occupancy is %d outside the active window, want 0", n)
+	}
+	if !s.outsideWindow.Load() {
+		t.Fatal("the pause wasn't recorded")
+	}
+
+	// Inside the window the targets move and the zone applies again
+	s.ActiveWindow = windowAround(-time.Hour, time.Hour)
+	s.ProcessTargets()
+	for _, target := range s.storage.GetAllValues() {
+		if target.UpdatedAt.IsZero() || float64(target.CurrentLat) != route[0][0] {
+			t.Fatalf("%s didn't move inside the active window", target.ID)
+		}
+	}
+	if n := zone.GetZoneService().ZoneOccupancy("window-zone"); n != len(targets) {
+		t.Fatalf("occupancy is %d inside the active window, want %d", n, len(targets))
+	}
+	if s.outsideWindow.Load() {
+		t.Fatal("the resume wasn't recorded")
+	}
+}
@@ -0,0 +1,122 @@
+package target
+
+import (
+	"sort"
+	"time"
+
+	"metalink/internal/config"
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+// compactTargets keeps the movement state of all targets in parallel slices indexed by a compact int
+// All routes are decoded once into a single shared points slice, so the movement pass reads
+// contiguous memory and the GC has no per-target route slices to track
+type compactTargets struct {
+	targets []*model.Target // Source targets sorted by ID, targets[i].ID is the ID of compact index i
+
+	lat        []float32
+	lng        []float32
+	bearing    []float32
+	speed      []model.Speed
+	state      []model.TargetState
+	next       []int32
+	backward   []bool
+	routeStart []int32 // Route of target i is points[routeStart[i]:routeStart[i+1]]
+
+	points [][2]float64
+}
+
+// newCompactTargets builds the compact store from targets, ordered by ID
+// Routes are taken from already decoded RoutePoints or decoded from the encoded route
+func newCompactTargets(targets []*model.Target) *compactTargets {
+	sorted := make([]*model.Target, len(targets))
+	copy(sorted, targets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	n := len(sorted)
+	c := &compactTargets{
+		targets:    sorted,
+		lat:        make([]float32, n),
+		lng:        make([]float32, n),
+		bearing:    make([]float32, n),
+		speed:      make([]model.Speed, n),
+		state:      make([]model.TargetState, n),
+		next:       make([]int32, n),
+		backward:   make([]bool, n),
+		routeStart: make([]int32, n+1),
+	}
+
+	for i, target := range sorted {
+		c.lat[i] = target.CurrentLat
+		c.lng[i] = target.CurrentLng
+		c.bearing[i] = target.CurrentBearing
+		c.speed[i] = target.Speed
+		c.state[i] = target.State
+		c.next[i] = int32(target.NextPointIndex)
+		c.backward[i] = target.MovingBackward
+
+		c.routeStart[i] = int32(len(c.points))
+		if target.RoutePoints != nil {
+			c.points = append(c.points, target.RoutePoints...)
+		} else {
+			c.points = append(c.points, util.DecodePolyline(target.Route)...)
+		}
+	}
+	c.routeStart[n] = int32(len(c.points))
+
+	return c
+}
+
+// len returns the number of targets
+func (c *compactTargets) len() int {
+	return len(c.targets)
+}
+
+// route returns the route points of the i-th target
+func (c *compactTargets) route(i int) [][2]float64 {
+	return c.points[c.routeStart[i]:c.routeStart[i+1]:c.routeStart[i+1]]
+}
+
+// move advances the i-th target for one tick and reports whether it was moving
+func (c *compactTargets) move(i int) bool {
+	if !c.state[i].IsMoving() {
+		return false
+	}
+
+	movement := routeMovement{
+		lat:            c.lat[i],
+		lng:            c.lng[i],
+		bearing:        c.bearing[i],
+		nextPointIndex: int(c.next[i]),
+		movingBackward: c.backward[i],
+		state:          c.state[i],
+	}
+	movement.moveAlongRoute(c.route(i), c.speed[i].DistanceOver(config.TargetsWorkerInterval))
+
+	c.lat[i] = movement.lat
+	c.lng[i] = movement.lng
+	c.bearing[i] = movement.bearing
+	c.next[i] = int32(movement.nextPointIndex)
+	c.backward[i] = movement.movingBackward
+	c.state[i] = movement.state
+	return true
+}
+
+// writeBack copies the movement state of the i-th target to its model.Target
+func (c *compactTargets) writeBack(i int, now time.Time) *model.Target {
+	target := c.targets[i]
+	target.CurrentLat = c.lat[i]
+	target.CurrentLng = c.lng[i]
+	target.CurrentBearing = c.bearing[i]
+	target.NextPointIndex = int(c.next[i])
+	target.MovingBackward = c.backward[i]
+	target.State = c.state[i]
+	target.UpdatedAt = now
+	return target
+}
+
+// position returns the [lat, lng] position of the i-th target
+func (c *compactTargets) position(i int) [2]float64 {
+	return [2]float64{float64(c.lat[i]), float64(c.lng[i])}
+}
@@ -0,0 +1,68 @@
+package target
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCompactMovementMatchesMap(t *testing.T) {
+	mapped := newTestTargetService(loopingTargets(300)...)
+	compact := newTestTargetService(loopingTargets(300)...)
+	compact.CompactMovement = true
+
+	for tick := 0; tick < 100; tick++ {
+		mapped.ProcessTargets()
+		compact.ProcessTargets()
+	}
+
+	for i := 0; i < 300; i++ {
+		id := fmt.Sprintf("target-%03d", i)
+		want, err := mapped.GetTarget(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := compact.GetTarget(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if float64(want.CurrentLat) == testRoute[0][0] && float64(want.CurrentLng) == testRoute[0][1] {
+			t.Fatalf("%s didn't move", id)
+		}
+		if got.CurrentLat != want.CurrentLat || got.CurrentLng != want.CurrentLng ||
+			got.NextPointIndex != want.NextPointIndex || got.MovingBackward != want.MovingBackward {
+			t.Fatalf("%s: compact at %v,%v (next %d), map at %v,%v (next %d)", id,
+				got.CurrentLat, got.CurrentLng, got.NextPointIndex, want.CurrentLat, want.CurrentLng, want.NextPointIndex)
+		}
+	}
+}
+
+// benchmarkProcessTargets runs movement passes over 300k targets and reports GC pauses per pass
+func benchmarkProcessTargets(b *testing.B, compactMovement bool) {
+	s := newTestTargetService(loopingTargets(300000)...)
+	s.CompactMovement = compactMovement
+	s.ProcessTargets() // Builds the compact store and decodes routes outside the timing
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.ProcessTargets()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+	b.ReportMetric(float64(time.Duration(after.PauseTotalNs-before.PauseTotalNs).Microseconds())/float64(b.N), "gc-pause-us/op")
+	b.ReportMetric(float64(after.HeapAlloc)/(1<<20), "heap-MB")
+}
+
+func BenchmarkProcessTargetsMap(b *testing.B) {
+	benchmarkProcessTargets(b, false)
+}
+
+func BenchmarkProcessTargetsCompact(b *testing.B) {
+	benchmarkProcessTargets(b, true)
+}
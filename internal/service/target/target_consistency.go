@@ -0,0 +1,173 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"metalink/internal/model"
+	pg "metalink/internal/postgres"
+	redis_client "metalink/internal/redis"
+)
+
+// consistencySampleSize is the number of IDs sampled from each store for presence checks
+const consistencySampleSize = 100
+
+// ConsistencyReport describes drift between memory, Redis and PostgreSQL target stores
+type ConsistencyReport struct {
+	MemoryCount int64 `json:"memory_count"`
+	RedisCount  int64 `json:"redis_count"`
+	PGCount     int64 `json:"pg_count"`
+
+	SampledIDs      int      `json:"sampled_ids"`
+	MissingInMemory []string `json:"missing_in_memory"`
+	MissingInRedis  []string `json:"missing_in_redis"`
+	MissingInPG     []string `json:"missing_in_pg"`
+
+	Consistent bool `json:"consistent"`
+}
+
+// targetIDStore is the PostgreSQL side of the consistency check
+type targetIDStore interface {
+	// Count returns the number of stored targets
+	Count(ctx context.Context) (int64, error)
+	// Sample returns up to limit target IDs
+	Sample(ctx context.Context, limit int) ([]string, error)
+	// Existing returns the given IDs that are stored
+	Existing(ctx context.Context, ids []string) ([]string, error)
+}
+
+// pgTargetIDs reads target IDs from PostgreSQL
+type pgTargetIDs struct{}
+
+// Count returns the number of targets in PostgreSQL
+func (pgTargetIDs) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := pg.GetDB().WithContext(ctx).Model(&model.TargetPG{}).Count(&count).Error
+	return count, err
+}
+
+// Sample returns up to limit target IDs from PostgreSQL
+func (pgTargetIDs) Sample(ctx context.Context, limit int) ([]string, error) {
+	var ids []string
+	err := pg.GetDB().WithContext(ctx).Model(&model.TargetPG{}).Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Existing returns the given IDs that exist in PostgreSQL
+func (pgTargetIDs) Existing(ctx context.Context, ids []string) ([]string, error) {
+	var existing []string
+	err := pg.GetDB().WithContext(ctx).Model(&model.TargetPG{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	return existing, err
+}
+
+// CheckConsistency compares target counts and a sample of IDs between memory, Redis and PostgreSQL
+func (s *TargetService) CheckConsistency(ctx context.Context) (ConsistencyReport, error) {
+	return s.checkConsistency(ctx, pgTargetIDs{})
+}
+
+// checkConsistency compares memory and Redis against the given PostgreSQL ID store
+func (s *TargetService) checkConsistency(ctx context.Context, pgStore targetIDStore) (ConsistencyReport, error) {
+	report := ConsistencyReport{
+		MemoryCount:     int64(s.storage.Count()),
+		MissingInMemory: []string{},
+		MissingInRedis:  []string{},
+		MissingInPG:     []string{},
+	}
+
+	redisIDs, err := s.loadRedisTargetIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to scan Redis targets: %w", err)
+	}
+	report.RedisCount = int64(len(redisIDs))
+
+	if report.PGCount, err = pgStore.Count(ctx); err != nil {
+		return report, fmt.Errorf("failed to count PostgreSQL targets: %w", err)
+	}
+
+	// Sample IDs from every store so a target missing from any of them can show up
+	sample := make(map[string]bool)
+	s.storage.ForEach(func(id string, _ *model.Target) bool {
+		sample[id] = true
+		return len(sample) < consistencySampleSize
+	})
+
+	redisSampled := 0
+	for id := range redisIDs {
+		if redisSampled >= consistencySampleSize {
+			break
+		}
+		sample[id] = true
+		redisSampled++
+	}
+
+	pgSampleIDs, err := pgStore.Sample(ctx, consistencySampleSize)
+	if err != nil {
+		return report, fmt.Errorf("failed to sample PostgreSQL targets: %w", err)
+	}
+	for _, id := range pgSampleIDs {
+		sample[id] = true
+	}
+
+	ids := make([]string, 0, len(sample))
+	for id := range sample {
+		ids = append(ids, id)
+	}
+	report.SampledIDs = len(ids)
+
+	// Check which sampled IDs exist in PostgreSQL
+	pgIDs := make(map[string]bool, len(ids))
+	if len(ids) > 0 {
+		existing, err := pgStore.Existing(ctx, ids)
+		if err != nil {
+			return report, fmt.Errorf("failed to check PostgreSQL targets: %w", err)
+		}
+		for _, id := range existing {
+			pgIDs[id] = true
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := s.storage.Get(id); !ok {
+			report.MissingInMemory = append(report.MissingInMemory, id)
+		}
+		if !redisIDs[id] {
+			report.MissingInRedis = append(report.MissingInRedis, id)
+		}
+		if !pgIDs[id] {
+			report.MissingInPG = append(report.MissingInPG, id)
+		}
+	}
+
+	report.Consistent = report.MemoryCount == report.PGCount &&
+		report.RedisCount == report.PGCount &&
+		len(report.MissingInMemory) == 0 &&
+		len(report.MissingInRedis) == 0 &&
+		len(report.MissingInPG) == 0
+
+	return report, nil
+}
+
+// loadRedisTargetIDs returns the IDs of all targets stored in Redis
+func (s *TargetService) loadRedisTargetIDs(ctx context.Context) (map[string]bool, error) {
+	client := redis_client.GetClient()
+	prefix := TargetRedisKey + ":"
+	pattern := prefix + "*"
+
+	ids := make(map[string]bool)
+	var cursor uint64
+	for {
+		batch, nextCursor, err := client.Scan(ctx, cursor, pattern, 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range batch {
+			ids[key[len(prefix):]] = true
+		}
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,79 @@
+package target
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// fakeTargetIDs is an in-memory targetIDStore
+type fakeTargetIDs map[string]bool
+
+func (f fakeTargetIDs) Count(context.Context) (int64, error) {
+	return int64(len(f)), nil
+}
+
+func (f fakeTargetIDs) Sample(_ context.Context, limit int) ([]string, error) {
+	var ids []string
+	for id := range f {
+		if len(ids) == limit {
+			break
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f fakeTargetIDs) Existing(_ context.Context, ids []string) ([]string, error) {
+	var existing []string
+	for _, id := range ids {
+		if f[id] {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
+func TestCheckConsistencyReportsTargetMissingInRedis(t *testing.T) {
+	server := startTestRedis(t)
+	s := newTestTargetService(&model.Target{ID: "a"}, &model.Target{ID: "b"})
+	server.Set(TargetRedisKey+":a", "{}")
+	server.Set(TargetRedisKey+":b", "{}")
+
+	// Target c was saved to PostgreSQL only
+	report, err := s.checkConsistency(context.Background(), fakeTargetIDs{"a": true, "b": true, "c": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Consistent {
+		t.Fatal("report is consistent despite the missing target")
+	}
+	if report.MemoryCount != 2 || report.RedisCount != 2 || report.PGCount != 3 {
+		t.Fatalf("counts memory %d, redis %d, pg %d; want 2, 2, 3", report.MemoryCount, report.RedisCount, report.PGCount)
+	}
+	sort.Strings(report.MissingInRedis)
+	if !reflect.DeepEqual(report.MissingInRedis, []string{"c"}) {
+		t.Fatalf("missing in Redis = %v, want [c]", report.MissingInRedis)
+	}
+	if !reflect.DeepEqual(report.MissingInMemory, []string{"c"}) || len(report.MissingInPG) != 0 {
+		t.Fatalf("missing in memory %v, in PostgreSQL %v; want [c], []", report.MissingInMemory, report.MissingInPG)
+	}
+}
+
+func TestCheckConsistencyConsistentStores(t *testing.T) {
+	server := startTestRedis(t)
+	s := newTestTargetService(&model.Target{ID: "a"})
+	server.Set(TargetRedisKey+":a", "{}")
+
+	report, err := s.checkConsistency(context.Background(), fakeTargetIDs{"a": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Consistent || report.SampledIDs != 1 {
+		t.Fatalf("report %+v, want consistent with 1 sampled ID", report)
+	}
+}
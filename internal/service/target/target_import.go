@@ -0,0 +1,147 @@
+package target
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"metalink/internal/config"
+	"metalink/internal/logx"
+	"metalink/internal/model"
+	pg "metalink/internal/postgres"
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// ImportTargetsFromGeoJSON creates targets in PostgreSQL from a GeoJSON FeatureCollection of points
+// Returns the number of imported targets
+func (s *TargetService) ImportTargetsFromGeoJSON(path string) (int, error) {
+	targets, err := LoadTargetsFromGeoJSON(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(targets) == 0 {
+		logx.Info("No targets found in %s", path)
+		return 0, nil
+	}
+
+	if err := pg.GetDB().CreateInBatches(targets, 500).Error; err != nil {
+		return 0, fmt.Errorf("failed to insert imported targets: %w", err)
+	}
+
+	logx.Info("Imported %d targets from %s", len(targets), path)
+	return len(targets), nil
+}
+
+// LoadTargetsFromGeoJSON reads Point features from a GeoJSON file and converts them to targets
+// Optional feature properties: name (string), speed (number, m/s), route (encoded polyline)
+func LoadTargetsFromGeoJSON(path string) ([]model.TargetPG, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON file: %w", err)
+	}
+
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON file: %w", err)
+	}
+
+	targets := make([]model.TargetPG, 0, len(fc.Features))
+	for i, feature := range fc.Features {
+		point, ok := feature.Geometry.(orb.Point)
+		if !ok {
+			return nil, fmt.Errorf("feature %d: expected Point geometry, got %T", i, feature.Geometry)
+		}
+
+		target, err := targetFromPointFeature(point, feature.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// targetFromPointFeature creates a target at the given point, defaulting missing properties
+func targetFromPointFeature(point orb.Point, props geojson.Properties) (model.TargetPG, error) {
+	lng, lat := point[0], point[1]
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return model.TargetPG{}, fmt.Errorf("invalid coordinates: lat=%f, lng=%f", lat, lng)
+	}
+
+	id, err := util.GenerateUUIDWithLength(12)
+	if err != nil {
+		return model.TargetPG{}, err
+	}
+
+	name, err := stringProperty(props, "name", "Target "+id)
+	if err != nil {
+		return model.TargetPG{}, err
+	}
+
+	speed, err := floatProperty(props, "speed", config.DefaultTargetSpeed)
+	if err != nil {
+		return model.TargetPG{}, err
+	}
+	if speed <= 0 {
+		return model.TargetPG{}, fmt.Errorf("invalid speed: %f", speed)
+	}
+
+	// Targets without a usable route stay at their initial position
+	route, err := stringProperty(props, "route", "")
+	if err != nil {
+		return model.TargetPG{}, err
+	}
+	state := model.TargetStateStopped
+	if points := util.DecodePolyline(route); len(points) >= 2 {
+		state = model.TargetStateWalking
+
+		// New targets start at the first route point, so a target placed away from its route walks
+		// there from the imported position instead of jumping (points compared at polyline precision)
+		start := [2]float64{lat, lng}
+		if util.EncodePolyline([][2]float64{start}) != util.EncodePolyline(points[:1]) {
+			route = util.EncodePolyline(append([][2]float64{start}, points...))
+		}
+	}
+
+	return model.TargetPG{
+		ID:             id,
+		Name:           name,
+		Speed:          float32(speed),
+		Route:          route,
+		State:          state,
+		NextPointIndex: model.RouteNotStarted,
+		CurrentLat:     float32(lat),
+		CurrentLng:     float32(lng),
+	}, nil
+}
+
+// stringProperty returns a string property or the default if it's missing
+func stringProperty(props geojson.Properties, key, def string) (string, error) {
+	v, ok := props[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("property %q must be a string, got %T", key, v)
+	}
+	return s, nil
+}
+
+// floatProperty returns a numeric property or the default if it's missing
+func floatProperty(props geojson.Properties, key string, def float64) (float64, error) {
+	v, ok := props[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("property %q must be a number, got %T", key, v)
+	}
+	return f, nil
+}
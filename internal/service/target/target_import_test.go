@@ -0,0 +1,88 @@
+package target
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func TestTargetFromPointFeatureStartsAtImportedPosition(t *testing.T) {
+	// Imported 0.001 degrees south of the first route point
+	route := util.EncodePolyline(testRoute)
+	target, err := targetFromPointFeature(orb.Point{0, 9.999}, geojson.Properties{"route": route})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := routeMovement{
+		lat: target.CurrentLat, lng: target.CurrentLng,
+		nextPointIndex: target.NextPointIndex, state: target.State,
+	}
+	m.moveAlongRoute(util.DecodePolyline(target.Route), 10)
+
+	// The first step heads from the imported position toward the route instead of jumping onto it
+	if d := util.HaversineDistance(float64(m.lat), float64(m.lng), 9.999, 0); math.Abs(d-10) > 0.5 {
+		t.Fatalf("target is %.2f m from its imported position after moving 10 m", d)
+	}
+}
+
+func TestTargetFromPointFeatureKeepsRouteStartingAtPosition(t *testing.T) {
+	route := util.EncodePolyline(testRoute)
+	target, err := targetFromPointFeature(orb.Point{testRoute[0][1], testRoute[0][0]}, geojson.Properties{"route": route})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Route != route || target.State != model.TargetStateWalking {
+		t.Fatalf("route %q, state %v; want the unchanged route, walking", target.Route, target.State)
+	}
+}
+
+func TestTargetFromPointFeatureRejectsInvalidCoordinates(t *testing.T) {
+	for _, point := range []orb.Point{{0, 91}, {181, 0}, {0, math.NaN()}, {math.NaN(), 0}} {
+		if _, err := targetFromPointFeature(point, nil); err == nil {
+			t.Errorf("point %v: expected an error", point)
+		}
+	}
+}
+
+func TestLoadTargetsFromGeoJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.geojson")
+	data := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-122.4194, 37.7749]}, "properties": {"name": "sf", "speed": 2.5}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [2.3522, 48.8566]}, "properties": null}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := LoadTargetsFromGeoJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("%d targets, want 2", len(targets))
+	}
+
+	// GeoJSON coordinates are [lon, lat]
+	sf, paris := targets[0], targets[1]
+	if sf.Name != "sf" || sf.Speed != 2.5 || sf.CurrentLat != 37.7749 || sf.CurrentLng != -122.4194 {
+		t.Fatalf("first target %+v, want sf at 37.7749,-122.4194 with speed 2.5", sf)
+	}
+	if paris.CurrentLat != 48.8566 || paris.CurrentLng != 2.3522 || paris.Speed <= 0 {
+		t.Fatalf("second target %+v, want 48.8566,2.3522 with the default speed", paris)
+	}
+	if sf.ID == paris.ID || sf.State != model.TargetStateStopped {
+		t.Fatalf("ids %q and %q, state %v; want distinct ids and stopped targets without routes", sf.ID, paris.ID, sf.State)
+	}
+
+	if _, err := LoadTargetsFromGeoJSON(filepath.Join(t.TempDir(), "missing.geojson")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
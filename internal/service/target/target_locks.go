@@ -0,0 +1,31 @@
+package target
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"metalink/internal/model"
+)
+
+// targetLockStripes is the number of mutexes guarding the fields of in-memory targets
+const targetLockStripes = 256
+
+// targetLocks stripes the per-target locks over a fixed set of mutexes keyed by target ID
+// The movement workers hold a target's lock while they write it and readers while they copy it
+type targetLocks [targetLockStripes]sync.Mutex
+
+// lock locks the stripe of the target ID and returns its unlock function
+func (l *targetLocks) lock(id string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	m := &l[h.Sum32()%targetLockStripes]
+	m.Lock()
+	return m.Unlock
+}
+
+// snapshot returns a copy of the target taken under its lock
+func (l *targetLocks) snapshot(target *model.Target) *model.Target {
+	unlock := l.lock(target.ID)
+	defer unlock()
+	return target.Clone()
+}
@@ -0,0 +1,137 @@
+package target
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+var (
+	// ErrTargetNotFound is returned when no target with the given ID is loaded
+	ErrTargetNotFound = errors.New("target not found")
+
+	// ErrInvalidRoute is returned when a route polyline decodes to fewer than two points
+	ErrInvalidRoute = errors.New("route must contain at least two points")
+)
+
+// CreateTarget adds a new walking target with the given route to memory storage
+// The target is marked dirty and persisted by the regular Redis/PostgreSQL workers
+func (s *TargetService) CreateTarget(name string, speed model.Speed, route string) (*model.Target, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("invalid speed: %f", speed)
+	}
+
+	routePoints := util.DecodePolyline(route)
+	if len(routePoints) < 2 {
+		return nil, ErrInvalidRoute
+	}
+
+	id, err := util.GenerateUUIDWithLength(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate target ID: %w", err)
+	}
+
+	if name == "" {
+		name = "Target " + id
+	}
+
+	now := time.Now()
+	target := &model.Target{
+		ID:             id,
+		Name:           name,
+		Speed:          speed,
+		Route:          route,
+		State:          model.TargetStateWalking,
+		NextPointIndex: model.RouteNotStarted,
+		CurrentLat:     float32(routePoints[0][0]),
+		CurrentLng:     float32(routePoints[0][1]),
+		RoutePoints:    routePoints,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// The copy is taken before the target is visible to the movement loop
+	created := target.Clone()
+	s.storage.Set(id, target)
+	s.compactStale.Store(true)
+	return created, nil
+}
+
+// GetTarget returns a copy of the in-memory target with the given ID
+// The copy is taken under the target's lock, so it's consistent even while the movement loop runs
+func (s *TargetService) GetTarget(id string) (*model.Target, error) {
+	target, exists := s.storage.Get(id)
+	if !exists {
+		return nil, ErrTargetNotFound
+	}
+	return s.locks.snapshot(target), nil
+}
+
+// UpdateTargetRoute assigns a new route to a target and restarts it from the first route point
+// Stopped targets start walking again. The route is queued and applied at the start of the next
+// movement pass, so it can't race with the movement workers; the returned copy shows the target as
+// it will be after the change
+func (s *TargetService) UpdateTargetRoute(id string, route string) (*model.Target, error) {
+	target, err := s.GetTarget(id)
+	if err != nil {
+		return nil, err
+	}
+
+	routePoints := util.DecodePolyline(route)
+	if len(routePoints) < 2 {
+		return nil, ErrInvalidRoute
+	}
+
+	s.pendingMutex.Lock()
+	if s.pendingRoutes == nil {
+		s.pendingRoutes = make(map[string]string)
+	}
+	s.pendingRoutes[id] = route
+	s.pendingMutex.Unlock()
+
+	applyRoute(target, route, routePoints, time.Now())
+	return target, nil
+}
+
+// applyPendingRoutes applies the routes queued by UpdateTargetRoute
+// Called by ProcessTargets before the movement workers start
+func (s *TargetService) applyPendingRoutes() {
+	s.pendingMutex.Lock()
+	pending := s.pendingRoutes
+	s.pendingRoutes = nil
+	s.pendingMutex.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for id, route := range pending {
+		target, exists := s.storage.Get(id)
+		if !exists {
+			continue
+		}
+
+		unlock := s.locks.lock(id)
+		applyRoute(target, route, util.DecodePolyline(route), now)
+		unlock()
+		s.storage.Set(id, target)
+	}
+	s.compactStale.Store(true)
+}
+
+// applyRoute restarts a target on a new route
+func applyRoute(target *model.Target, route string, routePoints [][2]float64, now time.Time) {
+	// RoutePoints are set directly so EnsureRoutePoints keeps them instead of the old decoded route
+	target.Route = route
+	target.RoutePoints = routePoints
+	target.NextPointIndex = model.RouteNotStarted
+	target.MovingBackward = false
+	if !target.State.IsMoving() {
+		target.State = model.TargetStateWalking
+	}
+	target.UpdatedAt = now
+}
@@ -0,0 +1,116 @@
+package target
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+// loopingTargets returns n looping targets on testRoute
+func loopingTargets(n int) []*model.Target {
+	route := util.EncodePolyline(testRoute)
+	targets := make([]*model.Target, n)
+	for i := range targets {
+		targets[i] = &model.Target{
+			ID:             fmt.Sprintf("target-%03d", i),
+			Speed:          model.SpeedFromMs(5),
+			Route:          route,
+			State:          model.TargetStateLooping,
+			NextPointIndex: model.RouteNotStarted,
+		}
+	}
+	return targets
+}
+
+func TestUpdateTargetRouteAppliesOnNextPass(t *testing.T) {
+	s := newTestTargetService(loopingTargets(1)...)
+	s.ProcessTargets()
+
+	newRoute := util.EncodePolyline([][2]float64{{20, 20}, {20, 20.001}})
+	updated, err := s.UpdateTargetRoute("target-000", newRoute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Route != newRoute || updated.NextPointIndex != model.RouteNotStarted {
+		t.Fatalf("returned target has route %q and next point %d", updated.Route, updated.NextPointIndex)
+	}
+
+	s.ProcessTargets()
+	got, err := s.GetTarget("target-000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Route != newRoute || got.CurrentLat < 19.9 {
+		t.Fatalf("after the pass the target has route %q at %v,%v", got.Route, got.CurrentLat, got.CurrentLng)
+	}
+
+	if _, err := s.UpdateTargetRoute("missing", newRoute); err != ErrTargetNotFound {
+		t.Fatalf("missing target: err = %v", err)
+	}
+	if _, err := s.UpdateTargetRoute("target-000", "_p~iF"); err != ErrInvalidRoute {
+		t.Fatalf("one point route: err = %v", err)
+	}
+}
+
+// Run with -race: readers and route changes run while the movement loop writes targets
+func TestTargetAccessDuringProcessing(t *testing.T) {
+	for _, compactMovement := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compact=%v", compactMovement), func(t *testing.T) {
+			s := newTestTargetService(loopingTargets(200)...)
+			s.CompactMovement = compactMovement
+
+			routes := []string{
+				util.EncodePolyline(testRoute),
+				util.EncodePolyline([][2]float64{{10, 0.002}, {10, 0}}),
+			}
+
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer close(done)
+				for i := 0; i < 50; i++ {
+					s.ProcessTargets()
+				}
+			}()
+
+			for r := 0; r < 4; r++ {
+				wg.Add(1)
+				go func(r int) {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						id := fmt.Sprintf("target-%03d", (r*50+i)%200)
+						if _, err := s.GetTarget(id); err != nil {
+							t.Error(err)
+							return
+						}
+						if _, err := s.UpdateTargetRoute(id, routes[i%2]); err != nil {
+							t.Error(err)
+							return
+						}
+						for _, target := range s.GetTargetsInBounds(9, -1, 11, 1) {
+							_ = target.CurrentLat
+						}
+					}
+				}(r)
+			}
+			wg.Wait()
+
+			// Everything queued during the run is applied by the next pass
+			s.ProcessTargets()
+			if len(s.pendingRoutes) != 0 {
+				t.Fatalf("%d route changes still pending", len(s.pendingRoutes))
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package target
+
+import (
+	"math"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+// testRoute is a straight west-east route of three points about 110 m apart
+var testRoute = [][2]float64{{10, 0.000}, {10, 0.001}, {10, 0.002}}
+
+// segmentLength returns the length in meters of the route segment between points i and j
+func segmentLength(route [][2]float64, i, j int) float64 {
+	return util.HaversineDistance(route[i][0], route[i][1], route[j][0], route[j][1])
+}
+
+// assertNear fails unless the movement is within toleranceMeters of the [lat, lng] point
+func assertNear(t *testing.T, m routeMovement, point [2]float64, toleranceMeters float64) {
+	t.Helper()
+	if d := util.HaversineDistance(float64(m.lat), float64(m.lng), point[0], point[1]); d > toleranceMeters {
+		t.Fatalf("position %.6f,%.6f is %.2f m from %v", m.lat, m.lng, d, point)
+	}
+}
+
+// pointAlong returns the point distanceMeters from route point i toward route point j
+func pointAlong(route [][2]float64, i, j int, distanceMeters float64) [2]float64 {
+	return util.MoveToward(route[i][0], route[i][1], route[j][0], route[j][1], distanceMeters)
+}
+
+func TestMoveAlongRouteWalkingStopsAtEnd(t *testing.T) {
+	m := routeMovement{nextPointIndex: model.RouteNotStarted, state: model.TargetStateWalking}
+	m.moveAlongRoute(testRoute, 10000)
+
+	assertNear(t, m, testRoute[2], 0.5)
+	if m.state != model.TargetStateStopped {
+		t.Fatalf("state = %v, want stopped", m.state)
+	}
+}
+
+func TestMoveAlongRouteLoopingWrapKeepsResidualDistance(t *testing.T) {
+	// At the middle point heading to the last one
+	m := routeMovement{
+		lat: float32(testRoute[1][0]), lng: float32(testRoute[1][1]),
+		nextPointIndex: 2, state: model.TargetStateLooping,
+	}
+	m.moveAlongRoute(testRoute, segmentLength(testRoute, 1, 2)+50)
+
+	// The 50 m left after reaching the end are spent on the way back to the first point
+	assertNear(t, m, pointAlong(testRoute, 2, 0, 50), 0.5)
+	if m.nextPointIndex != 0 || m.state != model.TargetStateLooping {
+		t.Fatalf("next point %d, state %v; want 0, looping", m.nextPointIndex, m.state)
+	}
+
+	// Heading back to point 0 is not a restart
+	m.moveAlongRoute(testRoute, 10)
+	assertNear(t, m, pointAlong(testRoute, 2, 0, 60), 0.5)
+}
+
+func TestMoveAlongRoutePingPongReversesWithResidualDistance(t *testing.T) {
+	m := routeMovement{
+		lat: float32(testRoute[1][0]), lng: float32(testRoute[1][1]),
+		nextPointIndex: 2, state: model.TargetStatePingPong,
+	}
+	m.moveAlongRoute(testRoute, segmentLength(testRoute, 1, 2)+30)
+
+	assertNear(t, m, pointAlong(testRoute, 2, 1, 30), 0.5)
+	if !m.movingBackward || m.nextPointIndex != 1 {
+		t.Fatalf("moving backward %v, next point %d; want true, 1", m.movingBackward, m.nextPointIndex)
+	}
+
+	// Past the first point it turns around again
+	m.moveAlongRoute(testRoute, segmentLength(testRoute, 2, 0)-30+20)
+	assertNear(t, m, pointAlong(testRoute, 0, 1, 20), 0.5)
+	if m.movingBackward || m.nextPointIndex != 1 {
+		t.Fatalf("moving backward %v, next point %d; want false, 1", m.movingBackward, m.nextPointIndex)
+	}
+}
+
+func TestMoveAlongRouteZeroValueLoopingTargetStartsAtRoute(t *testing.T) {
+	// A looping target left at the zero values must not walk in from (0, 0)
+	m := routeMovement{state: model.TargetStateLooping}
+	m.moveAlongRoute(testRoute, 40)
+
+	assertNear(t, m, pointAlong(testRoute, 0, 1, 40), 0.5)
+	if m.nextPointIndex != 1 {
+		t.Fatalf("next point = %d, want 1", m.nextPointIndex)
+	}
+}
+
+func TestMoveAlongRouteEmptyRoute(t *testing.T) {
+	m := routeMovement{nextPointIndex: model.RouteNotStarted, state: model.TargetStateWalking}
+	m.moveAlongRoute(nil, 100)
+
+	if m.lat != 0 || m.lng != 0 || math.IsNaN(float64(m.bearing)) {
+		t.Fatalf("target without route moved to %v,%v", m.lat, m.lng)
+	}
+}
+
+// facingNorth reports whether a bearing is within half a degree of north, seen as 0 or 360
+func facingNorth(bearing float32) bool {
+	b := math.Mod(float64(bearing)+360, 360)
+	return b < 0.5 || b > 359.5
+}
+
+func TestMoveAlongRouteBearingFollowsCorner(t *testing.T) {
+	// East to the corner, then north
+	route := [][2]float64{{10, 0.000}, {10, 0.001}, {10.001, 0.001}}
+	m := routeMovement{nextPointIndex: model.RouteNotStarted, state: model.TargetStateWalking}
+
+	m.moveAlongRoute(route, 50)
+	if math.Abs(float64(m.bearing)-90) > 0.5 {
+		t.Fatalf("bearing %.2f on the first leg, want ~90", m.bearing)
+	}
+
+	// Past the corner with the residual distance
+	m.moveAlongRoute(route, segmentLength(route, 0, 1)-50+20)
+	assertNear(t, m, pointAlong(route, 1, 2, 20), 0.5)
+	if !facingNorth(m.bearing) {
+		t.Fatalf("bearing %.2f after the corner, want ~0", m.bearing)
+	}
+
+	// The bearing is kept when the target stops at the end
+	m.moveAlongRoute(route, 1000)
+	if m.state != model.TargetStateStopped || !facingNorth(m.bearing) {
+		t.Fatalf("state %v, bearing %.2f at the end; want stopped facing ~0", m.state, m.bearing)
+	}
+}
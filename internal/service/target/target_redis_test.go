@@ -0,0 +1,103 @@
+package target
+
+import (
+	"fmt"
+	"testing"
+
+	"metalink/internal/model"
+	redis_client "metalink/internal/redis"
+	"metalink/internal/redis/redistest"
+	"metalink/internal/service/storage"
+)
+
+// newTestTargetService returns a target service holding the given targets in memory
+func newTestTargetService(targets ...*model.Target) *TargetService {
+	s := &TargetService{storage: storage.NewShardedMemoryStorage[string, *model.Target](4, nil)}
+	for _, target := range targets {
+		s.storage.Set(target.ID, target)
+	}
+	return s
+}
+
+// startTestRedis starts an in-process Redis server and points the global client at it
+func startTestRedis(t *testing.T) *redistest.Server {
+	t.Helper()
+	server, err := redistest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	if _, err := redis_client.Init(server.URL()); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestSaveDirtyTargetsToRedisKeepsTargetsDirtyOnFailure(t *testing.T) {
+	server := startTestRedis(t)
+	s := newTestTargetService(&model.Target{ID: "a"}, &model.Target{ID: "b"})
+
+	server.FailCommand("SET", true)
+	if err := s.SaveDirtyTargetsToRedis(); err == nil {
+		t.Fatal("expected the save to fail")
+	}
+	if dirty := s.storage.GetDirty(); len(dirty) != 2 {
+		t.Fatalf("got %d dirty targets after a failed save, want 2", len(dirty))
+	}
+
+	server.FailCommand("SET", false)
+	if err := s.SaveDirtyTargetsToRedis(); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := s.storage.GetDirty(); len(dirty) != 0 {
+		t.Fatalf("got %d dirty targets after a successful save, want 0", len(dirty))
+	}
+	if _, ok := server.Get(TargetRedisKey + ":a"); !ok {
+		t.Fatal("target a was not written to Redis")
+	}
+}
+
+func TestDeleteAllRedisTargetsChunkedDeletesInChunks(t *testing.T) {
+	server := startTestRedis(t)
+	for i := 0; i < 2500; i++ {
+		server.Set(fmt.Sprintf("%s:t%04d", TargetRedisKey, i), "{}")
+	}
+	server.Set("other:key", "kept")
+
+	s := newTestTargetService()
+	if err := s.DeleteAllRedisTargetsChunked(1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.Len() != 1 {
+		t.Fatalf("%d keys left, want only the non-target key", server.Len())
+	}
+	if _, ok := server.Get("other:key"); !ok {
+		t.Fatal("non-target key was deleted")
+	}
+	// Two full chunks and the remainder
+	if calls := server.Calls("DEL"); calls != 3 {
+		t.Fatalf("got %d DEL calls, want 3", calls)
+	}
+}
+
+func TestSaveTargetsToRedisSavesEachTargetOnce(t *testing.T) {
+	server := startTestRedis(t)
+	targets := []*model.Target{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	s := newTestTargetService(targets...)
+
+	// Fewer targets than config.RedisSaveWorkers
+	if err := s.saveTargetsToRedis(targets); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.Calls("SET"); calls != len(targets) {
+		t.Fatalf("got %d SET calls for %d targets", calls, len(targets))
+	}
+	for _, target := range targets {
+		if _, ok := server.Get(TargetRedisKey + ":" + target.ID); !ok {
+			t.Fatalf("target %s was not written to Redis", target.ID)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"metalink/internal/config"
+	"metalink/internal/logx"
+	"metalink/internal/model"
+	pg "metalink/internal/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// targetCopyColumns are the targets columns written by COPY, the same ones saveAllTargetsToPG upserts
+var targetCopyColumns = []string{
+	"id", "name", "speed", "state", "current_lat", "current_lng", "current_bearing",
+	"moving_backward", "target_lat", "target_lng", "next_point_index", "route", "created_at", "updated_at",
+}
+
+// SeedTestTargetsViaCopy creates count walking test targets in PostgreSQL using a single COPY stream
+// Much faster than SeedTestTargetsPGParallel for large counts
+func (s *TargetService) SeedTestTargetsViaCopy(count int, opts SeedOptions) error {
+	sqlDB, err := pg.GetDB().DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %w", err)
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get PostgreSQL connection: %w", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	var copied int64
+	err = conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected PostgreSQL driver connection %T", driverConn)
+		}
+
+		rows := &seedTargetRows{count: count, opts: opts, now: time.Now()}
+		copied, err = stdlibConn.Conn().CopyFrom(ctx, pgx.Identifier{"targets"}, targetCopyColumns, rows)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy test targets: %w", err)
+	}
+
+	logx.Info("Successfully seeded %d targets in PostgreSQL via COPY in %v", copied, time.Since(start))
+	return nil
+}
+
+// seedTargetRows generates seeded target rows for pgx.CopyFrom on the fly
+type seedTargetRows struct {
+	count int
+	opts  SeedOptions
+	now   time.Time
+	index int
+	row   []any
+	err   error
+}
+
+var _ pgx.CopyFromSource = (*seedTargetRows)(nil)
+
+// Next prepares the next row, returning false when all rows are generated or on error
+func (r *seedTargetRows) Next() bool {
+	if r.err != nil || r.index >= r.count {
+		return false
+	}
+
+	id, err := seedTargetID(r.opts, r.index)
+	if err != nil {
+		r.err = err
+		return false
+	}
+	route := seedTargetRoute(r.opts, r.index)
+	r.index++
+
+	if r.index%100000 == 0 {
+		logx.Debug("Streamed %d targets of %d to PostgreSQL", r.index, r.count)
+	}
+
+	r.row = []any{
+		id, "Target " + id, float32(config.DefaultTargetSpeed), int(model.TargetStateWalking),
+		float32(0), float32(0), float32(0),
+		false, float32(0), float32(0), model.RouteNotStarted, route, r.now, r.now,
+	}
+	return true
+}
+
+// Values returns the current row
+func (r *seedTargetRows) Values() ([]any, error) {
+	return r.row, nil
+}
+
+// Err returns the error that stopped row generation
+func (r *seedTargetRows) Err() error {
+	return r.err
+}
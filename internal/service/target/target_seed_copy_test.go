@@ -0,0 +1,79 @@
+package target
+
+import (
+	"os"
+	"testing"
+
+	pg "metalink/internal/postgres"
+)
+
+func TestSeedTargetRowsMatchCopyColumns(t *testing.T) {
+	rows := &seedTargetRows{count: 3, opts: SeedOptions{IDSeed: "seed"}}
+
+	var ids []string
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(values) != len(targetCopyColumns) {
+			t.Fatalf("row has %d values for %d columns", len(values), len(targetCopyColumns))
+		}
+		ids = append(ids, values[0].(string))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("generated %d rows, want 3", len(ids))
+	}
+
+	// The same ID seed gives the same IDs as the GORM seeding path
+	for i, id := range ids {
+		want, err := seedTargetID(SeedOptions{IDSeed: "seed"}, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != want {
+			t.Fatalf("row %d has ID %q, want %q", i, id, want)
+		}
+	}
+}
+
+// benchmarkSeedTargets seeds 100k targets per iteration into the database at METALINK_TEST_DB_URL
+// The targets table is truncated before every iteration, so never point it at real data
+func benchmarkSeedTargets(b *testing.B, seed func(s *TargetService, count int) error) {
+	url := os.Getenv("METALINK_TEST_DB_URL")
+	if url == "" {
+		b.Skip("METALINK_TEST_DB_URL is not set")
+	}
+	db, err := pg.Init(url)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	s := newTestTargetService()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		if err := db.Exec("TRUNCATE targets").Error; err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := seed(s, 100000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSeedTestTargetsPGParallel(b *testing.B) {
+	benchmarkSeedTargets(b, func(s *TargetService, count int) error {
+		return s.SeedTestTargetsPGParallel(count, SeedOptions{})
+	})
+}
+
+func BenchmarkSeedTestTargetsViaCopy(b *testing.B) {
+	benchmarkSeedTargets(b, func(s *TargetService, count int) error {
+		return s.SeedTestTargetsViaCopy(count, SeedOptions{})
+	})
+}
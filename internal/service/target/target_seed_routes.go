@@ -0,0 +1,111 @@
+package target
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strings"
+
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+)
+
+// Generated seed route shape
+const (
+	seedRouteMinPoints   = 5
+	seedRouteMaxPoints   = 15
+	seedRouteMinStep     = 50.0  // Meters between route points
+	seedRouteMaxStep     = 250.0 // Meters between route points
+	seedRouteMaxTurn     = math.Pi / 4
+	seedMetersPerDegree  = 111320.0
+	seedRouteMaxAttempts = 8 // Tries to find a step that stays inside the bounds
+)
+
+// LoadSeedRoutes reads encoded route polylines from a file, one per line
+// Empty lines and lines starting with # are skipped
+func LoadSeedRoutes(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seed routes file: %w", err)
+	}
+	defer file.Close()
+
+	var routes []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(util.DecodePolyline(line)) < 2 {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, ErrInvalidRoute)
+		}
+		routes = append(routes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seed routes file: %w", err)
+	}
+
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("seed routes file %s contains no routes", path)
+	}
+	return routes, nil
+}
+
+// seedTargetRoute returns the route of the index-th seeded target
+// The route depends only on the options and the index, so parallel workers and repeated runs agree
+func seedTargetRoute(opts SeedOptions, index int) string {
+	switch {
+	case len(opts.Routes) > 0:
+		return opts.Routes[seedRouteRand(opts, index).IntN(len(opts.Routes))]
+	case opts.RouteBounds != nil:
+		return generateSeedRoute(seedRouteRand(opts, index), *opts.RouteBounds)
+	default:
+		return seedTestRoute
+	}
+}
+
+// seedRouteRand returns the random source for the index-th seeded target
+func seedRouteRand(opts SeedOptions, index int) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(opts.RouteSeed), uint64(index)))
+}
+
+// generateSeedRoute returns a short random walk inside the [lon, lat] bounds
+// Each step turns by up to seedRouteMaxTurn; steps leaving the bounds are retried in a new direction
+func generateSeedRoute(rng *rand.Rand, bounds orb.Bound) string {
+	lat := bounds.Min.Lat() + rng.Float64()*(bounds.Max.Lat()-bounds.Min.Lat())
+	lng := bounds.Min.Lon() + rng.Float64()*(bounds.Max.Lon()-bounds.Min.Lon())
+	bearing := rng.Float64() * 2 * math.Pi
+
+	numPoints := seedRouteMinPoints + rng.IntN(seedRouteMaxPoints-seedRouteMinPoints+1)
+	points := make([][2]float64, 0, numPoints)
+	points = append(points, [2]float64{lat, lng})
+
+	for len(points) < numPoints {
+		var nextLat, nextLng float64
+		for attempt := 0; attempt < seedRouteMaxAttempts; attempt++ {
+			bearing += (rng.Float64()*2 - 1) * seedRouteMaxTurn
+			if attempt > 0 {
+				bearing = rng.Float64() * 2 * math.Pi
+			}
+
+			step := seedRouteMinStep + rng.Float64()*(seedRouteMaxStep-seedRouteMinStep)
+			nextLat = lat + step*math.Cos(bearing)/seedMetersPerDegree
+			nextLng = lng + step*math.Sin(bearing)/(seedMetersPerDegree*math.Cos(lat*math.Pi/180))
+			if bounds.Contains(orb.Point{nextLng, nextLat}) {
+				break
+			}
+		}
+
+		// Bounds smaller than a step still give a valid route
+		lat = min(max(nextLat, bounds.Min.Lat()), bounds.Max.Lat())
+		lng = min(max(nextLng, bounds.Min.Lon()), bounds.Max.Lon())
+		points = append(points, [2]float64{lat, lng})
+	}
+
+	return util.EncodePolyline(points)
+}
@@ -0,0 +1,60 @@
+package target
+
+import (
+	"slices"
+	"testing"
+
+	"metalink/internal/util"
+
+	"github.com/paulmach/orb"
+)
+
+// seedRouteSet returns the routes of the first count seeded targets
+func seedRouteSet(opts SeedOptions, count int) []string {
+	routes := make([]string, count)
+	for i := range routes {
+		routes[i] = seedTargetRoute(opts, i)
+	}
+	return routes
+}
+
+func TestSeedTargetRouteRepeatsForSameSeed(t *testing.T) {
+	bounds := orb.Bound{Min: orb.Point{13.3, 52.4}, Max: orb.Point{13.5, 52.6}}
+	cases := map[string]SeedOptions{
+		"generated": {RouteBounds: &bounds, RouteSeed: 42},
+		"picked":    {Routes: []string{seedTestRoute, "_p~iF~ps|U_ulLnnqC", "_ibE_seK_seK_seK"}, RouteSeed: 42},
+	}
+
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			first := seedRouteSet(opts, 50)
+			if !slices.Equal(first, seedRouteSet(opts, 50)) {
+				t.Fatal("same route seed gave different route sets")
+			}
+
+			other := opts
+			other.RouteSeed = 43
+			if slices.Equal(first, seedRouteSet(other, 50)) {
+				t.Fatal("different route seeds gave the same route set")
+			}
+		})
+	}
+}
+
+func TestSeedTargetRouteStaysInsideBounds(t *testing.T) {
+	bounds := orb.Bound{Min: orb.Point{13.3, 52.4}, Max: orb.Point{13.31, 52.41}}
+	opts := SeedOptions{RouteBounds: &bounds, RouteSeed: 7}
+	padded := bounds.Pad(1e-5) // Polyline precision
+
+	for i, route := range seedRouteSet(opts, 50) {
+		points := util.DecodePolyline(route)
+		if len(points) < seedRouteMinPoints || len(points) > seedRouteMaxPoints {
+			t.Fatalf("route %d has %d points", i, len(points))
+		}
+		for _, p := range points {
+			if !padded.Contains(orb.Point{p[1], p[0]}) {
+				t.Fatalf("route %d point %v is outside %v", i, p, bounds)
+			}
+		}
+	}
+}
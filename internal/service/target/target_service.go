@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"runtime"
 	"strings"
 	"sync"
@@ -12,6 +11,7 @@ import (
 	"time"
 
 	"metalink/internal/config"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 	pg "metalink/internal/postgres"
 	redis_client "metalink/internal/redis"
@@ -19,6 +19,8 @@ import (
 	"metalink/internal/service/zone"
 	"metalink/internal/util"
 
+	"github.com/dhconnelly/rtreego"
+	"github.com/paulmach/orb"
 	"gorm.io/gorm"
 )
 
@@ -26,8 +28,32 @@ const TargetRedisKey = "target"
 
 type TargetService struct {
 	storage     storage.Storage[string, *model.Target]
-	initialized bool
+	initialized atomic.Bool
 	initMutex   sync.RWMutex
+
+	// ActiveWindow limits movement and effects to a daily UTC time window (nil = always active)
+	ActiveWindow  *util.TimeWindow
+	outsideWindow atomic.Bool // Set while passes are skipped outside ActiveWindow, to log only the changes
+
+	// ZoneTransitionsChannel is the Redis channel zone enter/exit events are published to (empty = disabled)
+	ZoneTransitionsChannel string
+
+	// TrackZoneOccupancy keeps the ZoneService count of targets per zone up to date
+	TrackZoneOccupancy bool
+
+	spatialIndex *rtreego.Rtree // Target positions, rebuilt by the first query after a movement pass
+	indexStale   atomic.Bool    // Set when targets moved since spatialIndex was built
+	indexMutex   sync.RWMutex   // Guards spatialIndex swaps
+
+	// CompactMovement moves targets from a struct-of-arrays copy of their movement state
+	// with all routes in one shared slice, instead of decoding RoutePoints per target
+	CompactMovement bool
+	compact         *compactTargets // Used only by ProcessTargets
+	compactStale    atomic.Bool     // Set when targets are added or rerouted
+
+	locks         targetLocks       // Per-target locks, see targetLocks
+	pendingRoutes map[string]string // Target ID -> route queued by UpdateTargetRoute for the next pass
+	pendingMutex  sync.Mutex        // Guards pendingRoutes
 }
 
 var (
@@ -51,41 +77,86 @@ func (s *TargetService) InitService(ctx context.Context) error {
 	s.initMutex.Lock()
 	defer s.initMutex.Unlock()
 
-	if s.initialized {
+	if s.initialized.Load() {
 		return nil
 	}
 
-	log.Println("Initializing TargetService...")
+	logx.Info("Initializing TargetService...")
 	startTime := time.Now()
 
 	// Step 1: Load full data from PostgreSQL
-	log.Println("Loading targets from PostgreSQL...")
+	logx.Info("Loading targets from PostgreSQL...")
 	pgTargets, err := s.loadAllTargetsFromPG()
 	if err != nil {
 		return fmt.Errorf("failed to load targets from PostgreSQL: %w", err)
 	}
-	log.Printf("Loaded %d targets from PostgreSQL in %v", len(pgTargets), time.Since(startTime))
+	logx.Info("Loaded %d targets from PostgreSQL in %v", len(pgTargets), time.Since(startTime))
 
 	// Step 2: Load updates from Redis (with timestamps)
-	log.Println("Loading target updates from Redis...")
+	logx.Info("Loading target updates from Redis...")
 	redisTargets, err := s.loadAllTargetsFromRedis(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load targets from Redis: %w", err)
 	}
-	log.Printf("Loaded %d target updates from Redis", len(redisTargets))
+	logx.Info("Loaded %d target updates from Redis", len(redisTargets))
 
 	// Step 3: Merge data (Redis updates override PostgreSQL data)
-	log.Println("Merging data from PostgreSQL and Redis...")
+	logx.Info("Merging data from PostgreSQL and Redis...")
 	mergedCount := s.mergeTargetsIntoMemory(pgTargets, redisTargets)
-	log.Printf("Merged %d newer targets from Redis", mergedCount)
+	logx.Info("Merged %d newer targets from Redis", mergedCount)
 
-	log.Printf("Initialization complete: %d targets in memory, took %v",
+	logx.Info("Initialization complete: %d targets in memory, took %v",
 		s.storage.Count(), time.Since(startTime))
+	s.logShardDistribution()
+	s.invalidateSpatialIndex()
 
-	s.initialized = true
+	s.initialized.Store(true)
 	return nil
 }
 
+// LoadTargets stores targets that don't come from PostgreSQL (tests, tools) and marks the service initialized
+func (s *TargetService) LoadTargets(targets []*model.Target) {
+	s.initMutex.Lock()
+	defer s.initMutex.Unlock()
+
+	for _, target := range targets {
+		s.storage.Set(target.ID, target)
+	}
+	s.compactStale.Store(true)
+	s.invalidateSpatialIndex()
+	s.initialized.Store(true)
+}
+
+// IsInitialized reports whether InitService has finished loading targets
+func (s *TargetService) IsInitialized() bool {
+	return s.initialized.Load()
+}
+
+// Count returns the number of targets in memory
+func (s *TargetService) Count() int {
+	return s.storage.Count()
+}
+
+// logShardDistribution logs how evenly targets are spread across storage shards
+func (s *TargetService) logShardDistribution() {
+	sharded, ok := s.storage.(interface{ ShardStats() []int })
+	if !ok {
+		return
+	}
+
+	stats := sharded.ShardStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	minCount, maxCount := stats[0], stats[0]
+	for _, count := range stats[1:] {
+		minCount = min(minCount, count)
+		maxCount = max(maxCount, count)
+	}
+	logx.Info("Target shard distribution: %d shards, min %d, max %d targets per shard", len(stats), minCount, maxCount)
+}
+
 // loadAllTargetsFromPG loads all targets from PostgreSQL
 func (s *TargetService) loadAllTargetsFromPG() ([]*model.Target, error) {
 	db := pg.GetDB()
@@ -161,7 +232,11 @@ func (s *TargetService) loadAllTargetsFromRedis(ctx context.Context) (map[string
 // mergeTargetsIntoMemory merges targets from PostgreSQL and Redis into memory storage
 func (s *TargetService) mergeTargetsIntoMemory(pgTargets []*model.Target, redisTargets map[string]*model.Target) int {
 	// First load all PostgreSQL targets into memory
+	// Routes are decoded here so the movement pass doesn't have to (the compact store decodes its own)
 	for _, pgTarget := range pgTargets {
+		if !s.CompactMovement {
+			pgTarget.EnsureRoutePoints()
+		}
 		s.storage.Set(pgTarget.ID, pgTarget)
 	}
 
@@ -179,6 +254,9 @@ func (s *TargetService) mergeTargetsIntoMemory(pgTargets []*model.Target, redisT
 				redisTarget.DeletedAt = existingTarget.DeletedAt
 				redisTarget.RoutePoints = existingTarget.RoutePoints
 			}
+			if !s.CompactMovement {
+				redisTarget.EnsureRoutePoints()
+			}
 			s.storage.Set(id, redisTarget)
 			mergedCount++
 		}
@@ -191,10 +269,31 @@ func (s *TargetService) mergeTargetsIntoMemory(pgTargets []*model.Target, redisT
 func (s *TargetService) ProcessTargets() {
 	processingStart := time.Now()
 
-	// Get all targets once using GetAllValues
-	allTargets := s.storage.GetAllValues()
+	// Route changes are applied here, before any worker touches the targets
+	s.applyPendingRoutes()
+
+	// The world is frozen outside the active window, logged once when leaving and entering it
+	if s.ActiveWindow != nil && !s.ActiveWindow.Contains(processingStart.UTC()) {
+		if !s.outsideWindow.Swap(true) {
+			logx.Info("Outside active window %s UTC, pausing target processing", s.ActiveWindow)
+		}
+		return
+	}
+	if s.outsideWindow.Swap(false) {
+		logx.Info("Inside active window %s UTC, resuming target processing", s.ActiveWindow)
+	}
+
+	// Get all targets once using GetAllValues (or the compact store's targets)
+	var compact *compactTargets
+	var allTargets []*model.Target
+	if s.CompactMovement {
+		compact = s.compactTargets()
+		allTargets = compact.targets
+	} else {
+		allTargets = s.storage.GetAllValues()
+	}
 	if len(allTargets) == 0 {
-		log.Printf("No targets to process")
+		logx.Info("No targets to process")
 		return
 	}
 
@@ -212,6 +311,12 @@ func (s *TargetService) ProcessTargets() {
 
 	zoneService := zone.GetZoneService()
 
+	// Zone transitions are collected from all workers and published once per tick
+	publishTransitions := s.ZoneTransitionsChannel != ""
+	trackTransitions := publishTransitions || s.TrackZoneOccupancy
+	var transitionEvents []ZoneTransitionEvent
+	var eventsMutex sync.Mutex
+
 	for i := 0; i < numWorkers; i++ {
 		start := i * targetsPerWorker
 		end := start + targetsPerWorker
@@ -220,21 +325,68 @@ func (s *TargetService) ProcessTargets() {
 		}
 
 		wg.Add(1)
-		go func(targets []*model.Target) {
+		go func(start int, targets []*model.Target) {
 			defer wg.Done()
 
 			// Local counters for this worker
 			workerEffectsValue := float64(0)
 
-			// Process both movements and effects in single loop
-			for _, target := range targets {
-				// Step 1: Process movement if needed
-				if target.State == model.TargetStateWalking {
-					s.updateTargetPosition(target)
+			// Step 1: Process movement if needed
+			points := make([][2]float64, len(targets))
+			if compact != nil {
+				now := time.Now()
+				for i := range targets {
+					if compact.move(start + i) {
+						unlock := s.locks.lock(targets[i].ID)
+						target := compact.writeBack(start+i, now)
+						unlock()
+						s.storage.Set(target.ID, target)
+					}
+					points[i] = compact.position(start + i)
+				}
+			} else {
+				for i, target := range targets {
+					if target.State.IsMoving() {
+						s.updateTargetPosition(target)
+					}
+					points[i] = [2]float64{float64(target.CurrentLat), float64(target.CurrentLng)}
 				}
+			}
 
-				// Step 2: Process effects for all targets
-				effects := zoneService.GetEffectsForTarget(float64(target.CurrentLat), float64(target.CurrentLng))
+			// Step 2: Process effects for all targets in a single batch
+			var effectsPerTarget []map[model.TargetParamType]float32
+			if trackTransitions {
+				var zoneIDsPerTarget [][]string
+				effectsPerTarget, zoneIDsPerTarget = zoneService.GetEffectsAndZoneIDsForTargets(points)
+
+				// Step 3: Detect zone boundary crossings
+				var workerEvents []ZoneTransitionEvent
+				for i, target := range targets {
+					unlock := s.locks.lock(target.ID)
+					firstObservation := target.LastZoneIDs == nil && zoneIDsPerTarget[i] != nil
+					event, changed := detectZoneTransition(target, zoneIDsPerTarget[i])
+					unlock()
+					if s.TrackZoneOccupancy {
+						if firstObservation {
+							zoneService.UpdateOccupancy(zoneIDsPerTarget[i], nil)
+						} else if changed {
+							zoneService.UpdateOccupancy(event.Entered, event.Exited)
+						}
+					}
+					if changed && publishTransitions {
+						workerEvents = append(workerEvents, event)
+					}
+				}
+				if len(workerEvents) > 0 {
+					eventsMutex.Lock()
+					transitionEvents = append(transitionEvents, workerEvents...)
+					eventsMutex.Unlock()
+				}
+			} else {
+				effectsPerTarget = zoneService.GetEffectsForTargets(points)
+			}
+
+			for _, effects := range effectsPerTarget {
 				for _, effect := range effects {
 					workerEffectsValue += float64(effect)
 				}
@@ -242,85 +394,196 @@ func (s *TargetService) ProcessTargets() {
 
 			// Update atomic counters
 			atomic.AddInt64(&totalEffectsValue, int64(workerEffectsValue*1000))
-		}(allTargets[start:end])
+		}(start, allTargets[start:end])
 	}
 
 	wg.Wait()
 
+	// The new positions are indexed by the next viewport query
+	s.invalidateSpatialIndex()
+
+	if len(transitionEvents) > 0 {
+		if err := s.publishZoneTransitions(context.Background(), transitionEvents); err != nil {
+			logx.Error("Error publishing zone transitions: %v", err)
+		}
+	}
+
 	processingDuration := time.Since(processingStart)
 	finalEffectsValue := float64(totalEffectsValue) / 1000.0
 
-	log.Printf("PROCESSING TIME: %v | Total effects: %.2f", processingDuration, finalEffectsValue)
+	logx.Info("PROCESSING TIME: %v | Total effects: %.2f", processingDuration, finalEffectsValue)
+}
+
+// compactTargets returns the compact movement store, rebuilding it after targets were added or rerouted
+func (s *TargetService) compactTargets() *compactTargets {
+	if s.compact == nil || s.compactStale.Swap(false) || s.compact.len() != s.storage.Count() {
+		start := time.Now()
+		s.compact = newCompactTargets(s.storage.GetAllValues())
+		logx.Info("Built compact movement store: %d targets, %d route points in %v",
+			s.compact.len(), len(s.compact.points), time.Since(start))
+	}
+	return s.compact
 }
 
 // updateTargetPosition updates a target's position based on its speed and route
 func (s *TargetService) updateTargetPosition(target *model.Target) {
+	unlock := s.locks.lock(target.ID)
+
 	// Decode route points if not already decoded
-	if target.RoutePoints == nil {
-		target.RoutePoints = util.DecodePolyline(target.Route)
+	route := target.EnsureRoutePoints()
+
+	movement := movementOf(target)
+	movement.moveAlongRoute(route, target.Speed.DistanceOver(config.TargetsWorkerInterval))
+	movement.applyTo(target)
+
+	// Mark the target as updated
+	target.UpdatedAt = time.Now()
+	unlock()
+	s.storage.Set(target.ID, target)
+}
+
+// routeMovement is the part of a target that moving along its route changes
+type routeMovement struct {
+	lat            float32
+	lng            float32
+	bearing        float32
+	nextPointIndex int
+	movingBackward bool
+	state          model.TargetState
+}
+
+// movementOf returns the movement state of a target
+func movementOf(target *model.Target) routeMovement {
+	return routeMovement{
+		lat:            target.CurrentLat,
+		lng:            target.CurrentLng,
+		bearing:        target.CurrentBearing,
+		nextPointIndex: target.NextPointIndex,
+		movingBackward: target.MovingBackward,
+		state:          target.State,
 	}
+}
+
+// applyTo copies the movement state back to a target
+func (m *routeMovement) applyTo(target *model.Target) {
+	target.CurrentLat = m.lat
+	target.CurrentLng = m.lng
+	target.CurrentBearing = m.bearing
+	target.NextPointIndex = m.nextPointIndex
+	target.MovingBackward = m.movingBackward
+	target.State = m.state
+}
 
-	remainingDistance := float64(target.Speed * float32(config.TargetsWorkerInterval.Seconds()))
+// notStarted reports whether the target has not yet been placed on its route
+// model.RouteNotStarted marks new targets explicitly. Index 0 is also treated as not started for walking
+// targets, which never head back to point 0, and for targets left at the zero position; looping and
+// ping-pong targets that are on their way back to point 0 keep moving
+func (m *routeMovement) notStarted() bool {
+	if m.nextPointIndex < 0 {
+		return true
+	}
+	return m.nextPointIndex == 0 && (m.state == model.TargetStateWalking || (m.lat == 0 && m.lng == 0))
+}
 
+// moveAlongRoute moves up to remainingDistance meters along the route ([lat, lng] points)
+func (m *routeMovement) moveAlongRoute(route [][2]float64, remainingDistance float64) {
 	// Initialize target position if not set
-	if target.NextPointIndex <= 0 {
-		if len(target.RoutePoints) > 0 {
-			target.CurrentLat = float32(target.RoutePoints[0][0])
-			target.CurrentLng = float32(target.RoutePoints[0][1])
-			target.NextPointIndex = 1
-		} else {
+	if m.notStarted() {
+		if len(route) == 0 {
 			// No route points, can't move
-			target.UpdatedAt = time.Now()
-			s.storage.Set(target.ID, target)
 			return
 		}
+		m.lat = float32(route[0][0])
+		m.lng = float32(route[0][1])
+		m.nextPointIndex = 1
+		m.movingBackward = false
 	}
 
+	// Count points reached without moving to avoid spinning on degenerate looped routes
+	zeroDistanceSteps := 0
+
 	// Move target along route
-	for remainingDistance > 0 && target.NextPointIndex < len(target.RoutePoints) {
+	for remainingDistance > 0 && m.nextPointIndex < len(route) {
 		// Get next point coordinates
-		nextPointLat := target.RoutePoints[target.NextPointIndex][0]
-		nextPointLng := target.RoutePoints[target.NextPointIndex][1]
+		nextPointLat := route[m.nextPointIndex][0]
+		nextPointLng := route[m.nextPointIndex][1]
 
 		// Calculate distance to next point
-		distance := util.HaversineDistance(
-			float64(target.CurrentLat),
-			float64(target.CurrentLng),
-			nextPointLat,
-			nextPointLng,
-		)
+		distance := util.HaversineDistance(float64(m.lat), float64(m.lng), nextPointLat, nextPointLng)
+
+		// Face towards the next point (keep the last bearing when already there)
+		if distance > 0 {
+			m.bearing = float32(util.Bearing(float64(m.lat), float64(m.lng), nextPointLat, nextPointLng))
+		}
 
 		if remainingDistance >= distance {
 			// We can reach (or pass) the next point
-			target.CurrentLat = float32(nextPointLat)
-			target.CurrentLng = float32(nextPointLng)
+			m.lat = float32(nextPointLat)
+			m.lng = float32(nextPointLng)
 			remainingDistance -= distance
-			target.NextPointIndex++
 
-			// Check if we reached the end of the route
-			if target.NextPointIndex >= len(target.RoutePoints) {
-				target.State = model.TargetStateStopped
+			if distance == 0 {
+				zeroDistanceSteps++
+				if zeroDistanceSteps > len(route) {
+					break
+				}
+			} else {
+				zeroDistanceSteps = 0
+			}
+
+			// Check if we reached the end of the route, the remaining distance is consumed on the next segment
+			if !m.advanceToNextPoint(len(route)) {
+				m.state = model.TargetStateStopped
 				break
 			}
 		} else {
 			// Move partially toward the next point
-			newPosition := util.MoveToward(
-				float64(target.CurrentLat),
-				float64(target.CurrentLng),
-				nextPointLat,
-				nextPointLng,
-				remainingDistance,
-			)
-
-			target.CurrentLat = float32(newPosition[0])
-			target.CurrentLng = float32(newPosition[1])
+			newPosition := util.MoveToward(float64(m.lat), float64(m.lng), nextPointLat, nextPointLng, remainingDistance)
+			m.lat = float32(newPosition[0])
+			m.lng = float32(newPosition[1])
 			remainingDistance = 0
 		}
 	}
+}
 
-	// Mark the target as updated
-	target.UpdatedAt = time.Now()
-	s.storage.Set(target.ID, target)
+// advanceToNextPoint moves nextPointIndex to the following route point according to the target state
+// Returns false when the route is finished and the target should stop
+func (m *routeMovement) advanceToNextPoint(pointsCount int) bool {
+	switch m.state {
+	case model.TargetStateLooping:
+		if pointsCount < 2 {
+			return false
+		}
+		m.nextPointIndex = (m.nextPointIndex + 1) % pointsCount
+
+	case model.TargetStatePingPong:
+		if pointsCount < 2 {
+			return false
+		}
+		if m.movingBackward {
+			m.nextPointIndex--
+			if m.nextPointIndex < 0 {
+				// Reached the start, turn around
+				m.movingBackward = false
+				m.nextPointIndex = 1
+			}
+		} else {
+			m.nextPointIndex++
+			if m.nextPointIndex >= pointsCount {
+				// Reached the end, turn around
+				m.movingBackward = true
+				m.nextPointIndex = pointsCount - 2
+			}
+		}
+
+	default:
+		m.nextPointIndex++
+		if m.nextPointIndex >= pointsCount {
+			return false
+		}
+	}
+
+	return true
 }
 
 // StartPersistenceWorkers starts workers for persisting data to Redis and PostgreSQL
@@ -330,10 +593,10 @@ func (s *TargetService) StartPersistenceWorkers() {
 	go func() {
 		for range redisTimer.C {
 			startTime := time.Now()
-			if err := s.SaveAllTargetsToRedisV4(); err != nil {
-				log.Printf("Error saving to Redis: %v", err)
+			if err := s.SaveDirtyTargetsToRedis(); err != nil {
+				logx.Error("Error saving to Redis: %v", err)
 			}
-			log.Printf("Time taken to save dirty targets to REDIS << %v", time.Since(startTime))
+			logx.Info("Time taken to save dirty targets to REDIS << %v", time.Since(startTime))
 		}
 	}()
 
@@ -343,21 +606,52 @@ func (s *TargetService) StartPersistenceWorkers() {
 		for range pgTimer.C {
 			startTime := time.Now() // Start timing
 			if err := s.SaveAllTargetsToPGv2(); err != nil {
-				log.Printf("Error saving to PostgreSQL: %v", err)
+				logx.Error("Error saving to PostgreSQL: %v", err)
 			}
-			log.Printf("Time taken to save all targets to POSTGRESQL << %v", time.Since(startTime))
+			logx.Info("Time taken to save all targets to POSTGRESQL << %v", time.Since(startTime))
 		}
 	}()
 }
 
-// SaveAllTargetsToPGv3 saves all targets to PostgreSQL using bulk upsert SQL
+// Shutdown flushes dirty targets to Redis and all targets to PostgreSQL
+// Returns ctx.Err() if the flush doesn't finish before the context deadline
+func (s *TargetService) Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		if err := s.SaveDirtyTargetsToRedis(); err != nil {
+			logx.Error("Error flushing targets to Redis: %v", err)
+		}
+
+		count := s.storage.Count()
+		if err := s.saveAllTargetsToPG(ctx); err != nil {
+			done <- fmt.Errorf("failed to flush targets to PostgreSQL: %w", err)
+			return
+		}
+		logx.Info("Flushed %d targets to PostgreSQL", count)
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("target flush interrupted: %w", ctx.Err())
+	}
+}
+
+// SaveAllTargetsToPGv2 saves all targets to PostgreSQL using bulk upsert SQL
 func (s *TargetService) SaveAllTargetsToPGv2() error {
+	return s.saveAllTargetsToPG(context.Background())
+}
+
+// saveAllTargetsToPG saves all targets to PostgreSQL, aborting when ctx is done
+func (s *TargetService) saveAllTargetsToPG(ctx context.Context) error {
 	allTargets := s.storage.GetAllValues()
 	if len(allTargets) == 0 {
 		return nil
 	}
 
-	db := pg.GetDB()
+	db := pg.GetDB().WithContext(ctx)
 	batchSize := 2000
 
 	// Process in batches to avoid overwhelming the database
@@ -369,44 +663,56 @@ func (s *TargetService) SaveAllTargetsToPGv2() error {
 
 		batch := allTargets[i:end]
 
-		err := db.Transaction(func(tx *gorm.DB) error {
-			// Prepare for bulk upsert
-			sql := `INSERT INTO targets (id, name, speed, state, current_lat, current_lng, 
-                   target_lat, target_lng, next_point_index, route, created_at, updated_at)
-                   VALUES `
-
-			values := []interface{}{}
-			placeholders := []string{}
-
-			for i, target := range batch {
-				pgTarget := target.ToPG()
-				offset := i * 12
-
-				placeholders = append(placeholders,
-					fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-						offset+1, offset+2, offset+3, offset+4, offset+5, offset+6,
-						offset+7, offset+8, offset+9, offset+10, offset+11, offset+12))
+		// Prepare for bulk upsert
+		sql := `INSERT INTO targets (id, name, speed, state, current_lat, current_lng, current_bearing,
+	               moving_backward, target_lat, target_lng, next_point_index, route, created_at, updated_at)
+	               VALUES `
+
+		values := []interface{}{}
+		placeholders := []string{}
+
+		for i, target := range batch {
+			unlock := s.locks.lock(target.ID)
+			pgTarget := target.ToPG()
+			unlock()
+			offset := i * 14
+
+			placeholders = append(placeholders,
+				fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+					offset+1, offset+2, offset+3, offset+4, offset+5, offset+6, offset+7,
+					offset+8, offset+9, offset+10, offset+11, offset+12, offset+13, offset+14))
+
+			values = append(values,
+				pgTarget.ID, pgTarget.Name, pgTarget.Speed, pgTarget.State,
+				pgTarget.CurrentLat, pgTarget.CurrentLng, pgTarget.CurrentBearing, pgTarget.MovingBackward,
+				pgTarget.TargetLat, pgTarget.TargetLng, pgTarget.NextPointIndex, pgTarget.Route,
+				pgTarget.CreatedAt, pgTarget.UpdatedAt)
+		}
 
-				values = append(values,
-					pgTarget.ID, pgTarget.Name, pgTarget.Speed, pgTarget.State,
-					pgTarget.CurrentLat, pgTarget.CurrentLng, pgTarget.TargetLat, pgTarget.TargetLng,
-					pgTarget.NextPointIndex, pgTarget.Route, pgTarget.CreatedAt, pgTarget.UpdatedAt)
+		sql += strings.Join(placeholders, ",")
+		sql += ` ON CONFLICT (id) DO UPDATE SET 
+	              name = EXCLUDED.name,
+	              speed = EXCLUDED.speed,
+	              state = EXCLUDED.state,
+	              current_lat = EXCLUDED.current_lat,
+	              current_lng = EXCLUDED.current_lng,
+	              current_bearing = EXCLUDED.current_bearing,
+	              moving_backward = EXCLUDED.moving_backward,
+	              target_lat = EXCLUDED.target_lat,
+	              target_lng = EXCLUDED.target_lng,
+	              next_point_index = EXCLUDED.next_point_index,
+	              route = EXCLUDED.route,
+	              updated_at = EXCLUDED.updated_at`
+
+		// Retry transient failures so a connection blip doesn't abort the whole save
+		// The statement is built once per batch, retries only re-run it
+		err := util.WithRetryContext(ctx, config.PGSaveRetryAttempts, config.PGSaveRetryBackoff, func() error {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-
-			sql += strings.Join(placeholders, ",")
-			sql += ` ON CONFLICT (id) DO UPDATE SET 
-                  name = EXCLUDED.name,
-                  speed = EXCLUDED.speed,
-                  state = EXCLUDED.state,
-                  current_lat = EXCLUDED.current_lat,
-                  current_lng = EXCLUDED.current_lng,
-                  target_lat = EXCLUDED.target_lat,
-                  target_lng = EXCLUDED.target_lng,
-                  next_point_index = EXCLUDED.next_point_index,
-                  route = EXCLUDED.route,
-                  updated_at = EXCLUDED.updated_at`
-
-			return tx.Exec(sql, values...).Error
+			return db.Transaction(func(tx *gorm.DB) error {
+				return tx.Exec(sql, values...).Error
+			})
 		})
 
 		if err != nil {
@@ -414,7 +720,7 @@ func (s *TargetService) SaveAllTargetsToPGv2() error {
 		}
 
 		if end%10000 == 0 {
-			log.Printf("Saved batch of %d targets to PostgreSQL (%d/%d)",
+			logx.Debug("Saved batch of %d targets to PostgreSQL (%d/%d)",
 				len(batch), end, len(allTargets))
 		}
 	}
@@ -422,36 +728,61 @@ func (s *TargetService) SaveAllTargetsToPGv2() error {
 	return nil
 }
 
+// SaveDirtyTargetsToRedis saves only targets changed since the previous save to Redis
+func (s *TargetService) SaveDirtyTargetsToRedis() error {
+	dirtyTargets := s.storage.GetDirty()
+	if len(dirtyTargets) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(dirtyTargets))
+	targets := make([]*model.Target, 0, len(dirtyTargets))
+	for id, target := range dirtyTargets {
+		keys = append(keys, id)
+		targets = append(targets, target)
+	}
+
+	// Clear flags before saving: targets changed during the save are marked dirty again
+	// and get picked up by the next run
+	s.storage.ClearDirty(keys)
+
+	logx.Info("Saving %d dirty targets to Redis", len(targets))
+	if err := s.saveTargetsToRedis(targets); err != nil {
+		// Nothing tells which targets were written, so the next run retries all of them
+		s.storage.MarkDirty(keys)
+		return err
+	}
+	return nil
+}
+
 // SaveAllTargetsToRedisV4 saves targets to Redis using parallel processing
 func (s *TargetService) SaveAllTargetsToRedisV4() error {
-	allTargets := s.storage.GetAllValues()
+	return s.saveTargetsToRedis(s.storage.GetAllValues())
+}
 
+// saveTargetsToRedis saves given targets to Redis using parallel pipelines
+func (s *TargetService) saveTargetsToRedis(allTargets []*model.Target) error {
 	if len(allTargets) == 0 {
 		return nil
 	}
 
-	// Define parallel processing parameters
-	numWorkers := 8
-	batchSize := 500
-	targetsPerWorker := len(allTargets) / numWorkers
+	// Never start more workers than targets; each worker gets an equal contiguous chunk
+	numWorkers := min(config.RedisSaveWorkers, len(allTargets))
+	chunkSize := (len(allTargets) + numWorkers - 1) / numWorkers
+	batchSize := config.RedisSavePipelineBatch
 
 	// Setup wait group and error channel
 	var wg sync.WaitGroup
-	wg.Add(numWorkers)
 	errChan := make(chan error, numWorkers)
 
 	// Create atomic counter for tracking progress
 	var saved int64
 
 	// Launch worker goroutines
-	for w := 0; w < numWorkers; w++ {
-		// Calculate start and end for this worker
-		start := w * targetsPerWorker
-		end := start + targetsPerWorker
-		if w == numWorkers-1 {
-			end = len(allTargets) // Last worker takes remainder
-		}
+	for start := 0; start < len(allTargets); start += chunkSize {
+		end := min(start+chunkSize, len(allTargets))
 
+		wg.Add(1)
 		go func(workerStart, workerEnd int) {
 			defer wg.Done()
 
@@ -460,16 +791,17 @@ func (s *TargetService) SaveAllTargetsToRedisV4() error {
 
 			// Process in smaller batches
 			for i := workerStart; i < workerEnd; i += batchSize {
-				batchEnd := i + batchSize
-				if batchEnd > workerEnd {
-					batchEnd = workerEnd
-				}
+				batchEnd := min(i+batchSize, workerEnd)
 
 				pipe := client.Pipeline()
 				for j := i; j < batchEnd; j++ {
 					target := allTargets[j]
 					targetKey := fmt.Sprintf("%s:%s", TargetRedisKey, target.ID)
-					targetJSON, err := json.Marshal(target.ToRedis())
+					unlock := s.locks.lock(target.ID)
+					redisTarget := target.ToRedis()
+					unlock()
+
+					targetJSON, err := json.Marshal(redisTarget)
 					if err != nil {
 						errChan <- err
 						return
@@ -486,7 +818,7 @@ func (s *TargetService) SaveAllTargetsToRedisV4() error {
 				// Update progress
 				newCount := atomic.AddInt64(&saved, int64(batchEnd-i))
 				if newCount%100000 == 0 || newCount == int64(len(allTargets)) {
-					log.Printf("Saved %d/%d targets to Redis", newCount, len(allTargets))
+					logx.Debug("Saved %d/%d targets to Redis", newCount, len(allTargets))
 				}
 			}
 		}(start, end)
@@ -512,12 +844,23 @@ func (s *TargetService) SaveAllTargetsToRedisV4() error {
 
 // DeleteAllTargets removes all targets from Redis storage
 func (s *TargetService) DeleteAllRedisTargets() error {
+	return s.DeleteAllRedisTargetsChunked(1000)
+}
+
+// DeleteAllRedisTargetsChunked deletes all targets from Redis, issuing a DEL every chunkSize keys
+// while scanning so no single command is huge and memory stays bounded
+func (s *TargetService) DeleteAllRedisTargetsChunked(chunkSize int) error {
 	client := redis_client.GetClient()
 	ctx := context.Background()
 
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+
 	// Use Scan instead of Keys to improve performance with large datasets
 	var cursor uint64
-	var keys []string
+	var deleted int
+	keys := make([]string, 0, chunkSize)
 	pattern := fmt.Sprintf("%s:*", TargetRedisKey)
 
 	for {
@@ -528,27 +871,62 @@ func (s *TargetService) DeleteAllRedisTargets() error {
 			return err
 		}
 		keys = append(keys, batch...)
+
+		// Delete full chunks as soon as they're collected
+		for len(keys) >= chunkSize {
+			if err := client.Del(ctx, keys[:chunkSize]...).Err(); err != nil {
+				return err
+			}
+			deleted += chunkSize
+			keys = append(keys[:0], keys[chunkSize:]...)
+		}
+
 		if cursor == 0 {
 			break
 		}
 	}
 
-	// If no targets exist, return early
-	if len(keys) == 0 {
-		return nil
-	}
-
-	// Delete all target keys
-	err := client.Del(ctx, keys...).Err()
-	if err != nil {
-		return err
+	// Delete the remainder
+	if len(keys) > 0 {
+		if err := client.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+		deleted += len(keys)
 	}
 
-	log.Printf("Deleted %d targets", len(keys))
+	logx.Info("Deleted %d targets", deleted)
 	return nil
 }
 
-func (s *TargetService) SeedTestTargetsPGParallel(count int) error {
+// SeedOptions configures test target seeding
+type SeedOptions struct {
+	// IDSeed makes target IDs reproducible across runs (empty = random IDs)
+	IDSeed string
+
+	// Routes are encoded polylines seeded targets pick from (see LoadSeedRoutes)
+	Routes []string
+
+	// RouteBounds makes seeded targets walk random short routes inside these [lon, lat] bounds
+	// Ignored when Routes is set; without both every target gets the same fixed route
+	RouteBounds *orb.Bound
+
+	// RouteSeed seeds route picking and generation, the same seed always gives the same routes
+	RouteSeed int64
+}
+
+// seedTestRoute is the encoded route given to seeded test targets when no routes or bounds are set
+const seedTestRoute = "eyiaHbyokV@AAsPl@@@mG|@?B_BDQN@HCDGBMB_CzB@BsC@gJAE@sC?cNAY@q@@G?uBAgA?yI@a@EM?k@}D@cCDMGEKKeAIk@Me@EYSgA_@kCoBqMyAeKGk@Ai@EMIGAIEAG_@BaBO?y@IEECG@WqHGFiK}m@YmDEo[U?hA"
+
+// seedTargetID returns the ID of the index-th seeded target
+func seedTargetID(opts SeedOptions, index int) (string, error) {
+	if opts.IDSeed != "" {
+		return util.DeterministicIDWithLength(opts.IDSeed, index, 12)
+	}
+	return util.GenerateUUIDWithLength(12)
+}
+
+// SeedTestTargetsPGParallel creates count walking test targets in PostgreSQL
+func (s *TargetService) SeedTestTargetsPGParallel(count int, opts SeedOptions) error {
 	db := pg.GetDB()
 
 	// Define number of workers
@@ -590,7 +968,7 @@ func (s *TargetService) SeedTestTargetsPGParallel(count int) error {
 
 				var targets []model.TargetPG
 				for j := 0; j < currentBatchSize; j++ {
-					id, err := util.GenerateUUIDWithLength(12)
+					id, err := seedTargetID(opts, i+j)
 					if err != nil {
 						errChan <- err
 						return
@@ -602,9 +980,9 @@ func (s *TargetService) SeedTestTargetsPGParallel(count int) error {
 						Speed:          config.DefaultTargetSpeed,
 						TargetLat:      0,
 						TargetLng:      0,
-						Route:          "eyiaHbyokV@AAsPl@@@mG|@?B_BDQN@HCDGBMB_CzB@BsC@gJAE@sC?cNAY@q@@G?uBAgA?yI@a@EM?k@}D@cCDMGEKKeAIk@Me@EYSgA_@kCoBqMyAeKGk@Ai@EMIGAIEAG_@BaBO?y@IEECG@WqHGFiK}m@YmDEo[U?hA",
+						Route:          seedTargetRoute(opts, i+j),
 						State:          model.TargetState(model.TargetStateWalking),
-						NextPointIndex: -1,
+						NextPointIndex: model.RouteNotStarted,
 					}
 
 					targets = append(targets, target)
@@ -624,7 +1002,7 @@ func (s *TargetService) SeedTestTargetsPGParallel(count int) error {
 				// Increment atomic counter for progress tracking
 				newCount := atomic.AddInt64(&created, int64(currentBatchSize))
 				if newCount%10000 == 0 || newCount == int64(count) {
-					log.Printf("Seeded %d targets of %d in PostgreSQL", newCount, count)
+					logx.Debug("Seeded %d targets of %d in PostgreSQL", newCount, count)
 				}
 			}
 		}(w, start, end)
@@ -641,6 +1019,6 @@ func (s *TargetService) SeedTestTargetsPGParallel(count int) error {
 		}
 	}
 
-	log.Printf("Successfully seeded %d targets in PostgreSQL", count)
+	logx.Info("Successfully seeded %d targets in PostgreSQL", count)
 	return nil
 }
@@ -0,0 +1,87 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"metalink/internal/model"
+	"metalink/internal/service/storage"
+)
+
+// flushStorage records the flush reads of Shutdown and can hold them until released
+type flushStorage struct {
+	storage.Storage[string, *model.Target]
+	dirtyReads atomic.Int32
+	allReads   atomic.Int32
+	release    chan struct{} // GetDirty waits for it when set
+}
+
+func (s *flushStorage) GetDirty() map[string]*model.Target {
+	s.dirtyReads.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.Storage.GetDirty()
+}
+
+// GetAllValues returns nothing, so the PostgreSQL flush has no work and needs no database
+func (s *flushStorage) GetAllValues() []*model.Target {
+	s.allReads.Add(1)
+	return nil
+}
+
+func newFlushStorage(targets ...*model.Target) *flushStorage {
+	s := &flushStorage{Storage: storage.NewShardedMemoryStorage[string, *model.Target](4, nil)}
+	for _, target := range targets {
+		s.Set(target.ID, target)
+	}
+	return s
+}
+
+func TestShutdownFlushesTargets(t *testing.T) {
+	server := startTestRedis(t)
+	fake := newFlushStorage(&model.Target{ID: "a"})
+	s := &TargetService{storage: fake}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.dirtyReads.Load() != 1 || fake.allReads.Load() != 1 {
+		t.Fatalf("flush read dirty targets %d times and all targets %d times, want 1 each",
+			fake.dirtyReads.Load(), fake.allReads.Load())
+	}
+	if _, ok := server.Get(TargetRedisKey + ":a"); !ok {
+		t.Fatal("dirty target was not flushed to Redis")
+	}
+}
+
+func TestShutdownReturnsWhenContextIsCanceled(t *testing.T) {
+	startTestRedis(t)
+	fake := newFlushStorage(&model.Target{ID: "a"})
+	fake.release = make(chan struct{})
+	defer close(fake.release)
+	s := &TargetService{storage: fake}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(ctx) }()
+
+	// Wait until the flush is stuck in GetDirty
+	for fake.dirtyReads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown kept waiting for the flush after the context was canceled")
+	}
+}
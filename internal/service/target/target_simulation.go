@@ -0,0 +1,89 @@
+package target
+
+import (
+	"sort"
+	"time"
+
+	"metalink/internal/service/zone"
+	"metalink/internal/util"
+)
+
+// SimulationStats are the aggregate results of SimulateTicks
+type SimulationStats struct {
+	Ticks         int
+	Targets       int
+	Stopped       int           // Targets that reached the end of their route during the simulation
+	DistanceMoved float64       // Meters moved by all targets
+	EffectsTotal  float64       // Sum of all zone effects applied over all ticks
+	Elapsed       time.Duration // Simulated time, n * tickInterval
+}
+
+// SimulateTicks advances all in-memory targets n ticks of tickInterval each on a simulated clock
+// Movement and zone effects are processed as in ProcessTargets, but nothing is written to Redis or PostgreSQL,
+// no zone transitions are published and the active window is ignored
+// Targets are moved under their locks, so readers and route changes may run during a simulation
+func (s *TargetService) SimulateTicks(n int, tickInterval time.Duration) SimulationStats {
+	targets := s.storage.GetAllValues()
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ID < targets[j].ID })
+
+	stats := SimulationStats{Targets: len(targets)}
+	if n <= 0 || len(targets) == 0 {
+		return stats
+	}
+
+	zoneService := zone.GetZoneService()
+	now := time.Now()
+	points := make([][2]float64, len(targets))
+
+	for tick := 0; tick < n; tick++ {
+		now = now.Add(tickInterval)
+
+		// Routes queued by UpdateTargetRoute take effect at the start of the tick, as in ProcessTargets
+		s.applyPendingRoutes()
+
+		for i, target := range targets {
+			unlock := s.locks.lock(target.ID)
+			moving := target.State.IsMoving()
+			if moving {
+				route := target.EnsureRoutePoints()
+				movement := movementOf(target)
+
+				// A target that has not started jumps to the first route point, which is not movement
+				from := [2]float64{float64(movement.lat), float64(movement.lng)}
+				if movement.notStarted() && len(route) > 0 {
+					from = route[0]
+				}
+
+				movement.moveAlongRoute(route, target.Speed.DistanceOver(tickInterval))
+				movement.applyTo(target)
+				target.UpdatedAt = now
+
+				stats.DistanceMoved += util.HaversineDistance(from[0], from[1],
+					float64(target.CurrentLat), float64(target.CurrentLng))
+				if !target.State.IsMoving() {
+					stats.Stopped++
+				}
+			}
+			points[i] = [2]float64{float64(target.CurrentLat), float64(target.CurrentLng)}
+			unlock()
+
+			if moving {
+				s.storage.Set(target.ID, target)
+			}
+		}
+
+		for _, effects := range zoneService.GetEffectsForTargets(points) {
+			for _, effect := range effects {
+				stats.EffectsTotal += float64(effect)
+			}
+		}
+		stats.Ticks++
+	}
+
+	// Targets moved, so the compact store and the viewport index are out of date
+	s.compactStale.Store(true)
+	s.invalidateSpatialIndex()
+
+	stats.Elapsed = time.Duration(n) * tickInterval
+	return stats
+}
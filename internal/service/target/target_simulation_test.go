@@ -0,0 +1,98 @@
+package target
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"metalink/internal/model"
+	"metalink/internal/util"
+)
+
+// simulationTargets returns a fast and a slow walking target on testRoute and a stopped one
+func simulationTargets() []*model.Target {
+	route := util.EncodePolyline(testRoute)
+	return []*model.Target{
+		{ID: "fast", Speed: model.SpeedFromMs(10), Route: route, State: model.TargetStateWalking, NextPointIndex: model.RouteNotStarted},
+		{ID: "slow", Speed: model.SpeedFromMs(1), Route: route, State: model.TargetStateWalking, NextPointIndex: model.RouteNotStarted},
+		{ID: "stopped", Speed: model.SpeedFromMs(10), Route: route, State: model.TargetStateStopped, CurrentLat: 20, CurrentLng: 20},
+	}
+}
+
+func TestSimulateTicks(t *testing.T) {
+	s := newTestTargetService(simulationTargets()...)
+	stats := s.SimulateTicks(30, time.Second)
+
+	routeLength := segmentLength(testRoute, 0, 1) + segmentLength(testRoute, 1, 2)
+	if stats.Ticks != 30 || stats.Targets != 3 || stats.Elapsed != 30*time.Second {
+		t.Fatalf("stats %+v, want 30 ticks of 3 targets over 30s", stats)
+	}
+	if stats.Stopped != 1 {
+		t.Fatalf("%d targets stopped, want the fast one", stats.Stopped)
+	}
+	// The fast target walks the whole route, the slow one 1 m per tick
+	if want := routeLength + 30; math.Abs(stats.DistanceMoved-want) > 0.5 {
+		t.Fatalf("moved %.2f m, want %.2f m", stats.DistanceMoved, want)
+	}
+
+	fast, _ := s.GetTarget("fast")
+	if fast.State != model.TargetStateStopped || float64(fast.CurrentLat) != testRoute[2][0] || math.Abs(float64(fast.CurrentLng)-testRoute[2][1]) > 1e-6 {
+		t.Fatalf("fast target %v at %v,%v, want stopped at the route end", fast.State, fast.CurrentLat, fast.CurrentLng)
+	}
+	slow, _ := s.GetTarget("slow")
+	if d := util.HaversineDistance(testRoute[0][0], testRoute[0][1], float64(slow.CurrentLat), float64(slow.CurrentLng)); math.Abs(d-30) > 0.5 {
+		t.Fatalf("slow target is %.2f m from the start, want 30 m", d)
+	}
+	stopped, _ := s.GetTarget("stopped")
+	if stopped.CurrentLat != 20 || stopped.CurrentLng != 20 {
+		t.Fatalf("stopped target moved to %v,%v", stopped.CurrentLat, stopped.CurrentLng)
+	}
+}
+
+func TestSimulateTicksAppliesPendingRoutes(t *testing.T) {
+	s := newTestTargetService(simulationTargets()...)
+	newRoute := util.EncodePolyline([][2]float64{{20, 20}, {20, 20.001}})
+	if _, err := s.UpdateTargetRoute("slow", newRoute); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SimulateTicks(1, time.Second)
+	slow, _ := s.GetTarget("slow")
+	if slow.Route != newRoute || slow.CurrentLat != 20 {
+		t.Fatalf("slow target has route %q at %v,%v, want the queued route", slow.Route, slow.CurrentLat, slow.CurrentLng)
+	}
+}
+
+// Run with -race: readers and route changes run while the simulation moves targets
+func TestSimulateTicksConcurrentAccess(t *testing.T) {
+	s := newTestTargetService(loopingTargets(50)...)
+	routes := []string{util.EncodePolyline(testRoute), util.EncodePolyline([][2]float64{{10, 0.002}, {10, 0}})}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := s.GetTarget("target-001"); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := s.UpdateTargetRoute("target-002", routes[i%2]); err != nil {
+				t.Error(err)
+				return
+			}
+			s.GetTargetsInBounds(9, -1, 11, 1)
+		}
+	}()
+
+	s.SimulateTicks(200, time.Second)
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,90 @@
+package target
+
+import (
+	"metalink/internal/model"
+
+	"github.com/dhconnelly/rtreego"
+)
+
+// targetPointTolerance is the half-size of the rectangle indexing a target position, in degrees
+const targetPointTolerance = 1e-7
+
+// targetSpatial is a target position snapshot for R-tree indexing
+// The position is copied so the indexed bounds don't change while targets keep moving
+type targetSpatial struct {
+	target   *model.Target
+	lat, lng float64
+}
+
+// Bounds implements the rtreego.Spatial interface
+func (t *targetSpatial) Bounds() rtreego.Rect {
+	return rtreego.Point{t.lng, t.lat}.ToRect(targetPointTolerance)
+}
+
+// invalidateSpatialIndex marks the index out of date after targets moved
+// The next GetTargetsInBounds rebuilds it, so movement passes without viewport queries don't pay for it
+func (s *TargetService) invalidateSpatialIndex() {
+	s.indexStale.Store(true)
+}
+
+// currentSpatialIndex returns the index of target positions, rebuilding it first if it's out of date
+func (s *TargetService) currentSpatialIndex() *rtreego.Rtree {
+	if s.indexStale.Load() {
+		s.indexMutex.Lock()
+		// Another query may have rebuilt the index while this one waited for the lock
+		if s.indexStale.Swap(false) {
+			s.spatialIndex = s.buildSpatialIndex(s.storage.GetAllValues())
+		}
+		s.indexMutex.Unlock()
+	}
+
+	s.indexMutex.RLock()
+	defer s.indexMutex.RUnlock()
+	return s.spatialIndex
+}
+
+// buildSpatialIndex bulk-loads an index of the current target positions, read under the target locks
+func (s *TargetService) buildSpatialIndex(targets []*model.Target) *rtreego.Rtree {
+	objs := make([]rtreego.Spatial, len(targets))
+	for i, target := range targets {
+		unlock := s.locks.lock(target.ID)
+		objs[i] = &targetSpatial{
+			target: target,
+			lat:    float64(target.CurrentLat),
+			lng:    float64(target.CurrentLng),
+		}
+		unlock()
+	}
+	return rtreego.NewTree(2, 25, 50, objs...)
+}
+
+// GetTargetsInBounds returns targets whose indexed position is within the bounds (inclusive)
+// The first query after a movement pass rebuilds the index, so positions are at most one pass old
+// Targets created since the last pass aren't included yet; inverted bounds return nil
+// Returns copies of the targets, taken under their locks
+func (s *TargetService) GetTargetsInBounds(minLat, minLng, maxLat, maxLng float64) []*model.Target {
+	if minLat > maxLat || minLng > maxLng {
+		return nil
+	}
+
+	searchRect, err := rtreego.NewRectFromPoints(rtreego.Point{minLng, minLat}, rtreego.Point{maxLng, maxLat})
+	if err != nil {
+		return nil
+	}
+
+	index := s.currentSpatialIndex()
+	if index == nil {
+		return nil
+	}
+
+	var result []*model.Target
+	for _, item := range index.SearchIntersect(searchRect) {
+		indexed := item.(*targetSpatial)
+
+		// The index rectangles are slightly larger than the points, check the exact position
+		if indexed.lat >= minLat && indexed.lat <= maxLat && indexed.lng >= minLng && indexed.lng <= maxLng {
+			result = append(result, s.locks.snapshot(indexed.target))
+		}
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package target
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// scatteredTargets returns n targets at reproducible random positions inside the given box
+func scatteredTargets(n int, minLat, minLng, maxLat, maxLng float64) []*model.Target {
+	rng := rand.New(rand.NewSource(1))
+	targets := make([]*model.Target, n)
+	for i := range targets {
+		targets[i] = &model.Target{
+			ID:         fmt.Sprintf("target-%06d", i),
+			CurrentLat: float32(minLat + rng.Float64()*(maxLat-minLat)),
+			CurrentLng: float32(minLng + rng.Float64()*(maxLng-minLng)),
+		}
+	}
+	return targets
+}
+
+// scanTargetsInBounds counts the targets within the bounds by checking every target
+func scanTargetsInBounds(s *TargetService, minLat, minLng, maxLat, maxLng float64) int {
+	count := 0
+	for _, target := range s.storage.GetAllValues() {
+		lat, lng := float64(target.CurrentLat), float64(target.CurrentLng)
+		if lat >= minLat && lat <= maxLat && lng >= minLng && lng <= maxLng {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGetTargetsInBoundsMatchesScan(t *testing.T) {
+	s := newTestTargetService(scatteredTargets(10000, 30, -120, 45, -75)...)
+	s.invalidateSpatialIndex()
+
+	for _, bounds := range [][4]float64{
+		{35, -100, 36, -99},
+		{30, -120, 45, -75},
+		{40, -90, 40.5, -89},
+		{10, 10, 11, 11}, // Empty: no targets there
+	} {
+		got := len(s.GetTargetsInBounds(bounds[0], bounds[1], bounds[2], bounds[3]))
+		if want := scanTargetsInBounds(s, bounds[0], bounds[1], bounds[2], bounds[3]); got != want {
+			t.Fatalf("bounds %v: index found %d targets, scan %d", bounds, got, want)
+		}
+	}
+
+	if targets := s.GetTargetsInBounds(36, -99, 35, -100); targets != nil {
+		t.Fatalf("inverted bounds returned %d targets", len(targets))
+	}
+}
+
+func TestSpatialIndexRebuildsOnFirstQueryAfterPass(t *testing.T) {
+	s := newTestTargetService(loopingTargets(10)...)
+	s.ProcessTargets()
+	if s.spatialIndex != nil {
+		t.Fatal("the movement pass built the index before any query")
+	}
+
+	if targets := s.GetTargetsInBounds(-90, -180, 90, 180); len(targets) != 10 {
+		t.Fatalf("query found %d targets, want 10", len(targets))
+	}
+	built := s.spatialIndex
+
+	s.GetTargetsInBounds(-90, -180, 90, 180)
+	if s.spatialIndex != built {
+		t.Fatal("the index was rebuilt although no target moved")
+	}
+
+	s.ProcessTargets()
+	s.GetTargetsInBounds(-90, -180, 90, 180)
+	if s.spatialIndex == built {
+		t.Fatal("the index wasn't rebuilt after the targets moved")
+	}
+}
+
+// benchmarkTargetsService returns a service with 300k targets spread over the USA and its index built
+func benchmarkTargetsService(b *testing.B) *TargetService {
+	b.Helper()
+	s := newTestTargetService(scatteredTargets(300000, 30, -120, 45, -75)...)
+	s.invalidateSpatialIndex()
+	s.currentSpatialIndex()
+	return s
+}
+
+func BenchmarkGetTargetsInBounds(b *testing.B) {
+	s := benchmarkTargetsService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.GetTargetsInBounds(40, -90, 40.5, -89.5)
+	}
+}
+
+func BenchmarkGetTargetsInBoundsEmpty(b *testing.B) {
+	s := benchmarkTargetsService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.GetTargetsInBounds(10, 10, 11, 11)
+	}
+}
+
+func BenchmarkScanTargetsInBounds(b *testing.B) {
+	s := benchmarkTargetsService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		scanTargetsInBounds(s, 40, -90, 40.5, -89.5)
+	}
+}
+
+func BenchmarkSpatialIndexRebuild(b *testing.B) {
+	s := benchmarkTargetsService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.invalidateSpatialIndex()
+		s.currentSpatialIndex()
+	}
+}
@@ -0,0 +1,84 @@
+package target
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"metalink/internal/model"
+	redis_client "metalink/internal/redis"
+)
+
+// ZoneTransitionEvent is published when a target enters or leaves zones
+type ZoneTransitionEvent struct {
+	TargetID string   `json:"target_id"`
+	Entered  []string `json:"entered"`
+	Exited   []string `json:"exited"`
+}
+
+// detectZoneTransition compares the target's current zones with the ones from the previous tick
+// and remembers the current zones. zoneIDs must be sorted
+// The first observation of a target only initializes LastZoneIDs and doesn't produce an event
+func detectZoneTransition(target *model.Target, zoneIDs []string) (ZoneTransitionEvent, bool) {
+	// nil means the zones are unknown (zone service not ready), keep the previous state
+	if zoneIDs == nil {
+		return ZoneTransitionEvent{}, false
+	}
+
+	previous := target.LastZoneIDs
+	target.LastZoneIDs = zoneIDs
+	if previous == nil {
+		return ZoneTransitionEvent{}, false
+	}
+
+	entered, exited := diffSortedIDs(previous, zoneIDs)
+	if len(entered) == 0 && len(exited) == 0 {
+		return ZoneTransitionEvent{}, false
+	}
+
+	return ZoneTransitionEvent{
+		TargetID: target.ID,
+		Entered:  entered,
+		Exited:   exited,
+	}, true
+}
+
+// diffSortedIDs returns IDs present only in current (entered) and only in previous (exited)
+// Both slices are never nil so they encode as JSON arrays
+func diffSortedIDs(previous, current []string) (entered, exited []string) {
+	entered, exited = []string{}, []string{}
+
+	i, j := 0, 0
+	for i < len(previous) || j < len(current) {
+		switch {
+		case j == len(current) || (i < len(previous) && previous[i] < current[j]):
+			exited = append(exited, previous[i])
+			i++
+		case i == len(previous) || current[j] < previous[i]:
+			entered = append(entered, current[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+
+	return entered, exited
+}
+
+// publishZoneTransitions publishes the events to the zone transitions channel in a single pipeline
+func (s *TargetService) publishZoneTransitions(ctx context.Context, events []ZoneTransitionEvent) error {
+	pipe := redis_client.GetClient().Pipeline()
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal zone transition for target %s: %w", event.TargetID, err)
+		}
+		pipe.Publish(ctx, s.ZoneTransitionsChannel, payload)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to publish %d zone transitions: %w", len(events), err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package target
+
+import (
+	"encoding/json"
+	"testing"
+
+	"metalink/internal/model"
+	"metalink/internal/service/zone"
+	"metalink/internal/util"
+)
+
+// transitionZoneRoute is a route starting in transitionZone and leaving it after about 80 m
+// Each test package shares the zone singleton, so the zone and route are away from other tests
+var transitionZoneRoute = [][2]float64{{62, 0}, {62, 0.004}}
+
+// loadTransitionZone loads the zone around the start of transitionZoneRoute into the zone singleton
+func loadTransitionZone() {
+	zone.GetZoneService().LoadZones([]*model.Zone{{
+		ID:                "transition-zone",
+		TopLeftLatLon:     []float64{62.01, -0.001},
+		TopRightLatLon:    []float64{62.01, 0.0015},
+		BottomRightLatLon: []float64{61.99, 0.0015},
+		BottomLeftLatLon:  []float64{61.99, -0.001},
+	}})
+}
+
+// leavingTarget walks transitionZoneRoute 50 m per pass
+func leavingTarget(id string) *model.Target {
+	return &model.Target{
+		ID:             id,
+		Speed:          model.SpeedFromMs(10),
+		Route:          util.EncodePolyline(transitionZoneRoute),
+		State:          model.TargetStateWalking,
+		NextPointIndex: model.RouteNotStarted,
+	}
+}
+
+func TestZoneOccupancyFollowsTargets(t *testing.T) {
+	loadTransitionZone()
+	zoneService := zone.GetZoneService()
+	s := newTestTargetService(leavingTarget("occupant"))
+	s.TrackZoneOccupancy = true
+
+	// The first pass places the target and moves it 50 m, still inside the zone
+	s.ProcessTargets()
+	if n := zoneService.ZoneOccupancy("transition-zone"); n != 1 {
+		t.Fatalf("occupancy is %d after entering, want 1", n)
+	}
+
+	s.ProcessTargets()
+	if n := zoneService.ZoneOccupancy("transition-zone"); n != 0 {
+		target, _ := s.GetTarget("occupant")
+		t.Fatalf("occupancy is %d after leaving to %v,%v, want 0", n, target.CurrentLat, target.CurrentLng)
+	}
+
+	// Staying outside doesn't count the exit twice
+	s.ProcessTargets()
+	if n := zoneService.ZoneOccupancy("transition-zone"); n != 0 {
+		t.Fatalf("occupancy is %d after staying outside, want 0", n)
+	}
+}
+
+func TestZoneTransitionsArePublishedOncePerCrossing(t *testing.T) {
+	server := startTestRedis(t)
+	loadTransitionZone()
+
+	// Crosses the zone from east to west, 50 m per pass, and stops past it
+	crossing := leavingTarget("crossing")
+	crossing.Route = util.EncodePolyline([][2]float64{{62, 0.004}, {62, -0.004}})
+	s := newTestTargetService(crossing)
+	s.ZoneTransitionsChannel = "test-zone-transitions"
+
+	for i := 0; i < 12; i++ {
+		s.ProcessTargets()
+	}
+
+	var entered, exited int
+	for _, message := range server.Published("test-zone-transitions") {
+		var event ZoneTransitionEvent
+		if err := json.Unmarshal([]byte(message), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.TargetID != "crossing" {
+			t.Fatalf("event for %q, want crossing", event.TargetID)
+		}
+		for _, id := range event.Entered {
+			if id == "transition-zone" {
+				entered++
+			}
+		}
+		for _, id := range event.Exited {
+			if id == "transition-zone" {
+				exited++
+			}
+		}
+	}
+	if entered != 1 || exited != 1 {
+		t.Fatalf("%d enter and %d exit events, want one of each", entered, exited)
+	}
+	if n := server.Calls("PUBLISH"); n != 2 {
+		t.Fatalf("%d messages published, want 2", n)
+	}
+}
@@ -0,0 +1,72 @@
+package zone
+
+import (
+	"sort"
+
+	"metalink/internal/model"
+)
+
+// buildCategoryIndex maps each building category to the IDs of the zones with at least one building of it
+func buildCategoryIndex(zones []*model.Zone) map[string]map[string]struct{} {
+	index := make(map[string]map[string]struct{})
+	for _, zone := range zones {
+		addToCategoryIndex(index, zone)
+	}
+	return index
+}
+
+// addToCategoryIndex adds a zone under each of its building categories
+func addToCategoryIndex(index map[string]map[string]struct{}, zone *model.Zone) {
+	for category, count := range zone.Buildings.BuildingTypes {
+		if count <= 0 {
+			continue
+		}
+		ids, ok := index[category]
+		if !ok {
+			ids = make(map[string]struct{})
+			index[category] = ids
+		}
+		ids[zone.ID] = struct{}{}
+	}
+}
+
+// removeFromCategoryIndex removes the stored zone from the category index. Must be called with indexMutex held
+func (s *ZoneService) removeFromCategoryIndex(id string) {
+	existing, ok := s.storage.Get(id)
+	if !ok {
+		return
+	}
+	for category := range existing.Buildings.BuildingTypes {
+		ids := s.categoryIndex[category]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(s.categoryIndex, category)
+		}
+	}
+}
+
+// ZonesWithCategory returns the zones with at least one building of the given category,
+// largest area of that category first (ties ordered by zone ID)
+func (s *ZoneService) ZonesWithCategory(category string) []*model.Zone {
+	if !s.initialized.Load() {
+		return nil
+	}
+
+	s.indexMutex.RLock()
+	zones := make([]*model.Zone, 0, len(s.categoryIndex[category]))
+	for id := range s.categoryIndex[category] {
+		if zone, ok := s.storage.Get(id); ok {
+			zones = append(zones, zone)
+		}
+	}
+	s.indexMutex.RUnlock()
+
+	sort.Slice(zones, func(i, j int) bool {
+		areaI, areaJ := zones[i].Buildings.BuildingAreas[category], zones[j].Buildings.BuildingAreas[category]
+		if areaI != areaJ {
+			return areaI > areaJ
+		}
+		return zones[i].ID < zones[j].ID
+	})
+	return zones
+}
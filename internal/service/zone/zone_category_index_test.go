@@ -0,0 +1,57 @@
+package zone
+
+import (
+	"reflect"
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// categoryZone returns a zone with count buildings of the category covering area square meters
+func categoryZone(id string, lat float64, category string, count int, area float64) *model.Zone {
+	zone := testZone(id, lat, -100, lat+0.01, -99.99)
+	zone.Buildings.BuildingTypes = map[string]int{category: count}
+	zone.Buildings.BuildingAreas = map[string]float64{category: area}
+	return zone
+}
+
+// categoryIDs returns the IDs of the zones with the category, in result order
+func categoryIDs(s *ZoneService, category string) []string {
+	var ids []string
+	for _, zone := range s.ZonesWithCategory(category) {
+		ids = append(ids, zone.ID)
+	}
+	return ids
+}
+
+func TestZonesWithCategory(t *testing.T) {
+	s := newTestZoneService([]*model.Zone{
+		categoryZone("small-clinic", 40, "healthcare", 2, 500),
+		categoryZone("hospital", 40.02, "healthcare", 2, 8000),
+		categoryZone("houses", 40.04, "residential", 10, 3000),
+		categoryZone("tie", 40.06, "healthcare", 1, 500),
+		categoryZone("closed", 40.08, "healthcare", 0, 0),
+	})
+
+	// Largest area first, ties by ID; zones without healthcare buildings are left out
+	want := []string{"hospital", "small-clinic", "tie"}
+	if got := categoryIDs(s, "healthcare"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("healthcare zones %v, want %v", got, want)
+	}
+	if got := categoryIDs(s, "unknown"); len(got) != 0 {
+		t.Fatalf("unknown category zones %v, want none", got)
+	}
+
+	// The index follows updates: the houses get a clinic, the hospital closes
+	s.UpdateZones([]*model.Zone{
+		categoryZone("houses", 40.04, "healthcare", 1, 1000),
+		categoryZone("hospital", 40.02, "residential", 4, 8000),
+	})
+	want = []string{"houses", "small-clinic", "tie"}
+	if got := categoryIDs(s, "healthcare"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("healthcare zones after the update %v, want %v", got, want)
+	}
+	if got := categoryIDs(s, "residential"); !reflect.DeepEqual(got, []string{"hospital"}) {
+		t.Fatalf("residential zones after the update %v, want [hospital]", got)
+	}
+}
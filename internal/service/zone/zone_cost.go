@@ -0,0 +1,49 @@
+package zone
+
+import "metalink/internal/model"
+
+const (
+	// BaseEffectCost is the traversal cost of a point without any zone effects
+	BaseEffectCost = 1.0
+
+	// MinEffectCost keeps costs positive so strongly buffed areas don't break path searches
+	MinEffectCost = 0.01
+)
+
+// EffectCostAt returns the traversal cost at a coordinate derived from the zone effects there
+// Each effect changes the base cost by -value*weight, so debuffs (negative values) increase it
+// Effect types missing from weights are ignored; nil weights count every type with weight 1
+func (s *ZoneService) EffectCostAt(lat, lng float64, weights map[model.TargetParamType]float32) float64 {
+	return effectCost(s.GetEffectsForTarget(lat, lng), weights)
+}
+
+// EffectCostsForPoints returns traversal costs for many [lat, lng] points in input order
+// using a single batch lookup, see EffectCostAt
+func (s *ZoneService) EffectCostsForPoints(points [][2]float64, weights map[model.TargetParamType]float32) []float64 {
+	costs := make([]float64, len(points))
+	for i, effects := range s.GetEffectsForTargets(points) {
+		costs[i] = effectCost(effects, weights)
+	}
+	return costs
+}
+
+// effectCost combines effects into a scalar cost according to the weights
+func effectCost(effects map[model.TargetParamType]float32, weights map[model.TargetParamType]float32) float64 {
+	cost := BaseEffectCost
+	for paramType, value := range effects {
+		weight := float32(1)
+		if weights != nil {
+			w, ok := weights[paramType]
+			if !ok {
+				continue
+			}
+			weight = w
+		}
+		cost -= float64(value * weight)
+	}
+
+	if cost < MinEffectCost {
+		return MinEffectCost
+	}
+	return cost
+}
@@ -0,0 +1,41 @@
+package zone
+
+import (
+	"testing"
+
+	"metalink/internal/model"
+)
+
+func TestEffectCostDebuffZoneCostsMoreThanNeutral(t *testing.T) {
+	s := newTestZoneService([]*model.Zone{
+		testZone("neutral", 0, 0, 1, 1),
+		testZone("debuff", 0, 2, 1, 3,
+			model.ZoneEffect{ResourceType: model.TargetParamTypeAirQuality, Value: -0.8},
+			model.ZoneEffect{ResourceType: model.TargetParamTypeHealth, Value: -0.5},
+		),
+	})
+
+	neutral := s.EffectCostAt(0.5, 0.5, nil)
+	debuff := s.EffectCostAt(0.5, 2.5, nil)
+	outside := s.EffectCostAt(5, 5, nil)
+
+	if neutral != BaseEffectCost || outside != BaseEffectCost {
+		t.Fatalf("neutral zone costs %v and no zone %v, want the base cost %v", neutral, outside, BaseEffectCost)
+	}
+	if debuff <= neutral {
+		t.Fatalf("debuff zone costs %v, want more than the neutral %v", debuff, neutral)
+	}
+
+	// Only the weighted types count
+	weighted := s.EffectCostAt(0.5, 2.5, map[model.TargetParamType]float32{model.TargetParamTypeFoodSearch: 1})
+	if weighted != BaseEffectCost {
+		t.Fatalf("debuff zone costs %v with unrelated weights, want the base cost", weighted)
+	}
+}
+
+func TestEffectCostIsClampedForStrongBuffs(t *testing.T) {
+	effects := map[model.TargetParamType]float32{model.TargetParamTypeHealth: 5}
+	if cost := effectCost(effects, nil); cost != MinEffectCost {
+		t.Fatalf("cost = %v, want the minimum %v", cost, MinEffectCost)
+	}
+}
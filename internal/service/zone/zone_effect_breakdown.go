@@ -0,0 +1,59 @@
+package zone
+
+import (
+	"sort"
+
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+)
+
+// EffectContribution is the part of an effect at a point coming from one building category of one zone
+type EffectContribution struct {
+	ZoneID       string
+	Category     string
+	ResourceType model.TargetParamType
+	Value        float32 // Weighted by the zone's effect weight at the point, before combination and caps
+}
+
+// GetEffectsBreakdownForTarget returns the contributions behind GetEffectsForTarget at the given position
+// Contributions are sorted by zone ID, resource type and category
+// In additive mode their sum per resource type is the effect before caps; the diminishing mode
+// combines the per-zone sums instead
+func (s *ZoneService) GetEffectsBreakdownForTarget(lat, lng float64) []EffectContribution {
+	return s.GetEffectsBreakdownForZones(s.GetZonesAtPoint(lat, lng), lat, lng)
+}
+
+// GetEffectsBreakdownForZones returns the contributions of the given zones at the given position
+// Used when the caller already looked the zones up, so the zones and their contributions match
+func (s *ZoneService) GetEffectsBreakdownForZones(zones []*model.Zone, lat, lng float64) []EffectContribution {
+	if len(zones) == 0 {
+		return nil
+	}
+
+	point := orb.Point{lng, lat}
+	var contributions []EffectContribution
+	for _, zone := range zones {
+		weight := s.effectWeight(zone, point)
+		zone.ForEachCategoryEffect(func(category string, paramType model.TargetParamType, value float64) {
+			contributions = append(contributions, EffectContribution{
+				ZoneID:       zone.ID,
+				Category:     category,
+				ResourceType: paramType,
+				Value:        float32(value) * weight,
+			})
+		})
+	}
+
+	sort.Slice(contributions, func(i, j int) bool {
+		a, b := contributions[i], contributions[j]
+		if a.ZoneID != b.ZoneID {
+			return a.ZoneID < b.ZoneID
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Category < b.Category
+	})
+	return contributions
+}
@@ -0,0 +1,130 @@
+package zone
+
+import (
+	"math"
+
+	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/logx"
+	"metalink/internal/model"
+
+	"github.com/paulmach/orb"
+)
+
+// loadEffectCombination resolves the combination mode and effect caps from the building effects config
+func (s *ZoneService) loadEffectCombination() {
+	if s.EffectCombination == "" {
+		s.EffectCombination = mappers.GetEffectCombination()
+	}
+
+	s.effectCaps = make(map[model.TargetParamType]mappers.EffectCap)
+	for resource, effectCap := range mappers.GetEffectCaps() {
+		paramType, err := model.ParseTargetParamType(resource)
+		if err != nil {
+			logx.Warn("Ignoring effect cap: %v", err)
+			continue
+		}
+		s.effectCaps[paramType] = effectCap
+	}
+
+	logx.Info("Effect combination: %s, %d effect caps", s.EffectCombination, len(s.effectCaps))
+}
+
+// combineZoneEffects combines effects of all given zones at a [lon, lat] point per resource type
+// using the service combination mode, then clamps them to the configured caps
+// Zone effects are calculated before zones are stored (InitService, UpdateZones), so this only reads
+// them and is safe to call from many request goroutines; a zone without effects contributes nothing
+func (s *ZoneService) combineZoneEffects(zones []*model.Zone, point orb.Point) map[model.TargetParamType]float32 {
+	if s.EffectCombination == mappers.EffectCombinationDiminishing {
+		return s.combineDiminishing(zones, point)
+	}
+
+	effects := make(map[model.TargetParamType]float32)
+	for _, zone := range zones {
+		weight := s.effectWeight(zone, point)
+		for _, effect := range zone.Effects {
+			// Simply add the effect value (positive or negative)
+			effects[effect.ResourceType] += effect.Value * weight
+		}
+	}
+
+	for resourceType, value := range effects {
+		effects[resourceType] = s.clampEffect(resourceType, value)
+	}
+	return effects
+}
+
+// combineDiminishing combines effects as cap*(1-prod(1-e/cap)), separately for positive
+// values (relative to max) and negative values (relative to min)
+// Resource types without a cap on that side are summed
+func (s *ZoneService) combineDiminishing(zones []*model.Zone, point orb.Point) map[model.TargetParamType]float32 {
+	type partial struct {
+		sum         float64 // Sum of values without a cap on their side
+		positiveRem float64 // prod(1-e/max) of positive values
+		negativeRem float64 // prod(1-e/min) of negative values
+	}
+	partials := make(map[model.TargetParamType]*partial)
+
+	for _, zone := range zones {
+		weight := s.effectWeight(zone, point)
+		for _, effect := range zone.Effects {
+			p, ok := partials[effect.ResourceType]
+			if !ok {
+				p = &partial{positiveRem: 1, negativeRem: 1}
+				partials[effect.ResourceType] = p
+			}
+
+			value := float64(effect.Value * weight)
+			effectCap := s.effectCaps[effect.ResourceType]
+			switch {
+			case value > 0 && effectCap.Max != nil && *effectCap.Max > 0:
+				p.positiveRem *= 1 - math.Min(value / *effectCap.Max, 1)
+			case value < 0 && effectCap.Min != nil && *effectCap.Min < 0:
+				p.negativeRem *= 1 - math.Min(value / *effectCap.Min, 1)
+			default:
+				p.sum += value
+			}
+		}
+	}
+
+	effects := make(map[model.TargetParamType]float32, len(partials))
+	for resourceType, p := range partials {
+		value := p.sum
+		effectCap := s.effectCaps[resourceType]
+		if p.positiveRem < 1 {
+			value += *effectCap.Max * (1 - p.positiveRem)
+		}
+		if p.negativeRem < 1 {
+			value += *effectCap.Min * (1 - p.negativeRem)
+		}
+		effects[resourceType] = s.clampEffect(resourceType, float32(value))
+	}
+	return effects
+}
+
+// effectWeight returns the share of a zone's effects felt at a [lon, lat] point
+// Uniform mode always returns 1; gradient mode falls off linearly from 1 at the zone centroid
+// to GradientEdgeWeight at the farthest corner
+func (s *ZoneService) effectWeight(zone *model.Zone, point orb.Point) float32 {
+	if !s.GradientEffects {
+		return 1
+	}
+
+	distance := min(zone.NormalizedDistance(point), 1)
+	edgeWeight := min(max(s.GradientEdgeWeight, 0), 1)
+	return float32(1 - (1-edgeWeight)*distance)
+}
+
+// clampEffect limits a combined effect value to the cap of its resource type
+func (s *ZoneService) clampEffect(resourceType model.TargetParamType, value float32) float32 {
+	effectCap, ok := s.effectCaps[resourceType]
+	if !ok {
+		return value
+	}
+	if effectCap.Max != nil && value > float32(*effectCap.Max) {
+		value = float32(*effectCap.Max)
+	}
+	if effectCap.Min != nil && value < float32(*effectCap.Min) {
+		value = float32(*effectCap.Min)
+	}
+	return value
+}
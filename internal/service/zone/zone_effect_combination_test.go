@@ -0,0 +1,87 @@
+package zone
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/model"
+)
+
+// overlappingZonesService returns a service with n overlapping zones having the given effects
+// and a food cap of [-100, 100]
+func overlappingZonesService(combination string, n int, effects ...model.ZoneEffect) *ZoneService {
+	zones := make([]*model.Zone, n)
+	for i := range zones {
+		zones[i] = testZone(fmt.Sprintf("zone-%d", i), 40, -100, 40.01, -99.99, effects...)
+	}
+	s := newTestZoneService(zones)
+	minFood, maxFood := -100.0, 100.0
+	s.effectCaps[model.TargetParamTypeFoodSearch] = mappers.EffectCap{Min: &minFood, Max: &maxFood}
+	s.EffectCombination = combination
+	return s
+}
+
+var foodEffect = model.ZoneEffect{ResourceType: model.TargetParamTypeFoodSearch, Value: 50}
+
+func TestAdditiveEffectsClampToMax(t *testing.T) {
+	s := overlappingZonesService(mappers.EffectCombinationAdditive, 3, foodEffect)
+
+	if food := s.GetEffectsForTarget(40.005, -99.995)[model.TargetParamTypeFoodSearch]; food != 100 {
+		t.Fatalf("three +50 zones give %v food, want the 100 cap", food)
+	}
+
+	debuff := model.ZoneEffect{ResourceType: model.TargetParamTypeFoodSearch, Value: -50}
+	s = overlappingZonesService(mappers.EffectCombinationAdditive, 3, debuff)
+	if food := s.GetEffectsForTarget(40.005, -99.995)[model.TargetParamTypeFoodSearch]; food != -100 {
+		t.Fatalf("three -50 zones give %v food, want the -100 cap", food)
+	}
+}
+
+func TestDiminishingEffectsStayBelowMax(t *testing.T) {
+	s := overlappingZonesService(mappers.EffectCombinationDiminishing, 3, foodEffect)
+
+	// 100 * (1 - 0.5^3)
+	food := s.GetEffectsForTarget(40.005, -99.995)[model.TargetParamTypeFoodSearch]
+	if math.Abs(float64(food)-87.5) > 1e-3 {
+		t.Fatalf("three +50 zones give %v food, want 87.5", food)
+	}
+
+	// More zones get closer to the cap without reaching it
+	s = overlappingZonesService(mappers.EffectCombinationDiminishing, 8, foodEffect)
+	more := s.GetEffectsForTarget(40.005, -99.995)[model.TargetParamTypeFoodSearch]
+	if more <= food || more >= 100 {
+		t.Fatalf("eight +50 zones give %v food, want between %v and 100", more, food)
+	}
+}
+
+func TestDiminishingEffectsSumUncapped(t *testing.T) {
+	health := model.ZoneEffect{ResourceType: model.TargetParamTypeHealth, Value: 50}
+	s := overlappingZonesService(mappers.EffectCombinationDiminishing, 3, health)
+
+	if got := s.GetEffectsForTarget(40.005, -99.995)[model.TargetParamTypeHealth]; got != 150 {
+		t.Fatalf("three +50 uncapped zones give %v health, want 150", got)
+	}
+}
+
+func TestGradientEffectWeight(t *testing.T) {
+	s := overlappingZonesService(mappers.EffectCombinationAdditive, 1, foodEffect)
+	zone := s.GetZonesAtPoint(40.005, -99.995)[0]
+	centroid := zone.Centroid()
+
+	if food := s.GetEffectsForTarget(40, -100)[model.TargetParamTypeFoodSearch]; food != 50 {
+		t.Fatalf("uniform mode gives %v food at a corner, want the full 50", food)
+	}
+
+	s.GradientEffects = true
+	s.GradientEdgeWeight = 0.2
+	if food := s.GetEffectsForTarget(centroid[1], centroid[0])[model.TargetParamTypeFoodSearch]; math.Abs(float64(food)-50) > 1e-3 {
+		t.Fatalf("gradient mode gives %v food at the centroid, want the full 50", food)
+	}
+	// The corner gets the edge weight: 50 * 0.2
+	corner := s.GetEffectsForTarget(40, -100)[model.TargetParamTypeFoodSearch]
+	if math.Abs(float64(corner)-10) > 0.5 {
+		t.Fatalf("gradient mode gives %v food at a corner, want ~10", corner)
+	}
+}
@@ -0,0 +1,90 @@
+package zone
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"metalink/internal/model"
+
+	"github.com/dhconnelly/rtreego"
+)
+
+// incrementalIndex builds the zone R-tree by inserting zones one by one, as before bulk loading
+func incrementalIndex(s *ZoneService) *rtreego.Rtree {
+	index := rtreego.NewTree(2, 25, 50)
+	for _, zoneSpatial := range s.buildZoneSpatials(s.storage.GetAllValues()) {
+		index.Insert(zoneSpatial)
+	}
+	return index
+}
+
+// searchIDs returns the sorted IDs of the zones intersecting the rectangle
+func searchIDs(index *rtreego.Rtree, rect rtreego.Rect) []string {
+	var ids []string
+	for _, obj := range index.SearchIntersect(rect) {
+		ids = append(ids, obj.(*ZoneSpatial).ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestBulkLoadedIndexMatchesIncremental(t *testing.T) {
+	s := newTestZoneService(gridZones(100, 100, 40, -100, 0.01))
+	incremental := incrementalIndex(s)
+
+	for i, p := range randomPoints(2000, 40, -100, 41, -99) {
+		size := 0.001 + float64(i%50)*0.002
+		rect, err := rtreego.NewRect(rtreego.Point{p[1], p[0]}, []float64{size, size})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bulk, want := searchIDs(s.spatialIndex, rect), searchIDs(incremental, rect)
+		if !reflect.DeepEqual(bulk, want) {
+			t.Fatalf("rectangle %v: bulk-loaded index found %v, incremental %v", rect, bulk, want)
+		}
+	}
+}
+
+func benchmarkIndexBuild(b *testing.B, build func(s *ZoneService)) {
+	// About 500k zones
+	s := newTestZoneService(gridZones(707, 707, 30, -110, 0.01))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		build(s)
+	}
+}
+
+func BenchmarkSpatialIndexIncremental(b *testing.B) {
+	benchmarkIndexBuild(b, func(s *ZoneService) { incrementalIndex(s) })
+}
+
+func BenchmarkSpatialIndexBulkLoad(b *testing.B) {
+	benchmarkIndexBuild(b, func(s *ZoneService) { s.rebuildSpatialIndex() })
+}
+
+func TestDegenerateZonesAreNotIndexed(t *testing.T) {
+	point := testZone("point", 40.005, -99.995, 40.005, -99.995)
+	s := newTestZoneService([]*model.Zone{point, testZone("valid", 40, -100, 40.01, -99.99)})
+
+	if s.spatialIndex.Size() != 1 {
+		t.Fatalf("%d zones indexed, want only the valid one", s.spatialIndex.Size())
+	}
+	if ids := zoneIDsAt(s, 40.005, -99.995); len(ids) != 1 || !ids["valid"] {
+		t.Fatalf("zones at the collapsed zone: %v, want only the valid one", ids)
+	}
+
+	// A tiny zone passes validation but is too small to index when added later
+	tiny := testZone("tiny", 40.02, -100, 40.0200001, -99.9999999)
+	if err := tiny.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateZones([]*model.Zone{tiny})
+	if s.spatialIndex.Size() != 1 {
+		t.Fatalf("%d zones indexed after adding a tiny zone, want 1", s.spatialIndex.Size())
+	}
+	if ids := zoneIDsAt(s, 40.02000005, -99.99999995); len(ids) != 0 {
+		t.Fatalf("zones at the tiny zone: %v, want none", ids)
+	}
+}
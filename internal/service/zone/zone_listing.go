@@ -0,0 +1,54 @@
+package zone
+
+import (
+	"sort"
+
+	"metalink/internal/model"
+)
+
+// ZoneFilter selects zones for ListZones; zero values match every zone
+type ZoneFilter struct {
+	MinBuildings int    // Minimum total building count
+	Category     string // Building category the zone must have at least one building of
+}
+
+// matches reports whether a zone passes the filter
+func (f ZoneFilter) matches(zone *model.Zone) bool {
+	if zone.Buildings.TotalCount < f.MinBuildings {
+		return false
+	}
+	return f.Category == "" || zone.Buildings.BuildingTypes[f.Category] > 0
+}
+
+// ListZones returns up to limit zones matching the filter with IDs after the given one, sorted by ID,
+// the ID to continue after (empty on the last page) and the number of matching zones in total
+func (s *ZoneService) ListZones(afterID string, limit int, filter ZoneFilter) ([]*model.Zone, string, int) {
+	if !s.initialized.Load() || limit <= 0 {
+		return nil, "", 0
+	}
+
+	var matching []*model.Zone
+	if filter.Category != "" {
+		matching = s.ZonesWithCategory(filter.Category)
+	} else {
+		matching = s.storage.GetAllValues()
+	}
+
+	filtered := matching[:0]
+	for _, zone := range matching {
+		if filter.matches(zone) {
+			filtered = append(filtered, zone)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].ID < filtered[j].ID })
+
+	start := sort.Search(len(filtered), func(i int) bool { return filtered[i].ID > afterID })
+	end := min(start+limit, len(filtered))
+	page := filtered[start:end]
+
+	nextID := ""
+	if end < len(filtered) {
+		nextID = page[len(page)-1].ID
+	}
+	return page, nextID, len(filtered)
+}
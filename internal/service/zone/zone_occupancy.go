@@ -0,0 +1,61 @@
+package zone
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// ZoneCount is the number of targets currently in a zone
+type ZoneCount struct {
+	ZoneID string `json:"zone_id"`
+	Count  int    `json:"count"`
+}
+
+// UpdateOccupancy counts targets entering and leaving zones, safe to call from many goroutines
+// Leaving a zone without a counter (e.g. a removed zone) is ignored
+func (s *ZoneService) UpdateOccupancy(entered, exited []string) {
+	for _, zoneID := range entered {
+		counter, _ := s.occupancy.LoadOrStore(zoneID, new(atomic.Int64))
+		counter.(*atomic.Int64).Add(1)
+	}
+	for _, zoneID := range exited {
+		if counter, ok := s.occupancy.Load(zoneID); ok {
+			counter.(*atomic.Int64).Add(-1)
+		}
+	}
+}
+
+// ZoneOccupancy returns the number of targets currently in the zone
+func (s *ZoneService) ZoneOccupancy(zoneID string) int {
+	counter, ok := s.occupancy.Load(zoneID)
+	if !ok {
+		return 0
+	}
+	return int(counter.(*atomic.Int64).Load())
+}
+
+// TopOccupiedZones returns up to n occupied zones with the most targets, ties ordered by zone ID
+func (s *ZoneService) TopOccupiedZones(n int) []ZoneCount {
+	if n <= 0 {
+		return nil
+	}
+
+	var counts []ZoneCount
+	s.occupancy.Range(func(key, value any) bool {
+		if count := int(value.(*atomic.Int64).Load()); count > 0 {
+			counts = append(counts, ZoneCount{ZoneID: key.(string), Count: count})
+		}
+		return true
+	})
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].ZoneID < counts[j].ZoneID
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
@@ -0,0 +1,28 @@
+package zone
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopOccupiedZones(t *testing.T) {
+	s := newTestZoneService(nil)
+	s.UpdateOccupancy([]string{"b", "c", "a", "c", "d", "c", "b", "e"}, nil)
+	s.UpdateOccupancy(nil, []string{"e", "unknown"})
+
+	// Ties are ordered by zone ID and the emptied zone e is left out
+	s.UpdateOccupancy([]string{"a"}, nil)
+	want := []ZoneCount{{"c", 3}, {"a", 2}, {"b", 2}, {"d", 1}}
+	if got := s.TopOccupiedZones(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("top zones %v, want %v", got, want)
+	}
+	if got := s.TopOccupiedZones(2); !reflect.DeepEqual(got, want[:2]) {
+		t.Fatalf("top 2 zones %v, want %v", got, want[:2])
+	}
+	if got := s.TopOccupiedZones(0); got != nil {
+		t.Fatalf("top 0 zones %v, want none", got)
+	}
+	if n := s.ZoneOccupancy("unknown"); n != 0 {
+		t.Fatalf("leaving an unknown zone created a counter of %d", n)
+	}
+}
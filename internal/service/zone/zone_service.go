@@ -3,14 +3,19 @@ package zone
 import (
 	"context"
 	"fmt"
-	"log"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/logx"
 	"metalink/internal/model"
 	pg "metalink/internal/postgres"
 	"metalink/internal/service/storage"
 	"metalink/internal/util"
+	"metalink/internal/util/geoproj"
 
 	"github.com/dhconnelly/rtreego"
 	"github.com/paulmach/orb"
@@ -43,11 +48,31 @@ func (z *ZoneSpatial) Bounds() rtreego.Rect {
 
 // ZoneService manages zone data and spatial indexing
 type ZoneService struct {
-	storage      storage.Storage[string, *model.Zone]
-	spatialIndex *rtreego.Rtree // R-tree spatial index
-	indexMutex   sync.RWMutex   // Mutex for thread-safe index operations
-	initialized  bool           // Flag indicating if service is initialized
-	initMutex    sync.RWMutex   // Mutex for initialization
+	storage       storage.Storage[string, *model.Zone]
+	spatialIndex  *rtreego.Rtree                 // R-tree spatial index
+	categoryIndex map[string]map[string]struct{} // Building category -> IDs of zones with buildings of it
+	indexMutex    sync.RWMutex                   // Mutex for thread-safe index operations
+	initialized   atomic.Bool                    // Flag indicating if service is initialized
+	initMutex     sync.RWMutex                   // Mutex for initialization
+
+	// ForceRecalcEffects ignores the effects cache stored in DB and recalculates effects on init
+	ForceRecalcEffects bool
+
+	// MaxViewportZones caps the zones returned for a map viewport (0 = unlimited)
+	MaxViewportZones int
+
+	// EffectCombination is how overlapping zone effects combine, additive or diminishing
+	// (empty = value from the building effects config)
+	EffectCombination string
+	effectCaps        map[model.TargetParamType]mappers.EffectCap // Combined effect bounds from the effects config
+
+	// GradientEffects weights zone effects by the target's distance from the zone centroid
+	// instead of applying them uniformly across the zone
+	GradientEffects bool
+	// GradientEdgeWeight is the effect weight at the farthest corner of a zone in gradient mode (0..1)
+	GradientEdgeWeight float64
+
+	occupancy sync.Map // Zone ID -> *atomic.Int64 number of targets in the zone
 }
 
 var (
@@ -59,8 +84,9 @@ var (
 func GetZoneService() *ZoneService {
 	zoneServiceOnce.Do(func() {
 		zoneServiceInstance = &ZoneService{
-			storage:      storage.NewShardedMemoryStorage[string, *model.Zone](8, nil),
-			spatialIndex: rtreego.NewTree(2, 25, 50), // 2D index with min 25, max 50 entries per node
+			storage:       storage.NewShardedMemoryStorage[string, *model.Zone](8, nil),
+			spatialIndex:  rtreego.NewTree(2, 25, 50), // 2D index with min 25, max 50 entries per node
+			categoryIndex: make(map[string]map[string]struct{}),
 		}
 	})
 	return zoneServiceInstance
@@ -71,65 +97,101 @@ func (s *ZoneService) InitService(ctx context.Context) error {
 	s.initMutex.Lock()
 	defer s.initMutex.Unlock()
 
-	if s.initialized {
-		log.Println("ZoneService already initialized, skipping")
+	if s.initialized.Load() {
+		logx.Info("ZoneService already initialized, skipping")
 		return nil
 	}
 
-	log.Println("=== Starting ZoneService initialization ===")
+	logx.Info("=== Starting ZoneService initialization ===")
 	totalStartTime := time.Now()
 
 	// Step 1: Load data from PostgreSQL
-	log.Println("Step 1: Loading zones from PostgreSQL...")
+	logx.Info("Step 1: Loading zones from PostgreSQL...")
 	pgLoadStart := time.Now()
 	zones, err := s.loadAllZonesFromPG()
 	if err != nil {
-		log.Printf("ERROR: Failed to load zones from PostgreSQL after %v: %v", time.Since(pgLoadStart), err)
+		logx.Error("Failed to load zones from PostgreSQL after %v: %v", time.Since(pgLoadStart), err)
 		return fmt.Errorf("failed to load zones from PostgreSQL: %w", err)
 	}
 	pgLoadDuration := time.Since(pgLoadStart)
-	log.Printf("PostgreSQL loading completed: %d zones loaded in %v", len(zones), pgLoadDuration)
+	logx.Info("PostgreSQL loading completed: %d zones loaded in %v", len(zones), pgLoadDuration)
 
-	// Step 2: Pre-calculate all zone effects
-	log.Println("Step 2: Pre-calculating zone effects...")
+	zones = validZones(zones)
+	s.loadEffectCombination()
+
+	// Step 2: Pre-calculate zone effects (zones with cached effects are skipped)
+	logx.Info("Step 2: Pre-calculating zone effects...")
 	effectsStart := time.Now()
+	calculatedCount := 0
 	for _, zone := range zones {
-		zone.CalculateEffects()
+		if s.ForceRecalcEffects || len(zone.Effects) == 0 {
+			zone.CalculateEffects()
+			calculatedCount++
+		}
 	}
 	effectsDuration := time.Since(effectsStart)
-	log.Printf("Effects calculation completed: %d zones processed in %v", len(zones), effectsDuration)
+	logx.Info("Effects calculation completed: %d zones processed, %d loaded from cache in %v",
+		calculatedCount, len(zones)-calculatedCount, effectsDuration)
 
 	// Step 3: Load zones into memory storage
-	log.Println("Step 3: Loading zones into memory storage...")
+	logx.Info("Step 3: Loading zones into memory storage...")
 	memoryLoadStart := time.Now()
 	for _, zone := range zones {
 		s.storage.Set(zone.ID, zone)
 	}
 	memoryLoadDuration := time.Since(memoryLoadStart)
-	log.Printf("Memory loading completed: %d zones stored in %v", len(zones), memoryLoadDuration)
+	logx.Info("Memory loading completed: %d zones stored in %v", len(zones), memoryLoadDuration)
 
 	// Step 4: Build spatial index
-	log.Println("Step 4: Building spatial R-tree index...")
+	logx.Info("Step 4: Building spatial R-tree index...")
 	indexBuildStart := time.Now()
 	s.rebuildSpatialIndex()
 	indexBuildDuration := time.Since(indexBuildStart)
-	log.Printf("Spatial index built in %v", indexBuildDuration)
+	logx.Info("Spatial index built in %v", indexBuildDuration)
 
 	// Final summary
 	totalDuration := time.Since(totalStartTime)
-	log.Printf("=== ZoneService initialization completed ===")
-	log.Printf("Total zones: %d", s.storage.Count())
-	log.Printf("Total time: %v", totalDuration)
-	log.Printf("Breakdown:")
-	log.Printf("  - PostgreSQL loading: %v (%.1f%%)", pgLoadDuration, float64(pgLoadDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
-	log.Printf("  - Effects calculation: %v (%.1f%%)", effectsDuration, float64(effectsDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
-	log.Printf("  - Memory storage: %v (%.1f%%)", memoryLoadDuration, float64(memoryLoadDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
-	log.Printf("  - Spatial indexing: %v (%.1f%%)", indexBuildDuration, float64(indexBuildDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
-
-	s.initialized = true
+	logx.Info("=== ZoneService initialization completed ===")
+	logx.Info("Total zones: %d", s.storage.Count())
+	logx.Info("Total time: %v", totalDuration)
+	logx.Info("Breakdown:")
+	logx.Info("  - PostgreSQL loading: %v (%.1f%%)", pgLoadDuration, float64(pgLoadDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
+	logx.Info("  - Effects calculation: %v (%.1f%%)", effectsDuration, float64(effectsDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
+	logx.Info("  - Memory storage: %v (%.1f%%)", memoryLoadDuration, float64(memoryLoadDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
+	logx.Info("  - Spatial indexing: %v (%.1f%%)", indexBuildDuration, float64(indexBuildDuration.Nanoseconds())/float64(totalDuration.Nanoseconds())*100)
+
+	s.initialized.Store(true)
 	return nil
 }
 
+// LoadZones stores zones that don't come from PostgreSQL (tests, tools) and marks the service initialized
+// Invalid zones are skipped and zones without cached effects get them calculated, as in InitService
+func (s *ZoneService) LoadZones(zones []*model.Zone) {
+	s.initMutex.Lock()
+	defer s.initMutex.Unlock()
+
+	zones = validZones(zones)
+	s.loadEffectCombination()
+	for _, zone := range zones {
+		if len(zone.Effects) == 0 {
+			zone.CalculateEffects()
+		}
+		s.storage.Set(zone.ID, zone)
+	}
+	s.rebuildSpatialIndex()
+	s.initialized.Store(true)
+}
+
+// IsInitialized reports whether InitService has finished loading zones
+func (s *ZoneService) IsInitialized() bool {
+	return s.initialized.Load()
+}
+
+// Count returns the number of zones in memory
+func (s *ZoneService) Count() int {
+	return s.storage.Count()
+}
+
 // loadAllZonesFromPG loads all zones from PostgreSQL
 func (s *ZoneService) loadAllZonesFromPG() ([]*model.Zone, error) {
 	db := pg.GetDB()
@@ -150,65 +212,117 @@ func (s *ZoneService) loadAllZonesFromPG() ([]*model.Zone, error) {
 	return zones, nil
 }
 
-// rebuildSpatialIndex rebuilds the spatial index for efficient searching
+// validZones returns the zones that pass validation, logging and skipping the others
+// The input slice is left unchanged
+func validZones(zones []*model.Zone) []*model.Zone {
+	valid := make([]*model.Zone, 0, len(zones))
+	skipped := 0
+	for _, zone := range zones {
+		if err := zone.Validate(); err != nil {
+			logx.Warn("Skipping invalid zone: %v", err)
+			skipped++
+			continue
+		}
+		valid = append(valid, zone)
+	}
+
+	if skipped > 0 {
+		logx.Info("Skipped %d invalid zones out of %d", skipped, len(zones))
+	}
+	return valid
+}
+
+// rebuildSpatialIndex rebuilds the spatial index and the building category index for efficient searching
+// Zone polygons are built in parallel and the R-tree is bulk-loaded (OMT) instead of inserting zones one by one
 func (s *ZoneService) rebuildSpatialIndex() {
+	zones := s.storage.GetAllValues()
+	zoneSpatials := s.buildZoneSpatials(zones)
+	categoryIndex := buildCategoryIndex(zones)
+
+	// Bulk-load the R-tree (rtreego uses OMT bulk loading for more than max entries)
+	objs := make([]rtreego.Spatial, len(zoneSpatials))
+	for i, zoneSpatial := range zoneSpatials {
+		objs[i] = zoneSpatial
+	}
+	index := rtreego.NewTree(2, 25, 50, objs...)
+
 	s.indexMutex.Lock()
-	defer s.indexMutex.Unlock()
-
-	// Create a new R-tree
-	s.spatialIndex = rtreego.NewTree(2, 25, 50)
-
-	// Add all zones to the index
-	s.storage.ForEach(func(id string, zone *model.Zone) bool {
-		if zone.Polygon != nil && zone.BoundingBox != nil {
-			// Create a ZoneSpatial object for indexing
-			zoneSpatial := &ZoneSpatial{
-				ID:          zone.ID,
-				Polygon:     zone.Polygon,
-				BoundingBox: zone.BoundingBox,
-				Zone:        zone,
-			}
-			// Insert into R-tree
-			s.spatialIndex.Insert(zoneSpatial)
-		} else {
-			// Create polygon from corner points
-			polygon, bounds := s.createPolygonFromCorners(zone)
-			zone.Polygon = polygon
-			zone.BoundingBox = bounds
-
-			// Create and insert ZoneSpatial
-			zoneSpatial := &ZoneSpatial{
-				ID:          zone.ID,
-				Polygon:     polygon,
-				BoundingBox: bounds,
-				Zone:        zone,
+	s.spatialIndex = index
+	s.categoryIndex = categoryIndex
+	s.indexMutex.Unlock()
+}
+
+// buildZoneSpatials creates the spatial objects of the zones, building missing polygons in parallel
+// Degenerate (zero-area) zones are left out of the result since they can't contain a point
+func (s *ZoneService) buildZoneSpatials(zones []*model.Zone) []*ZoneSpatial {
+	zoneSpatials := make([]*ZoneSpatial, len(zones))
+
+	numWorkers := runtime.NumCPU()
+	chunkSize := (len(zones) + numWorkers - 1) / numWorkers
+
+	var wg sync.WaitGroup
+	var invalidGeometries atomic.Int64
+	for start := 0; start < len(zones); start += chunkSize {
+		end := min(start+chunkSize, len(zones))
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				zone := zones[i]
+				if zone.Polygon == nil || zone.BoundingBox == nil {
+					var err error
+					zone.Polygon, zone.BoundingBox, err = s.buildZonePolygon(zone)
+					if err != nil && invalidGeometries.Add(1) == 1 {
+						logx.Warn("Invalid zone geometry, using the zone corners instead: %v", err)
+					}
+				}
+				if zone.IsDegenerate() {
+					continue
+				}
+
+				zoneSpatials[i] = &ZoneSpatial{
+					ID:          zone.ID,
+					Polygon:     zone.Polygon,
+					BoundingBox: zone.BoundingBox,
+					Zone:        zone,
+				}
 			}
-			s.spatialIndex.Insert(zoneSpatial)
+		}(start, end)
+	}
+	wg.Wait()
+
+	// Drop the degenerate zones left as nil
+	indexed := zoneSpatials[:0]
+	for _, zoneSpatial := range zoneSpatials {
+		if zoneSpatial != nil {
+			indexed = append(indexed, zoneSpatial)
 		}
-		return true
-	})
+	}
+	if count := invalidGeometries.Load(); count > 1 {
+		logx.Warn("%d zones with an invalid geometry use their corners instead", count)
+	}
+	if skipped := len(zones) - len(indexed); skipped > 0 {
+		logx.Warn("Skipped %d degenerate zones with a polygon area below %.0f m² from the spatial index", skipped, model.MinPolygonArea)
+	}
+
+	return indexed
 }
 
-// createPolygonFromCorners creates a polygon from the four corner points
-func (s *ZoneService) createPolygonFromCorners(zone *model.Zone) (*orb.Polygon, *orb.Bound) {
-	// Create a polygon from the four corners
-	// Order matters: we go clockwise from top-left
-	ring := orb.Ring{
-		orb.Point{zone.TopLeftLatLon[1], zone.TopLeftLatLon[0]},         // [lon, lat]
-		orb.Point{zone.TopRightLatLon[1], zone.TopRightLatLon[0]},       // [lon, lat]
-		orb.Point{zone.BottomRightLatLon[1], zone.BottomRightLatLon[0]}, // [lon, lat]
-		orb.Point{zone.BottomLeftLatLon[1], zone.BottomLeftLatLon[0]},   // [lon, lat]
-		orb.Point{zone.TopLeftLatLon[1], zone.TopLeftLatLon[0]},         // Close the ring
+// buildZonePolygon creates the zone polygon and its bound, preferring the stored geometry over the four corners
+// Returns the geometry error when an invalid stored geometry was replaced by the corners
+func (s *ZoneService) buildZonePolygon(zone *model.Zone) (*orb.Polygon, *orb.Bound, error) {
+	polygon, err := zone.GeometryPolygon()
+	if polygon == nil {
+		polygon = orb.Polygon{zone.CornersRing()}
 	}
-
-	polygon := orb.Polygon{ring}
 	bound := polygon.Bound()
-	return &polygon, &bound
+	return &polygon, &bound, err
 }
 
 // GetZonesAtPoint returns all zones containing the given point
 func (s *ZoneService) GetZonesAtPoint(lat, lng float64) []*model.Zone {
-	if !s.initialized {
+	if !s.initialized.Load() {
 		return nil
 	}
 
@@ -220,12 +334,14 @@ func (s *ZoneService) GetZonesAtPoint(lat, lng float64) []*model.Zone {
 		[]float64{0.0001, 0.0001}, // Small radius for point search
 	)
 	if err != nil {
-		log.Printf("invalid search rect: %v", err)
+		logx.Warn("invalid search rect: %v", err)
 		return nil
 	}
 
 	// Find candidate zones using the spatial index
+	s.indexMutex.RLock()
 	spatialResults := s.spatialIndex.SearchIntersect(searchRect)
+	s.indexMutex.RUnlock()
 
 	if len(spatialResults) == 0 {
 		return nil
@@ -247,7 +363,7 @@ func (s *ZoneService) GetZonesAtPoint(lat, lng float64) []*model.Zone {
 
 // GetZonesInBounds returns all zones that intersect with the given bounds
 func (s *ZoneService) GetZonesInBounds(minLat, minLng, maxLat, maxLng float64) []*model.Zone {
-	if !s.initialized {
+	if !s.initialized.Load() {
 		return nil
 	}
 
@@ -258,7 +374,9 @@ func (s *ZoneService) GetZonesInBounds(minLat, minLng, maxLat, maxLng float64) [
 	)
 
 	// Find candidate zones using the spatial index
+	s.indexMutex.RLock()
 	spatialResults := s.spatialIndex.SearchIntersect(searchRect)
+	s.indexMutex.RUnlock()
 
 	if len(spatialResults) == 0 {
 		return nil
@@ -274,6 +392,55 @@ func (s *ZoneService) GetZonesInBounds(minLat, minLng, maxLat, maxLng float64) [
 	return result
 }
 
+// GetZonesInRadius returns all zones within radiusMeters of the given point, sorted by distance
+// Distance is measured to the nearest edge of the zone polygon (0 if the point is inside)
+func (s *ZoneService) GetZonesInRadius(lat, lng, radiusMeters float64) []*model.Zone {
+	if !s.initialized.Load() || radiusMeters < 0 {
+		return nil
+	}
+
+	// Convert radius to degrees; longitude degrees shrink towards the poles
+	radiusLat := geoproj.MetersToDegreesLat(radiusMeters, lat)
+	radiusLng := geoproj.MetersToDegreesLon(radiusMeters, lat)
+
+	searchRect, err := rtreego.NewRect(
+		rtreego.Point{lng - radiusLng, lat - radiusLat},
+		[]float64{2*radiusLng + 0.0001, 2*radiusLat + 0.0001},
+	)
+	if err != nil {
+		logx.Warn("invalid search rect: %v", err)
+		return nil
+	}
+
+	s.indexMutex.RLock()
+	spatialResults := s.spatialIndex.SearchIntersect(searchRect)
+	s.indexMutex.RUnlock()
+
+	// Filter candidates by real distance
+	type zoneDistance struct {
+		zone     *model.Zone
+		distance float64
+	}
+	var candidates []zoneDistance
+	for _, item := range spatialResults {
+		zoneSpatial := item.(*ZoneSpatial)
+		distance := util.DistanceToPolygon(*zoneSpatial.Polygon, lat, lng)
+		if distance <= radiusMeters {
+			candidates = append(candidates, zoneDistance{zone: zoneSpatial.Zone, distance: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	result := make([]*model.Zone, len(candidates))
+	for i, candidate := range candidates {
+		result[i] = candidate.zone
+	}
+	return result
+}
+
 // GetEffectsForTarget returns the combined effects for a target at the given position
 func (s *ZoneService) GetEffectsForTarget(lat, lng float64) map[model.TargetParamType]float32 {
 	zones := s.GetZonesAtPoint(lat, lng)
@@ -281,19 +448,75 @@ func (s *ZoneService) GetEffectsForTarget(lat, lng float64) map[model.TargetPara
 		return nil
 	}
 
-	effects := make(map[model.TargetParamType]float32)
+	return s.combineZoneEffects(zones, orb.Point{lng, lat})
+}
 
-	for _, zone := range zones {
-		// Ensure effects are calculated
-		if len(zone.Effects) == 0 {
-			zone.CalculateEffects()
+// GetEffectsForTargets returns the combined effects for many positions at once
+// Points are in [lat, lng] format, results are returned in input order (nil where no zones match)
+// The index read lock is taken once for the whole batch
+func (s *ZoneService) GetEffectsForTargets(points [][2]float64) []map[model.TargetParamType]float32 {
+	results := make([]map[model.TargetParamType]float32, len(points))
+	s.forEachTargetZones(points, func(i int, zones []*model.Zone) {
+		if len(zones) > 0 {
+			results[i] = s.combineZoneEffects(zones, orb.Point{points[i][1], points[i][0]})
+		}
+	})
+	return results
+}
+
+// GetEffectsAndZoneIDsForTargets is GetEffectsForTargets that also returns the IDs of the zones
+// containing each point, sorted, using a single spatial index pass
+func (s *ZoneService) GetEffectsAndZoneIDsForTargets(points [][2]float64) ([]map[model.TargetParamType]float32, [][]string) {
+	effects := make([]map[model.TargetParamType]float32, len(points))
+	zoneIDs := make([][]string, len(points))
+	s.forEachTargetZones(points, func(i int, zones []*model.Zone) {
+		ids := make([]string, len(zones))
+		for j, zone := range zones {
+			ids[j] = zone.ID
 		}
+		sort.Strings(ids)
+		zoneIDs[i] = ids
 
-		for _, effect := range zone.Effects {
-			// Simply add the effect value (positive or negative)
-			effects[effect.ResourceType] += effect.Value
+		if len(zones) > 0 {
+			effects[i] = s.combineZoneEffects(zones, orb.Point{points[i][1], points[i][0]})
 		}
+	})
+	return effects, zoneIDs
+}
+
+// forEachTargetZones calls fn with the zones containing each point ([lat, lng])
+// The zones slice is reused between calls and must not be retained by fn
+func (s *ZoneService) forEachTargetZones(points [][2]float64, fn func(i int, zones []*model.Zone)) {
+	if !s.initialized.Load() {
+		return
 	}
 
-	return effects
+	s.indexMutex.RLock()
+	defer s.indexMutex.RUnlock()
+
+	// Reuse one search rectangle for all points: NewRectFromPoints keeps the corner slices it is given
+	const searchLength = 0.0001 // Small radius for point search
+	minCorner := make(rtreego.Point, 2)
+	maxCorner := make(rtreego.Point, 2)
+	var zones []*model.Zone
+
+	for i, p := range points {
+		minCorner[0], minCorner[1] = p[1], p[0]
+		maxCorner[0], maxCorner[1] = p[1]+searchLength, p[0]+searchLength
+		searchRect, err := rtreego.NewRectFromPoints(minCorner, maxCorner)
+		if err != nil {
+			continue
+		}
+
+		point := orb.Point{p[1], p[0]}
+		zones = zones[:0]
+		for _, item := range s.spatialIndex.SearchIntersect(searchRect) {
+			zoneSpatial := item.(*ZoneSpatial)
+			if util.PointInPolygon(*zoneSpatial.Polygon, point) {
+				zones = append(zones, zoneSpatial.Zone)
+			}
+		}
+
+		fn(i, zones)
+	}
 }
@@ -0,0 +1,165 @@
+package zone
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	mappers "metalink/cmd/osm-zone-parser/mappers"
+	"metalink/internal/model"
+	"metalink/internal/service/storage"
+
+	"github.com/dhconnelly/rtreego"
+)
+
+// newTestZoneService returns an initialized zone service holding the given zones, without PostgreSQL
+func newTestZoneService(zones []*model.Zone) *ZoneService {
+	s := &ZoneService{
+		storage:       storage.NewShardedMemoryStorage[string, *model.Zone](8, nil),
+		spatialIndex:  rtreego.NewTree(2, 25, 50),
+		categoryIndex: make(map[string]map[string]struct{}),
+		effectCaps:    make(map[model.TargetParamType]mappers.EffectCap),
+	}
+	for _, zone := range zones {
+		s.storage.Set(zone.ID, zone)
+	}
+	s.rebuildSpatialIndex()
+	s.initialized.Store(true)
+	return s
+}
+
+// testZone returns a zone covering the given lat/lon rectangle with the given effects
+func testZone(id string, minLat, minLon, maxLat, maxLon float64, effects ...model.ZoneEffect) *model.Zone {
+	return &model.Zone{
+		ID:                id,
+		TopLeftLatLon:     []float64{maxLat, minLon},
+		TopRightLatLon:    []float64{maxLat, maxLon},
+		BottomRightLatLon: []float64{minLat, maxLon},
+		BottomLeftLatLon:  []float64{minLat, minLon},
+		Effects:           effects,
+	}
+}
+
+// gridZones returns rows x cols square zones of size degrees starting at (lat, lon), each with a food effect
+func gridZones(rows, cols int, lat, lon, size float64) []*model.Zone {
+	zones := make([]*model.Zone, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			minLat, minLon := lat+float64(r)*size, lon+float64(c)*size
+			zones = append(zones, testZone(fmt.Sprintf("zone-%03d-%03d", r, c), minLat, minLon, minLat+size, minLon+size,
+				model.ZoneEffect{ResourceType: model.TargetParamTypeFoodSearch, Value: float32(r*cols + c)}))
+		}
+	}
+	return zones
+}
+
+// randomPoints returns n reproducible [lat, lng] points inside the given box
+func randomPoints(n int, minLat, minLon, maxLat, maxLon float64) [][2]float64 {
+	rng := rand.New(rand.NewSource(1))
+	points := make([][2]float64, n)
+	for i := range points {
+		points[i] = [2]float64{minLat + rng.Float64()*(maxLat-minLat), minLon + rng.Float64()*(maxLon-minLon)}
+	}
+	return points
+}
+
+func TestGetEffectsForTargetsMatchesPerPoint(t *testing.T) {
+	s := newTestZoneService(gridZones(10, 10, 40, -100, 0.01))
+	points := randomPoints(1000, 40, -100, 40.1, -99.9)
+	points = append(points, [2]float64{10, 10}) // Outside all zones
+
+	batch := s.GetEffectsForTargets(points)
+	if len(batch) != len(points) {
+		t.Fatalf("got %d results for %d points", len(batch), len(points))
+	}
+	for i, p := range points {
+		single := s.GetEffectsForTarget(p[0], p[1])
+		if len(single) == 0 && len(batch[i]) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(single, batch[i]) {
+			t.Fatalf("point %v: batch %v, per point %v", p, batch[i], single)
+		}
+	}
+	if batch[len(points)-1] != nil {
+		t.Fatalf("point outside all zones got effects %v", batch[len(points)-1])
+	}
+}
+
+func benchmarkZoneService(b *testing.B) (*ZoneService, [][2]float64) {
+	b.Helper()
+	s := newTestZoneService(gridZones(100, 100, 40, -100, 0.01))
+	return s, randomPoints(100000, 40, -100, 41, -99)
+}
+
+func BenchmarkGetEffectsPerCall(b *testing.B) {
+	s, points := benchmarkZoneService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, p := range points {
+			s.GetEffectsForTarget(p[0], p[1])
+		}
+	}
+}
+
+func BenchmarkGetEffectsBatch(b *testing.B) {
+	s, points := benchmarkZoneService(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.GetEffectsForTargets(points)
+	}
+}
+
+// Run with -race: lookups must only read the shared zones, also zones without effects
+func TestGetEffectsForTargetConcurrent(t *testing.T) {
+	zones := gridZones(2, 2, 40, -100, 0.01)
+	zones = append(zones, testZone("no-effects", 40, -100, 40.02, -99.98))
+	zones[len(zones)-1].Buildings.BuildingAreas = map[string]float64{"residential": 1000}
+	s := newTestZoneService(zones)
+
+	want := s.GetEffectsForTarget(40.005, -99.995)
+	if len(want) == 0 {
+		t.Fatal("no effects at the test point")
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if got := s.GetEffectsForTarget(40.005, -99.995); !reflect.DeepEqual(got, want) {
+					t.Errorf("effects changed between calls: %v, want %v", got, want)
+					return
+				}
+				s.GetEffectsForTargets([][2]float64{{40.005, -99.995}, {40.015, -99.985}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if effects := zones[len(zones)-1].Effects; effects != nil {
+		t.Fatalf("the lookup calculated effects %v of a shared zone", effects)
+	}
+}
+
+func TestGetZonesInRadiusAtHighLatitude(t *testing.T) {
+	// At 70°N a degree of longitude is about 38 km, a third of a degree of latitude
+	s := newTestZoneService([]*model.Zone{
+		testZone("east-near", 69.99, 0.02, 70.01, 0.03),   // ~760 m east
+		testZone("east-far", 69.99, 0.03, 70.01, 0.04),    // ~1140 m east
+		testZone("north-near", 70.005, -0.01, 70.006, 0),  // ~560 m north
+		testZone("north-far", 70.01, -0.01, 70.011, 0.01), // ~1120 m north
+	})
+
+	zones := s.GetZonesInRadius(70, 0, 1000)
+	var ids []string
+	for _, zone := range zones {
+		ids = append(ids, zone.ID)
+	}
+	if want := []string{"north-near", "east-near"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("zones within 1 km = %v, want %v nearest first", ids, want)
+	}
+}
@@ -0,0 +1,87 @@
+package zone
+
+import (
+	"metalink/internal/logx"
+	"metalink/internal/model"
+
+	"github.com/dhconnelly/rtreego"
+)
+
+// sameZoneID compares index entries by zone ID, so entries can be removed without the original pointer
+func sameZoneID(obj1, obj2 rtreego.Spatial) bool {
+	return obj1.(*ZoneSpatial).ID == obj2.(*ZoneSpatial).ID
+}
+
+// UpdateZones adds or replaces the given zones without rebuilding the whole spatial or category index
+// Zones should be freshly loaded objects; effects are recalculated for them only
+// Invalid zones are logged and skipped, as on init
+func (s *ZoneService) UpdateZones(zones []*model.Zone) {
+	zones = validZones(zones)
+	if len(zones) == 0 {
+		return
+	}
+
+	// Prepare geometry and effects before taking the index lock
+	for _, zone := range zones {
+		zone.CalculateEffects()
+	}
+	zoneSpatials := s.buildZoneSpatials(zones)
+
+	s.indexMutex.Lock()
+	defer s.indexMutex.Unlock()
+
+	// Old entries are removed for all zones, including degenerate ones that aren't indexed again
+	replaced := 0
+	for _, zone := range zones {
+		if s.removeFromIndex(zone.ID) {
+			replaced++
+		}
+		s.removeFromCategoryIndex(zone.ID)
+		s.storage.Set(zone.ID, zone)
+		addToCategoryIndex(s.categoryIndex, zone)
+	}
+	for _, zoneSpatial := range zoneSpatials {
+		s.spatialIndex.Insert(zoneSpatial)
+	}
+
+	logx.Info("Updated %d zones in spatial index (%d new, %d replaced)", len(zones), len(zones)-replaced, replaced)
+}
+
+// RemoveZones removes zones with the given IDs from storage and the spatial index
+// Unknown IDs are ignored
+func (s *ZoneService) RemoveZones(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	s.indexMutex.Lock()
+	defer s.indexMutex.Unlock()
+
+	removed := 0
+	for _, id := range ids {
+		if s.removeFromIndex(id) {
+			removed++
+		}
+		s.removeFromCategoryIndex(id)
+		s.storage.Delete(id)
+		s.occupancy.Delete(id)
+	}
+
+	logx.Info("Removed %d zones from spatial index", removed)
+}
+
+// removeFromIndex deletes the indexed entry of a stored zone. Must be called with indexMutex held
+func (s *ZoneService) removeFromIndex(id string) bool {
+	existing, ok := s.storage.Get(id)
+	if !ok || existing.BoundingBox == nil {
+		return false
+	}
+
+	// The lookup uses the bounds of the stored zone, which are the ones it was indexed with
+	return s.spatialIndex.DeleteWithComparator(&ZoneSpatial{
+		ID:          id,
+		Polygon:     existing.Polygon,
+		BoundingBox: existing.BoundingBox,
+		Zone:        existing,
+	}, sameZoneID)
+}
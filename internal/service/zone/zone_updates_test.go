@@ -0,0 +1,87 @@
+package zone
+
+import (
+	"testing"
+
+	"metalink/internal/model"
+)
+
+// zoneIDsAt returns the IDs of the zones containing the point
+func zoneIDsAt(s *ZoneService, lat, lng float64) map[string]bool {
+	ids := make(map[string]bool)
+	for _, zone := range s.GetZonesAtPoint(lat, lng) {
+		ids[zone.ID] = true
+	}
+	return ids
+}
+
+func TestUpdateZonesSkipsInvalidZones(t *testing.T) {
+	s := newTestZoneService(nil)
+
+	missingCorner := testZone("missing-corner", 40, -100, 40.01, -99.99)
+	missingCorner.TopLeftLatLon = []float64{40.01}
+	bowtie := testZone("bowtie", 40, -100, 40.01, -99.99)
+	bowtie.TopLeftLatLon, bowtie.TopRightLatLon = bowtie.TopRightLatLon, bowtie.TopLeftLatLon
+	valid := testZone("valid", 40, -100, 40.01, -99.99)
+
+	s.UpdateZones([]*model.Zone{missingCorner, bowtie, valid})
+
+	if ids := zoneIDsAt(s, 40.005, -99.995); len(ids) != 1 || !ids["valid"] {
+		t.Fatalf("zones at the point: %v, want only the valid one", ids)
+	}
+	if s.Count() != 1 {
+		t.Fatalf("%d zones stored, want only the valid one", s.Count())
+	}
+}
+
+func TestUpdateZonesAddsAndReplacesWithoutRebuild(t *testing.T) {
+	s := newTestZoneService(gridZones(2, 2, 40, -100, 0.01))
+	index := s.spatialIndex
+
+	added := testZone("added", 41, -100, 41.01, -99.99)
+	s.UpdateZones([]*model.Zone{added})
+	if ids := zoneIDsAt(s, 41.005, -99.995); !ids["added"] {
+		t.Fatalf("zones at the added zone: %v", ids)
+	}
+	if s.spatialIndex != index {
+		t.Fatal("the spatial index was rebuilt")
+	}
+
+	// The replacement moves the zone, so the old position no longer finds it
+	moved := testZone("added", 42, -100, 42.01, -99.99)
+	s.UpdateZones([]*model.Zone{moved})
+	if ids := zoneIDsAt(s, 41.005, -99.995); ids["added"] {
+		t.Fatal("the replaced zone is still found at its old position")
+	}
+	if ids := zoneIDsAt(s, 42.005, -99.995); !ids["added"] {
+		t.Fatal("the replaced zone isn't found at its new position")
+	}
+	if s.Count() != 5 {
+		t.Fatalf("%d zones stored, want 5", s.Count())
+	}
+}
+
+func TestRemoveZonesDropsIndexEntries(t *testing.T) {
+	hospital := testZone("hospital", 40, -100, 40.01, -99.99)
+	hospital.Buildings.BuildingTypes = map[string]int{"healthcare": 2}
+	s := newTestZoneService([]*model.Zone{hospital})
+	s.UpdateOccupancy([]string{"hospital"}, nil)
+
+	s.RemoveZones([]string{"hospital", "unknown"})
+
+	if ids := zoneIDsAt(s, 40.005, -99.995); len(ids) != 0 {
+		t.Fatalf("removed zone still found: %v", ids)
+	}
+	if zones := s.ZonesWithCategory("healthcare"); len(zones) != 0 {
+		t.Fatalf("category index still has %d zones", len(zones))
+	}
+	if _, ok := s.categoryIndex["healthcare"]; ok {
+		t.Fatal("empty category entry was kept")
+	}
+	if count := s.ZoneOccupancy("hospital"); count != 0 {
+		t.Fatalf("occupancy of the removed zone = %d", count)
+	}
+	if s.Count() != 0 {
+		t.Fatalf("%d zones stored, want 0", s.Count())
+	}
+}
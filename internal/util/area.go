@@ -0,0 +1,68 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AreaUnit is a unit used for area outputs
+type AreaUnit string
+
+const (
+	AreaUnitM2       AreaUnit = "m2"
+	AreaUnitKM2      AreaUnit = "km2"
+	AreaUnitAcres    AreaUnit = "acres"
+	AreaUnitHectares AreaUnit = "hectares"
+)
+
+// Square meters per unit
+const (
+	squareMetersPerKM2     = 1_000_000.0
+	squareMetersPerAcre    = 4046.8564224
+	squareMetersPerHectare = 10_000.0
+)
+
+// ParseAreaUnit parses an area unit name (m2, km2, acres, hectares)
+func ParseAreaUnit(s string) (AreaUnit, error) {
+	switch AreaUnit(strings.ToLower(strings.TrimSpace(s))) {
+	case AreaUnitM2:
+		return AreaUnitM2, nil
+	case AreaUnitKM2:
+		return AreaUnitKM2, nil
+	case AreaUnitAcres:
+		return AreaUnitAcres, nil
+	case AreaUnitHectares:
+		return AreaUnitHectares, nil
+	}
+	return "", fmt.Errorf("unknown area unit %q (expected m2, km2, acres or hectares)", s)
+}
+
+// ConvertArea converts square meters to the given unit
+// The result isn't rounded: exported values are read back by the GeoJSON importer, and a building area
+// rounded to 2 decimals in km2 or hectares would come back as 0
+func ConvertArea(squareMeters float64, unit AreaUnit) float64 {
+	switch unit {
+	case AreaUnitKM2:
+		return squareMeters / squareMetersPerKM2
+	case AreaUnitAcres:
+		return squareMeters / squareMetersPerAcre
+	case AreaUnitHectares:
+		return squareMeters / squareMetersPerHectare
+	default:
+		return squareMeters
+	}
+}
+
+// AreaToSquareMeters converts an area in the given unit back to square meters
+func AreaToSquareMeters(value float64, unit AreaUnit) float64 {
+	switch unit {
+	case AreaUnitKM2:
+		return value * squareMetersPerKM2
+	case AreaUnitAcres:
+		return value * squareMetersPerAcre
+	case AreaUnitHectares:
+		return value * squareMetersPerHectare
+	default:
+		return value
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// ParseBounds parses a bounding box in "minLat,minLng,maxLat,maxLng" format
+// The returned bound is in orb [lon, lat] order
+func ParseBounds(s string) (orb.Bound, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return orb.Bound{}, fmt.Errorf("invalid bounding box %q, expected minLat,minLng,maxLat,maxLng", s)
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return orb.Bound{}, fmt.Errorf("invalid bounding box value %q: %w", part, err)
+		}
+		values[i] = value
+	}
+	minLat, minLng, maxLat, maxLng := values[0], values[1], values[2], values[3]
+
+	if minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+		return orb.Bound{}, fmt.Errorf("invalid bounding box %q: out of range", s)
+	}
+	if minLat >= maxLat || minLng >= maxLng {
+		return orb.Bound{}, fmt.Errorf("invalid bounding box %q: min values must be below max values", s)
+	}
+
+	return orb.Bound{Min: orb.Point{minLng, minLat}, Max: orb.Point{maxLng, maxLat}}, nil
+}
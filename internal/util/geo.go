@@ -1,21 +1,31 @@
 package util
 
 import (
+	"math"
+
+	"metalink/internal/util/geoproj"
+
 	"github.com/golang/geo/s1"
 	"github.com/golang/geo/s2"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/planar"
 )
 
+// MoveToward returns the [lat, lng] point distanceMeters from the start along the great circle to the end
+// Never overshoots: a distance at or beyond the end (or identical start and end) returns the end point exactly,
+// a zero or negative distance returns the start point
 func MoveToward(startLat, startLng, endLat, endLng, distanceMeters float64) [2]float64 {
+	if distanceMeters <= 0 {
+		return [2]float64{startLat, startLng}
+	}
+
 	// Convert degrees to S2 points
 	startPoint := s2.PointFromLatLng(s2.LatLngFromDegrees(startLat, startLng))
 	endPoint := s2.PointFromLatLng(s2.LatLngFromDegrees(endLat, endLng))
 
 	// Calculate total distance between points
 	totalDistanceAngle := s1.Angle(s2.ChordAngleBetweenPoints(startPoint, endPoint).Angle())
-	earthRadiusMeters := 6371000.0
-	totalDistanceMeters := totalDistanceAngle.Radians() * earthRadiusMeters
+	totalDistanceMeters := totalDistanceAngle.Radians() * geoproj.SphereRadius
 
 	// If requested distance exceeds total distance, return end point
 	if distanceMeters >= totalDistanceMeters {
@@ -32,6 +42,8 @@ func MoveToward(startLat, startLng, endLat, endLng, distanceMeters float64) [2]f
 	return [2]float64{newLatLng.Lat.Degrees(), newLatLng.Lng.Degrees()}
 }
 
+// HaversineDistance returns the great-circle distance in meters between two lat/lng points
+// Uses a spherical Earth with a radius of 6371km, so it is within about 0.5% of the ellipsoidal distance
 func HaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	// Convert coordinates from degrees to S2 points
 	point1 := s2.PointFromLatLng(s2.LatLngFromDegrees(lat1, lng1))
@@ -41,12 +53,25 @@ func HaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	angle := s1.Angle(s2.ChordAngleBetweenPoints(point1, point2).Angle())
 
 	// Convert angle to distance on Earth's surface
-	earthRadiusMeters := 6371000.0
-	distanceMeters := angle.Radians() * earthRadiusMeters
+	distanceMeters := angle.Radians() * geoproj.SphereRadius
 
 	return distanceMeters
 }
 
+// Bearing returns the initial great-circle bearing from the first point to the second one
+// Result is in degrees in range [0, 360), where 0 = north, 90 = east
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLonRad := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(deltaLonRad) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLonRad)
+
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
 func PointInPolygon(polygon orb.Polygon, point orb.Point) bool {
 	if !planar.RingContains(polygon[0], point) {
 		return false
@@ -58,3 +83,48 @@ func PointInPolygon(polygon orb.Polygon, point orb.Point) bool {
 	}
 	return true
 }
+
+// MetersToDegrees converts a distance in meters to degrees of longitude at a given latitude
+// Capped at 180 degrees, since near the poles a small distance spans all longitudes
+func MetersToDegrees(meters float64, latitude float64) float64 {
+	return geoproj.MetersToDegreesLon(meters, latitude)
+}
+
+// DistanceToPolygon returns the distance in meters from a point to the nearest edge of a polygon
+// Returns 0 if the point is inside the polygon
+func DistanceToPolygon(polygon orb.Polygon, lat, lng float64) float64 {
+	point := orb.Point{lng, lat}
+	if len(polygon) == 0 {
+		return math.Inf(1)
+	}
+	if PointInPolygon(polygon, point) {
+		return 0
+	}
+
+	// Find the closest point on each edge in a local equirectangular projection,
+	// then measure the real distance to it
+	lonScale := math.Cos(lat * math.Pi / 180)
+	minDistance := math.Inf(1)
+	for _, ring := range polygon {
+		for i := 0; i+1 < len(ring); i++ {
+			a, b := ring[i], ring[i+1]
+
+			ax, ay := (a[0]-lng)*lonScale, a[1]-lat
+			bx, by := (b[0]-lng)*lonScale, b[1]-lat
+			dx, dy := bx-ax, by-ay
+
+			t := 0.0
+			if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+				t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+			}
+
+			closestLng := a[0] + t*(b[0]-a[0])
+			closestLat := a[1] + t*(b[1]-a[1])
+			if distance := HaversineDistance(lat, lng, closestLat, closestLng); distance < minDistance {
+				minDistance = distance
+			}
+		}
+	}
+
+	return minDistance
+}
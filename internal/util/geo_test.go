@@ -0,0 +1,59 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		wantKm                 float64
+	}{
+		{"London-Paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.6},
+		{"New York-Los Angeles", 40.7128, -74.0060, 34.0522, -118.2437, 3936},
+		{"Sydney-Melbourne", -33.8688, 151.2093, -37.8136, 144.9631, 713.8},
+		{"same point", 40, -100, 40, -100, 0},
+	} {
+		got := HaversineDistance(tc.lat1, tc.lng1, tc.lat2, tc.lng2) / 1000
+		if math.Abs(got-tc.wantKm) > tc.wantKm*0.002+0.001 {
+			t.Errorf("%s: %.1f km, want %.1f km", tc.name, got, tc.wantKm)
+		}
+		if back := HaversineDistance(tc.lat2, tc.lng2, tc.lat1, tc.lng1) / 1000; math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: distance is not symmetric (%v and %v)", tc.name, got, back)
+		}
+	}
+}
+
+func TestMoveToward(t *testing.T) {
+	const startLat, startLng, endLat, endLng = 40.0, -100.0, 40.01, -100.0
+
+	// Beyond the end point the result is the end point exactly
+	for _, distance := range []float64{1e9, HaversineDistance(startLat, startLng, endLat, endLng)} {
+		if got := MoveToward(startLat, startLng, endLat, endLng, distance); got != [2]float64{endLat, endLng} {
+			t.Errorf("distance %v: got %v, want the end point", distance, got)
+		}
+	}
+
+	// Zero and negative distances don't move
+	for _, distance := range []float64{0, -50} {
+		if got := MoveToward(startLat, startLng, endLat, endLng, distance); got != [2]float64{startLat, startLng} {
+			t.Errorf("distance %v: got %v, want the start point", distance, got)
+		}
+	}
+
+	// Part of the way the point is on the path at the requested distance
+	got := MoveToward(startLat, startLng, endLat, endLng, 500)
+	if moved := HaversineDistance(startLat, startLng, got[0], got[1]); math.Abs(moved-500) > 0.01 {
+		t.Errorf("moved %v m, want 500 m", moved)
+	}
+	if math.Abs(got[1]-startLng) > 1e-9 || got[0] <= startLat || got[0] >= endLat {
+		t.Errorf("point %v is not between the start and the end", got)
+	}
+
+	// Identical start and end return the end point
+	if got := MoveToward(startLat, startLng, startLat, startLng, 10); got != [2]float64{startLat, startLng} {
+		t.Errorf("identical points: got %v", got)
+	}
+}
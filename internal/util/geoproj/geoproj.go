@@ -0,0 +1,82 @@
+// Package geoproj converts distances in meters to degrees of latitude and longitude
+// The fast default treats the Earth as a sphere; setting Precise switches to the WGS84 ellipsoid,
+// which stays accurate at high latitudes
+package geoproj
+
+import "math"
+
+// Earth models
+const (
+	SphereRadius = 6371000.0 // Mean Earth radius in meters
+
+	wgs84SemiMajorAxis = 6378137.0         // Equatorial radius in meters
+	wgs84Flattening    = 1 / 298.257223563 // (a-b)/a
+)
+
+// wgs84EccentricitySquared is the squared first eccentricity of the WGS84 ellipsoid
+const wgs84EccentricitySquared = wgs84Flattening * (2 - wgs84Flattening)
+
+// Precise makes MetersPerDegree and the conversions built on it use the WGS84 ellipsoid
+// Must be set once at startup, before any conversions
+var Precise bool
+
+// MetersPerDegreeLat returns the length of one degree of latitude at the given latitude on the WGS84 ellipsoid
+func MetersPerDegreeLat(lat float64) float64 {
+	sinLat := math.Sin(lat * math.Pi / 180)
+	w := 1 - wgs84EccentricitySquared*sinLat*sinLat
+	meridionalRadius := wgs84SemiMajorAxis * (1 - wgs84EccentricitySquared) / (w * math.Sqrt(w))
+	return meridionalRadius * math.Pi / 180
+}
+
+// MetersPerDegreeLon returns the length of one degree of longitude at the given latitude on the WGS84 ellipsoid
+func MetersPerDegreeLon(lat float64) float64 {
+	latRad := lat * math.Pi / 180
+	sinLat := math.Sin(latRad)
+	primeVerticalRadius := wgs84SemiMajorAxis / math.Sqrt(1-wgs84EccentricitySquared*sinLat*sinLat)
+	return primeVerticalRadius * math.Cos(latRad) * math.Pi / 180
+}
+
+// SphericalMetersPerDegreeLat returns the length of one degree of latitude on the sphere, the same everywhere
+func SphericalMetersPerDegreeLat() float64 {
+	return SphereRadius * math.Pi / 180
+}
+
+// SphericalMetersPerDegreeLon returns the length of one degree of longitude at the given latitude on the sphere
+func SphericalMetersPerDegreeLon(lat float64) float64 {
+	return SphereRadius * math.Pi / 180 * math.Cos(lat*math.Pi/180)
+}
+
+// MetersPerDegree returns the lengths of one degree of latitude and longitude at the given latitude,
+// on the sphere or, if Precise is set, on the WGS84 ellipsoid
+func MetersPerDegree(lat float64) (latMeters, lonMeters float64) {
+	if Precise {
+		return MetersPerDegreeLat(lat), MetersPerDegreeLon(lat)
+	}
+	return SphericalMetersPerDegreeLat(), SphericalMetersPerDegreeLon(lat)
+}
+
+// MetersToDegreesLat converts a north-south distance in meters at the given latitude to degrees of latitude
+func MetersToDegreesLat(meters, lat float64) float64 {
+	latMeters, _ := MetersPerDegree(lat)
+	return meters / latMeters
+}
+
+// MetersToDegreesLon converts an east-west distance in meters at the given latitude to degrees of longitude
+// Capped at 180 degrees, since near the poles a small distance spans all longitudes
+func MetersToDegreesLon(meters, lat float64) float64 {
+	_, lonMeters := MetersPerDegree(lat)
+	if lonMeters <= 0 {
+		return 180
+	}
+	return math.Min(meters/lonMeters, 180)
+}
+
+// BoundArea returns the area in square meters of the region between two latitudes and two longitudes
+// on the sphere; unlike degrees times meters per degree it stays exact for large regions
+func BoundArea(minLat, minLon, maxLat, maxLon float64) float64 {
+	if maxLat <= minLat || maxLon <= minLon {
+		return 0
+	}
+	dLon := (maxLon - minLon) * math.Pi / 180
+	return SphereRadius * SphereRadius * dLon * (math.Sin(maxLat*math.Pi/180) - math.Sin(minLat*math.Pi/180))
+}
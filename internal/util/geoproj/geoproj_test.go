@@ -0,0 +1,51 @@
+package geoproj
+
+import (
+	"math"
+	"testing"
+)
+
+// WGS84 lengths of one degree in meters, from the standard series expansions
+var referenceDegrees = []struct {
+	lat, latMeters, lonMeters float64
+}{
+	{0, 110574.3, 111319.5},
+	{45, 111131.8, 78846.8},
+	{70, 111562.0, 38186.5},
+}
+
+func TestMetersPerDegreeMatchesWGS84Reference(t *testing.T) {
+	for _, ref := range referenceDegrees {
+		if got := MetersPerDegreeLat(ref.lat); math.Abs(got-ref.latMeters) > 0.5 {
+			t.Errorf("lat %v: degree of latitude = %.1f m, want %.1f", ref.lat, got, ref.latMeters)
+		}
+		if got := MetersPerDegreeLon(ref.lat); math.Abs(got-ref.lonMeters) > 0.5 {
+			t.Errorf("lat %v: degree of longitude = %.1f m, want %.1f", ref.lat, got, ref.lonMeters)
+		}
+	}
+}
+
+func TestMetersPerDegreeSphereAndPrecise(t *testing.T) {
+	defer func(previous bool) { Precise = previous }(Precise)
+
+	Precise = false
+	latMeters, lonMeters := MetersPerDegree(70)
+	if math.Abs(latMeters-111194.9) > 0.5 || math.Abs(lonMeters-38030.9) > 0.5 {
+		t.Fatalf("sphere at lat 70 = %.1f, %.1f m, want 111194.9, 38030.9", latMeters, lonMeters)
+	}
+
+	Precise = true
+	latMeters, lonMeters = MetersPerDegree(70)
+	if latMeters != MetersPerDegreeLat(70) || lonMeters != MetersPerDegreeLon(70) {
+		t.Fatalf("precise at lat 70 = %.1f, %.1f m, want the WGS84 lengths", latMeters, lonMeters)
+	}
+	if degrees := MetersToDegreesLon(38186.5, 70); math.Abs(degrees-1) > 1e-4 {
+		t.Fatalf("38186.5 m at lat 70 = %v degrees of longitude, want 1", degrees)
+	}
+}
+
+func TestMetersToDegreesLonIsCappedAtThePole(t *testing.T) {
+	if degrees := MetersToDegreesLon(1000, 90); degrees != 180 {
+		t.Fatalf("1 km at the pole = %v degrees of longitude, want 180", degrees)
+	}
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	metersPerFoot = 0.3048
+	metersPerInch = 0.0254
+
+	// MetersPerLevel is the assumed height of a single building level
+	MetersPerLevel = 3.0
+)
+
+// metricHeightSuffixes are stripped from metric OSM height values, longest first
+var metricHeightSuffixes = []string{"meters", "metres", "meter", "metre", "m"}
+
+// ParseOSMHeight parses an OSM height value in meters
+// Accepts bare numbers, metric units ("12 m", "25.5 metres") and feet/inches ("40'", "40 ft", "10'6\"")
+func ParseOSMHeight(s string) (float64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.ReplaceAll(s, ",", ".")
+	if s == "" {
+		return 0, false
+	}
+
+	// Feet and optional inches, e.g. 10'6"
+	if feetStr, rest, ok := strings.Cut(s, "'"); ok {
+		feet, err := strconv.ParseFloat(strings.TrimSpace(feetStr), 64)
+		if err != nil {
+			return 0, false
+		}
+		meters := feet * metersPerFoot
+
+		rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "\""))
+		if rest != "" {
+			inches, err := strconv.ParseFloat(rest, 64)
+			if err != nil {
+				return 0, false
+			}
+			meters += inches * metersPerInch
+		}
+		return positiveHeight(meters)
+	}
+
+	for _, suffix := range []string{"feet", "ft"} {
+		if value, ok := strings.CutSuffix(s, suffix); ok {
+			feet, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return 0, false
+			}
+			return positiveHeight(feet * metersPerFoot)
+		}
+	}
+
+	for _, suffix := range metricHeightSuffixes {
+		if value, ok := strings.CutSuffix(s, suffix); ok {
+			s = strings.TrimSpace(value)
+			break
+		}
+	}
+
+	meters, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return positiveHeight(meters)
+}
+
+// positiveHeight accepts only finite heights above zero
+func positiveHeight(meters float64) (float64, bool) {
+	if meters <= 0 || math.IsInf(meters, 0) || math.IsNaN(meters) {
+		return 0, false
+	}
+	return meters, true
+}
+
+// EstimateLevelsFromHeight estimates the number of building levels from its height, at least 1
+func EstimateLevelsFromHeight(meters float64) int {
+	return max(1, int(math.Round(meters/MetersPerLevel)))
+}
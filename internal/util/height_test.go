@@ -0,0 +1,45 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseOSMHeightUnits(t *testing.T) {
+	cases := map[string]float64{
+		"12":          12,
+		" 25.5 ":      25.5,
+		"7,5":         7.5,
+		"12 m":        12,
+		"12m":         12,
+		"25.5 metres": 25.5,
+		"30 meters":   30,
+		"40'":         12.192,
+		"40 ft":       12.192,
+		"40feet":      12.192,
+		"10'6\"":      3.2004,
+	}
+	for input, want := range cases {
+		got, ok := ParseOSMHeight(input)
+		if !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("ParseOSMHeight(%q) = %v, %v, want %v", input, got, ok, want)
+		}
+	}
+}
+
+func TestParseOSMHeightRejectsInvalid(t *testing.T) {
+	for _, input := range []string{"", "tall", "-3", "0", "12 km", "ft", "10'x\"", "NaN", "Inf"} {
+		if got, ok := ParseOSMHeight(input); ok {
+			t.Errorf("ParseOSMHeight(%q) = %v, want rejected", input, got)
+		}
+	}
+}
+
+func TestEstimateLevelsFromHeight(t *testing.T) {
+	cases := map[float64]int{0.5: 1, 3: 1, 4.4: 1, 4.6: 2, 12: 4, 100: 33}
+	for meters, want := range cases {
+		if got := EstimateLevelsFromHeight(meters); got != want {
+			t.Errorf("EstimateLevelsFromHeight(%v) = %d, want %d", meters, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package util
 // DecodePolyline converts an encoded polyline string to a slice of lat/lng coordinates
 // Implementation based on Google's Encoded Polyline Algorithm Format
 // Default precision is 1e-5 (the Google Maps standard)
+// Points are [lat, lng]; an empty string gives nil and a truncated string gives the points decoded before the cut
 func DecodePolyline(encoded string) [][2]float64 {
 	return DecodePolylineWithPrecision(encoded, 1e-5)
 }
@@ -31,7 +32,7 @@ func DecodePolylineWithPrecision(encoded string, precision float64) [][2]float64
 
 		// Handle the sign bit for latitude
 		if result&1 != 0 {
-			lat -= result >> 1
+			lat += ^(result >> 1)
 		} else {
 			lat += result >> 1
 		}
@@ -53,7 +54,7 @@ func DecodePolylineWithPrecision(encoded string, precision float64) [][2]float64
 
 		// Handle the sign bit for longitude
 		if result&1 != 0 {
-			lng -= result >> 1
+			lng += ^(result >> 1)
 		} else {
 			lng += result >> 1
 		}
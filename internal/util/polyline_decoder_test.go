@@ -0,0 +1,39 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	for _, tc := range []struct {
+		encoded string
+		want    [][2]float64
+	}{
+		// Reference example of the polyline algorithm
+		{"_p~iF~ps|U_ulLnnqC_mqNvxq`@", [][2]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}},
+		// Single point with negative coordinates in both axes
+		{"~ps|U~ps|U", [][2]float64{{-120.2, -120.2}}},
+		{"", nil},
+	} {
+		got := DecodePolyline(tc.encoded)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%q: decoded %d points, want %d", tc.encoded, len(got), len(tc.want))
+		}
+		for i := range got {
+			if math.Abs(got[i][0]-tc.want[i][0]) > 1e-9 || math.Abs(got[i][1]-tc.want[i][1]) > 1e-9 {
+				t.Fatalf("%q: point %d = %v, want %v", tc.encoded, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestDecodePolylineRoundTrip(t *testing.T) {
+	points := [][2]float64{{40.71427, -74.00597}, {-33.86785, 151.20732}, {0, 0}, {-0.00001, 0.00001}}
+	got := DecodePolyline(EncodePolyline(points))
+	for i := range points {
+		if math.Abs(got[i][0]-points[i][0]) > 1e-9 || math.Abs(got[i][1]-points[i][1]) > 1e-9 {
+			t.Fatalf("point %d = %v, want %v", i, got[i], points[i])
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"math"
+	"strings"
+)
+
+// EncodePolyline converts [lat, lng] points to an encoded polyline string
+// Uses the default 1e-5 precision, so DecodePolyline gives the points back rounded to 5 decimals
+func EncodePolyline(points [][2]float64) string {
+	var sb strings.Builder
+	prevLat, prevLng := 0, 0
+
+	for _, point := range points {
+		lat := int(math.Round(point[0] * 1e5))
+		lng := int(math.Round(point[1] * 1e5))
+
+		encodePolylineValue(&sb, lat-prevLat)
+		encodePolylineValue(&sb, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+
+	return sb.String()
+}
+
+// encodePolylineValue writes a single signed delta in 5-bit chunks
+func encodePolylineValue(sb *strings.Builder, value int) {
+	// Shift left and invert negative values so the sign ends up in the lowest bit
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+
+	for shifted >= 0x20 {
+		sb.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+	sb.WriteByte(byte(shifted + 63))
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// maxRetryBackoff caps the delay between WithRetry attempts
+const maxRetryBackoff = 30 * time.Second
+
+// WithRetry calls fn until it succeeds or attempts are exhausted, returning the last error
+// The delay starts at backoff and doubles after every failure, up to maxRetryBackoff
+func WithRetry(attempts int, backoff time.Duration, fn func() error) error {
+	return WithRetryContext(context.Background(), attempts, backoff, fn)
+}
+
+// WithRetryContext is WithRetry that stops waiting for the next attempt when ctx is done
+// Returns the last error of fn together with the context error in that case
+func WithRetryContext(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("Attempt %d/%d failed, retrying in %v: %v", attempt, attempts, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry canceled after %d attempts: %w: %w", attempt, ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+
+	return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithRetrySucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	err := WithRetry(3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v after %d calls, want success on the third", err, calls)
+	}
+}
+
+func TestWithRetryReturnsLastError(t *testing.T) {
+	failure := errors.New("permanent")
+	calls := 0
+	err := WithRetry(2, time.Millisecond, func() error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, failure) || calls != 2 {
+		t.Fatalf("err = %v after %d calls, want the last error after 2", err, calls)
+	}
+}
+
+func TestWithRetryContextStopsWaitingWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := errors.New("transient")
+	calls := 0
+
+	start := time.Now()
+	err := WithRetryContext(ctx, 5, time.Hour, func() error {
+		calls++
+		cancel()
+		return failure
+	})
+	if time.Since(start) > time.Second {
+		t.Fatal("retry waited for the backoff after the context was canceled")
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, failure) || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want the canceled and last errors after 1", err, calls)
+	}
+}
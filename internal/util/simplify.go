@@ -0,0 +1,89 @@
+package util
+
+import (
+	"math"
+
+	"metalink/internal/util/geoproj"
+
+	"github.com/paulmach/orb"
+)
+
+// SimplifyRing reduces the vertices of a [lon, lat] ring with Douglas-Peucker, dropping vertices
+// closer than toleranceMeters to the simplified outline
+// Distances are measured in a local equirectangular projection, so the tolerance holds at any latitude.
+// Kept vertices are the original ones, the first and last vertex are always kept (a closed ring stays closed)
+// and a ring that would collapse below 4 positions is returned unchanged
+func SimplifyRing(ring orb.Ring, toleranceMeters float64) orb.Ring {
+	if toleranceMeters <= 0 || len(ring) <= 4 {
+		return ring
+	}
+
+	// Project to meters around the ring's mean latitude
+	meanLat := 0.0
+	for _, p := range ring {
+		meanLat += p[1]
+	}
+	meanLat /= float64(len(ring))
+
+	metersPerDegreeLat, metersPerDegreeLon := geoproj.MetersPerDegree(meanLat)
+	projected := make([]orb.Point, len(ring))
+	for i, p := range ring {
+		projected[i] = orb.Point{(p[0] - ring[0][0]) * metersPerDegreeLon, (p[1] - ring[0][1]) * metersPerDegreeLat}
+	}
+
+	keep := make([]bool, len(ring))
+	keep[0], keep[len(ring)-1] = true, true
+	markDouglasPeucker(projected, keep, toleranceMeters*toleranceMeters)
+
+	simplified := make(orb.Ring, 0, len(ring))
+	for i, p := range ring {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	if len(simplified) < 4 {
+		return ring
+	}
+	return simplified
+}
+
+// markDouglasPeucker marks the points to keep between the first and last point
+// Uses an explicit stack instead of recursion so long rings can't overflow it
+func markDouglasPeucker(points []orb.Point, keep []bool, toleranceSquared float64) {
+	type span struct{ start, end int }
+	stack := []span{{0, len(points) - 1}}
+
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDistance := -1, toleranceSquared
+		for i := s.start + 1; i < s.end; i++ {
+			if d := segmentDistanceSquared(points[i], points[s.start], points[s.end]); d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest < 0 {
+			continue
+		}
+
+		keep[farthest] = true
+		stack = append(stack, span{s.start, farthest}, span{farthest, s.end})
+	}
+}
+
+// segmentDistanceSquared returns the squared planar distance from p to the segment a-b
+// A zero-length segment (e.g. the ends of a closed ring) measures the distance to the point a
+func segmentDistanceSquared(p, a, b orb.Point) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	x, y := a[0], a[1]
+
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / lengthSquared
+		t = math.Max(0, math.Min(1, t))
+		x, y = a[0]+t*dx, a[1]+t*dy
+	}
+
+	dx, dy = p[0]-x, p[1]-y
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"metalink/internal/util/geoproj"
+
+	"github.com/paulmach/orb"
+)
+
+// jitteredCircle returns a closed ring of n vertices on a circle of radiusMeters around (lat, lon),
+// each vertex moved outward by up to jitterMeters
+func jitteredCircle(lat, lon, radiusMeters, jitterMeters float64, n int) orb.Ring {
+	rng := rand.New(rand.NewSource(1))
+	metersPerDegreeLat, metersPerDegreeLon := geoproj.MetersPerDegree(lat)
+	ring := make(orb.Ring, 0, n+1)
+	for i := 0; i < n; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		r := radiusMeters + jitterMeters*rng.Float64()
+		ring = append(ring, orb.Point{lon + r*math.Cos(angle)/metersPerDegreeLon, lat + r*math.Sin(angle)/metersPerDegreeLat})
+	}
+	return append(ring, ring[0])
+}
+
+func TestSimplifyRingReducesDetailedRing(t *testing.T) {
+	for _, lat := range []float64{0, 60} {
+		ring := jitteredCircle(lat, 10, 500, 0.5, 1000)
+
+		previous := len(ring)
+		for _, tolerance := range []float64{0.1, 1, 5, 50} {
+			simplified := SimplifyRing(ring, tolerance)
+			if !simplified.Closed() || len(simplified) < 4 {
+				t.Fatalf("lat %v, tolerance %v: %d positions, closed %v", lat, tolerance, len(simplified), simplified.Closed())
+			}
+			if len(simplified) >= previous {
+				t.Fatalf("lat %v, tolerance %v: %d positions, want fewer than %d", lat, tolerance, len(simplified), previous)
+			}
+			previous = len(simplified)
+		}
+	}
+}
+
+func TestSimplifyRingToleranceIsInMeters(t *testing.T) {
+	// The same shape in meters simplifies to the same vertex count at any latitude
+	equator := SimplifyRing(jitteredCircle(0, 10, 500, 0.5, 1000), 5)
+	north := SimplifyRing(jitteredCircle(60, 10, 500, 0.5, 1000), 5)
+	if diff := len(equator) - len(north); diff < -2 || diff > 2 {
+		t.Fatalf("%d positions at the equator, %d at 60 degrees", len(equator), len(north))
+	}
+}
+
+func TestSimplifyRingKeepsSmallRings(t *testing.T) {
+	ring := jitteredCircle(40, 10, 500, 0, 100)
+	if simplified := SimplifyRing(ring, 10000); len(simplified) != len(ring) {
+		t.Fatalf("ring collapsed to %d positions, want it unchanged", len(simplified))
+	}
+
+	square := orb.Bound{Min: orb.Point{10, 40}, Max: orb.Point{10.001, 40.001}}.ToRing()
+	if simplified := SimplifyRing(square, 1000); len(simplified) != 5 {
+		t.Fatalf("square has %d positions after simplification, want 5", len(simplified))
+	}
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a daily time-of-day window, e.g. 08:00-22:00
+// Windows where End is before Start wrap past midnight
+type TimeWindow struct {
+	Start time.Duration // Offset from midnight
+	End   time.Duration // Offset from midnight
+}
+
+// ParseTimeWindow parses a window in "HH:MM-HH:MM" format
+func ParseTimeWindow(s string) (*TimeWindow, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+
+	start, err := parseTimeOfDay(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window start: %w", err)
+	}
+
+	end, err := parseTimeOfDay(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window end: %w", err)
+	}
+
+	if start == end {
+		return nil, fmt.Errorf("invalid time window %q: start equals end", s)
+	}
+
+	return &TimeWindow{Start: start, End: end}, nil
+}
+
+// Contains reports whether the time of day of t is inside the window
+func (w *TimeWindow) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+
+	// Window wraps past midnight
+	return offset >= w.Start || offset < w.End
+}
+
+// String returns the window in "HH:MM-HH:MM" format
+func (w *TimeWindow) String() string {
+	return formatTimeOfDay(w.Start) + "-" + formatTimeOfDay(w.End)
+}
+
+// parseTimeOfDay parses "HH:MM" into an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatTimeOfDay formats an offset from midnight as "HH:MM"
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -1,8 +1,10 @@
 package util
 
 import (
+	"crypto/sha1"
 	"encoding/base64"
 	"errors"
+	"strconv"
 
 	"github.com/google/uuid"
 )
@@ -24,3 +26,16 @@ func GenerateUUIDWithLength(length int) (string, error) {
 
 	return encoded[:length], nil
 }
+
+// DeterministicIDWithLength generates a reproducible ID from a seed and an index
+// The same seed and index always give the same ID (up to 27 symbols)
+func DeterministicIDWithLength(seed string, index int, length int) (string, error) {
+	sum := sha1.Sum([]byte(seed + ":" + strconv.Itoa(index)))
+	encoded := base64.RawURLEncoding.EncodeToString(sum[:]) // 27 symbols without padding
+
+	if length > len(encoded) {
+		return "", errors.New("requested length exceeds the maximum possible")
+	}
+
+	return encoded[:length], nil
+}
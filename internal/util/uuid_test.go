@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDeterministicIDWithLengthRepeatsForSameSeed(t *testing.T) {
+	for index := 0; index < 100; index++ {
+		first, err := DeterministicIDWithLength("seed", index, 12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := DeterministicIDWithLength("seed", index, 12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second {
+			t.Fatalf("index %d: got %q then %q for the same seed", index, first, second)
+		}
+		if len(first) != 12 {
+			t.Fatalf("index %d: id %q has length %d, want 12", index, first, len(first))
+		}
+	}
+}
+
+func TestDeterministicIDWithLengthDiffersBySeedAndIndex(t *testing.T) {
+	seen := make(map[string]string)
+	for _, seed := range []string{"seed", "other"} {
+		for index := 0; index < 100; index++ {
+			id, err := DeterministicIDWithLength(seed, index, 12)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key := fmt.Sprintf("%s/%d", seed, index)
+			if previous, ok := seen[id]; ok {
+				t.Fatalf("id %q repeated for %s and %s", id, previous, key)
+			}
+			seen[id] = key
+		}
+	}
+}
+
+func TestDeterministicIDWithLengthRejectsTooLong(t *testing.T) {
+	if _, err := DeterministicIDWithLength("seed", 0, 28); err == nil {
+		t.Fatal("expected an error for a length above 27")
+	}
+}